@@ -62,6 +62,11 @@ func (b *FakeCliConnectionBuilder) GetServices(services []plugin_models.GetServi
 	return b
 }
 
+func (b *FakeCliConnectionBuilder) GetOrg(name string, org plugin_models.GetOrg_Model, err error) *FakeCliConnectionBuilder {
+	b.cliConn.GetOrgReturns(org, err) // TODO
+	return b
+}
+
 func (b *FakeCliConnectionBuilder) GetSpace(name string, space plugin_models.GetSpace_Model, err error) *FakeCliConnectionBuilder {
 	b.cliConn.GetSpaceReturns(space, err) // TODO
 	return b
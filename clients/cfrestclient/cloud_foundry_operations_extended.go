@@ -9,7 +9,8 @@ type CloudFoundryOperationsExtended interface {
 	GetAppProcessStatistics(appGuid string) ([]models.ApplicationProcessStatistics, error)
 	GetApplicationRoutes(appGuid string) ([]models.ApplicationRoute, error)
 	GetServiceInstances(mtaId, mtaNamespace, spaceGuid string) ([]models.CloudFoundryServiceInstance, error)
-	GetServiceBindings(serviceName string) ([]models.ServiceBinding, error)
+	GetServiceBindings(serviceGuid string) ([]models.ServiceBinding, error)
+	GetServiceKeys(serviceGuid string) ([]models.ServiceKey, error)
 	GetServiceInstanceByName(serviceName, spaceGuid string) (models.CloudFoundryServiceInstance, error)
 	CreateUserProvidedServiceInstance(serviceName string, spaceGuid string, credentials map[string]string) (models.CloudFoundryServiceInstance, error)
 }
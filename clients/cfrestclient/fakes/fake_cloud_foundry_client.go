@@ -13,6 +13,11 @@ type FakeCloudFoundryClient struct {
 	ServicesErr        error
 	ServiceBindings    []models.ServiceBinding
 	ServiceBindingsErr error
+	ServiceKeys        []models.ServiceKey
+	ServiceKeysErr     error
+
+	// ServiceBindingsByServiceGuid, if set, holds the bindings returned for each service instance instead of ServiceBindings
+	ServiceBindingsByServiceGuid map[string][]models.ServiceBinding
 }
 
 func (f FakeCloudFoundryClient) GetApplications(mtaId, mtaNamespace, spaceGuid string) ([]models.CloudFoundryApplication, error) {
@@ -31,10 +36,17 @@ func (f FakeCloudFoundryClient) GetServiceInstances(mtaId, mtaNamespace, spaceGu
 	return f.Services, f.ServicesErr
 }
 
-func (f FakeCloudFoundryClient) GetServiceBindings(serviceName string) ([]models.ServiceBinding, error) {
+func (f FakeCloudFoundryClient) GetServiceBindings(serviceGuid string) ([]models.ServiceBinding, error) {
+	if f.ServiceBindingsByServiceGuid != nil {
+		return f.ServiceBindingsByServiceGuid[serviceGuid], f.ServiceBindingsErr
+	}
 	return f.ServiceBindings, f.ServiceBindingsErr
 }
 
+func (f FakeCloudFoundryClient) GetServiceKeys(serviceGuid string) ([]models.ServiceKey, error) {
+	return f.ServiceKeys, f.ServiceKeysErr
+}
+
 func (f FakeCloudFoundryClient) GetServiceInstanceByName(serviceName, spaceGuid string) (models.CloudFoundryServiceInstance, error) {
 	return f.Services[0], f.ServiceBindingsErr
 }
@@ -41,9 +41,15 @@ func (c ResilientCloudFoundryRestClient) GetServiceInstances(mtaId, mtaNamespace
 	}, c.MaxRetriesCount, c.RetryInterval)
 }
 
-func (c ResilientCloudFoundryRestClient) GetServiceBindings(serviceName string) ([]models.ServiceBinding, error) {
+func (c ResilientCloudFoundryRestClient) GetServiceBindings(serviceGuid string) ([]models.ServiceBinding, error) {
 	return retryOnError(func() ([]models.ServiceBinding, error) {
-		return c.CloudFoundryRestClient.GetServiceBindings(serviceName)
+		return c.CloudFoundryRestClient.GetServiceBindings(serviceGuid)
+	}, c.MaxRetriesCount, c.RetryInterval)
+}
+
+func (c ResilientCloudFoundryRestClient) GetServiceKeys(serviceGuid string) ([]models.ServiceKey, error) {
+	return retryOnError(func() ([]models.ServiceKey, error) {
+		return c.CloudFoundryRestClient.GetServiceKeys(serviceGuid)
 	}, c.MaxRetriesCount, c.RetryInterval)
 }
 
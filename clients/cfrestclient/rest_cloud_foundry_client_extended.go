@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"code.cloudfoundry.org/cli/v8/plugin"
 	"code.cloudfoundry.org/jsonry"
@@ -104,17 +105,28 @@ func (c CloudFoundryRestClient) GetServiceInstances(mtaId, mtaNamespace, spaceGu
 	return getPaginatedResourcesWithIncluded(getServicesUrl, token, c.isSslDisabled, buildServiceInstance)
 }
 
-func (c CloudFoundryRestClient) GetServiceBindings(serviceName string) ([]models.ServiceBinding, error) {
+func (c CloudFoundryRestClient) GetServiceBindings(serviceGuid string) ([]models.ServiceBinding, error) {
 	token, err := c.cliConn.AccessToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve access token: %s", err)
 	}
 	apiEndpoint, _ := c.cliConn.ApiEndpoint()
 
-	getServiceBindingsUrl := fmt.Sprintf("%s/%sservice_credential_bindings?type=app&include=app&service_instance_names=%s", apiEndpoint, cfBaseUrl, serviceName)
+	getServiceBindingsUrl := fmt.Sprintf("%s/%sservice_credential_bindings?type=app&include=app&service_instance_guids=%s", apiEndpoint, cfBaseUrl, url.QueryEscape(serviceGuid))
 	return getPaginatedResourcesWithIncluded(getServiceBindingsUrl, token, c.isSslDisabled, buildServiceBinding)
 }
 
+func (c CloudFoundryRestClient) GetServiceKeys(serviceGuid string) ([]models.ServiceKey, error) {
+	token, err := c.cliConn.AccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve access token: %s", err)
+	}
+	apiEndpoint, _ := c.cliConn.ApiEndpoint()
+
+	getServiceKeysUrl := fmt.Sprintf("%s/%sservice_credential_bindings?type=key&service_instance_guids=%s", apiEndpoint, cfBaseUrl, url.QueryEscape(serviceGuid))
+	return getPaginatedResources[models.ServiceKey](getServiceKeysUrl, token, c.isSslDisabled)
+}
+
 func (c CloudFoundryRestClient) GetServiceInstanceByName(serviceName, spaceGuid string) (models.CloudFoundryServiceInstance, error) {
 	token, err := c.cliConn.AccessToken()
 	if err != nil {
@@ -1,10 +1,14 @@
 package models
 
 type CloudFoundryApplication struct {
-	Name      string `json:"name"`
-	Guid      string `json:"guid"`
-	State     string `json:"state"`
-	SpaceGuid string `jsonry:"relationships.space.data.guid"`
+	Name        string            `json:"name"`
+	Guid        string            `json:"guid"`
+	State       string            `json:"state"`
+	SpaceGuid   string            `jsonry:"relationships.space.data.guid"`
+	Labels      map[string]string `jsonry:"metadata.labels"`
+	Annotations map[string]string `jsonry:"metadata.annotations"`
+	CreatedAt   string            `json:"created_at"`
+	UpdatedAt   string            `json:"updated_at"`
 }
 
 type AppProcessStatisticsResponse struct {
@@ -50,6 +50,11 @@ type ServiceBinding struct {
 	AppName string `json:"-"`
 }
 
+type ServiceKey struct {
+	Guid string `json:"guid"`
+	Name string `json:"name"`
+}
+
 type ServiceInstanceAuxiliaryContent struct {
 	ServicePlans     []ServicePlan     `json:"service_plans"`
 	ServiceOfferings []ServiceOffering `json:"service_offerings"`
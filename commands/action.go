@@ -5,6 +5,7 @@ import (
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 )
 
@@ -15,20 +16,31 @@ type Action interface {
 
 // GetActionToExecute returns the action to execute specified with action ID
 func GetActionToExecute(actionID, commandName string, monitoringRetries uint) Action {
+	return newActionToExecute(actionID, commandName, monitoringRetries, deploymentHistoryContext{}, operationObservers{}, nil)
+}
+
+// newActionToExecute returns the action specified with action ID, whose monitor records the operation in the
+// deployment history with the history context of the command, passes it to the observers of the command and traces it
+// as a child of the span of the command
+func newActionToExecute(actionID, commandName string, monitoringRetries uint, historyContext deploymentHistoryContext,
+	observers operationObservers, parentSpan *tracing.Span) Action {
 	switch actionID {
 	case "abort":
 		action := newAction(actionID, VerbosityLevelVERBOSE)
 		return &action
 	case "retry":
-		action := newMonitoringAction(actionID, commandName, VerbosityLevelVERBOSE, monitoringRetries)
+		action := newMonitoringAction(actionID, commandName, VerbosityLevelVERBOSE, monitoringRetries, historyContext, observers, parentSpan)
 		return &action
 	case "resume":
-		action := newMonitoringAction(actionID, commandName, VerbosityLevelVERBOSE, monitoringRetries)
+		action := newMonitoringAction(actionID, commandName, VerbosityLevelVERBOSE, monitoringRetries, historyContext, observers, parentSpan)
 		return &action
 	case "monitor":
 		return &MonitorAction{
 			commandName:       commandName,
 			monitoringRetries: monitoringRetries,
+			historyContext:    historyContext,
+			observers:         observers,
+			parentSpan:        parentSpan,
 		}
 	}
 	return nil
@@ -38,11 +50,15 @@ func GetNoRetriesActionToExecute(actionID, commandName string) Action {
 	return GetActionToExecute(actionID, commandName, 0)
 }
 
-func newMonitoringAction(actionID, commandName string, verbosityLevel VerbosityLevel, monitoringRetries uint) monitoringAction {
+func newMonitoringAction(actionID, commandName string, verbosityLevel VerbosityLevel, monitoringRetries uint,
+	historyContext deploymentHistoryContext, observers operationObservers, parentSpan *tracing.Span) monitoringAction {
 	return monitoringAction{
 		action:            newAction(actionID, verbosityLevel),
 		commandName:       commandName,
 		monitoringRetries: monitoringRetries,
+		historyContext:    historyContext,
+		observers:         observers,
+		parentSpan:        parentSpan,
 	}
 }
 
@@ -88,6 +104,9 @@ type monitoringAction struct {
 	action
 	commandName       string
 	monitoringRetries uint
+	historyContext    deploymentHistoryContext
+	observers         operationObservers
+	parentSpan        *tracing.Span
 }
 
 func (a *monitoringAction) Execute(operationID string, mtaClient mtaclient.MtaClientOperations) ExecutionStatus {
@@ -104,7 +123,11 @@ func (a *monitoringAction) Execute(operationID string, mtaClient mtaclient.MtaCl
 		return status
 	}
 
-	return NewExecutionMonitor(a.commandName, operationID, "messages", a.monitoringRetries, operation.Messages, mtaClient).Monitor()
+	return NewExecutionMonitor(a.commandName, operationID, "messages", a.monitoringRetries, operation.Messages, mtaClient).
+		withHistoryContext(a.historyContext).
+		withObservers(a.observers).
+		WithParentSpan(a.parentSpan).
+		Monitor()
 }
 
 func getMonitoringOperation(operationID string, mtaClient mtaclient.MtaClientOperations) (*models.Operation, error) {
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -21,6 +23,7 @@ import (
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient_v2"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/restclient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/log"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 )
@@ -56,6 +59,18 @@ type BaseCommand struct {
 	clientFactory              clients.ClientFactory
 	tokenFactory               baseclient.TokenFactory
 	deployServiceURLCalculator util.DeployServiceURLCalculator
+
+	// promptsDisabled is set by commands which run several operations in parallel, where concurrent prompts would
+	// compete for the standard input
+	promptsDisabled bool
+
+	// Input is the reader from which the answers to the prompts of the command are read, the standard input if nil
+	Input      io.Reader
+	prompt     *ui.Prompt
+	promptOnce sync.Once
+
+	// span is the span of the command execution, the parent of the spans of the operations which the command monitors
+	span *tracing.Span
 }
 
 // Initialize initializes the command with the specified name and CLI connection
@@ -95,6 +110,22 @@ func (c *BaseCommand) Usage(message string) {
 func (c *BaseCommand) Execute(args []string) ExecutionStatus {
 	log.Tracef("Executing command '"+c.name+"': args: '%v'\n", args)
 
+	c.span = tracing.StartSpan("cf " + c.name)
+	status := c.execute(args)
+	if status == Failure {
+		c.span.Fail("The " + c.name + " command failed")
+	}
+	c.span.End()
+	if err := tracing.Flush(); err != nil {
+		ui.Warn(err.Error())
+	}
+	if err := FlushTimingReport(); err != nil {
+		ui.Warn(err.Error())
+	}
+	return status
+}
+
+func (c *BaseCommand) execute(args []string) ExecutionStatus {
 	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
 	flags.SetOutput(io.Discard)
 
@@ -175,6 +206,13 @@ func (c *BaseCommand) GetCFTarget() (util.CloudFoundryTarget, error) {
 
 // ExecuteAction executes the action over the process specified with operationID
 func (c *BaseCommand) ExecuteAction(operationID, actionID string, retries uint, host string, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	return c.executeAction(operationID, actionID, retries, host, cfTarget, deploymentHistoryContext{}, operationObservers{})
+}
+
+// executeAction executes the action like ExecuteAction, records the monitored operation in the deployment history with
+// the history context of the command and passes it to the observers of the command
+func (c *BaseCommand) executeAction(operationID, actionID string, retries uint, host string, cfTarget util.CloudFoundryTarget,
+	historyContext deploymentHistoryContext, observers operationObservers) ExecutionStatus {
 	mtaClient := c.NewMtaClient(host, cfTarget)
 
 	// find ongoing operation by the specified operationID
@@ -190,7 +228,7 @@ func (c *BaseCommand) ExecuteAction(operationID, actionID string, retries uint,
 	}
 
 	// Finds the action specified with the actionID
-	action := GetActionToExecute(actionID, c.name, retries)
+	action := newActionToExecute(actionID, c.name, retries, historyContext, observers, c.span)
 	if action == nil {
 		ui.Failed("Invalid action %s", terminal.EntityNameColor(actionID))
 		return Failure
@@ -263,11 +301,27 @@ func (c *BaseCommand) isConflicting(operation *models.Operation, mtaID string, n
 		operation.AcquiredLock
 }
 
+// getPrompt returns the prompt through which the command asks the user, which is created on first use from the input
+func (c *BaseCommand) getPrompt() *ui.Prompt {
+	c.promptOnce.Do(func() {
+		input := c.Input
+		if input == nil {
+			input = os.Stdin
+		}
+		c.prompt = ui.NewPrompt(input)
+	})
+	return c.prompt
+}
+
 func (c *BaseCommand) shouldAbortConflictingOperation(mtaID string, force bool) bool {
 	if force {
 		return true
 	}
-	return ui.Confirm("There is an ongoing operation for multi-target app %s. Do you want to abort it? (y/n)",
+	if c.promptsDisabled {
+		ui.Warn("There is an ongoing operation for multi-target app %s. Use the -%s option to abort it.", terminal.EntityNameColor(mtaID), forceOpt)
+		return false
+	}
+	return c.getPrompt().Confirm("There is an ongoing operation for multi-target app %s. Do you want to abort it? (y/n)",
 		terminal.EntityNameColor(mtaID))
 }
 
@@ -281,8 +335,11 @@ func newTransport(isSslDisabled bool) http.RoundTripper {
 	// Wrap with User-Agent transport first
 	userAgentTransport := baseclient.NewUserAgentTransport(httpTransport)
 
+	// Then wrap with tracing transport, so that the csrf token requests are traced as well
+	tracingTransport := tracing.NewTransport(userAgentTransport)
+
 	// Then wrap with CSRF transport
-	return &csrf.Transport{Delegate: userAgentTransport, Csrf: &csrfx}
+	return &csrf.Transport{Delegate: tracingTransport, Csrf: &csrfx}
 }
 
 // NewTransportForTesting creates a transport for testing purposes
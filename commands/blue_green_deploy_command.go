@@ -20,7 +20,7 @@ type BlueGreenDeployCommand struct {
 // NewBlueGreenDeployCommand creates a new BlueGreenDeployCommand.
 func NewBlueGreenDeployCommand() *BlueGreenDeployCommand {
 	baseCmd := &BaseCommand{flagsParser: deployCommandLineArgumentsParser{}, flagsValidator: deployCommandFlagsValidator{}}
-	deployCmd := &DeployCommand{baseCmd, blueGreenDeployProcessParametersSetter(), &blueGreenDeployCommandProcessTypeProvider{}, os.Stdin, 30 * time.Second, nil}
+	deployCmd := &DeployCommand{baseCmd, blueGreenDeployProcessParametersSetter(), &blueGreenDeployCommandProcessTypeProvider{}, os.Stdin, 30 * time.Second, nil, util.NewSimpleGetExecutor(), make(chan os.Signal, 1)}
 	bgDeployCmd := &BlueGreenDeployCommand{deployCmd}
 	baseCmd.Command = bgDeployCmd
 	return bgDeployCmd
@@ -33,7 +33,7 @@ func (c *BlueGreenDeployCommand) GetPluginCommand() plugin.Command {
 		HelpText: "Deploy a multi-target app using blue-green deployment",
 		UsageDetails: plugin.Usage{
 			Usage: `Deploy a multi-target app using blue-green deployment
-   cf bg-deploy MTA [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u URL] [-f] [--retries RETRIES] [--no-start] [--namespace NAMESPACE] [--apply-namespace-app-names true/false] [--apply-namespace-service-names true/false] [--apply-namespace-app-routes true/false] [--apply-namespace-as-suffix true/false ] [--delete-services] [--delete-service-keys] [--delete-service-brokers] [--keep-files] [--no-restart-subscribed-apps] [--no-confirm] [--skip-idle-start] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT]
+   cf bg-deploy MTA [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u URL] [-f] [--retries RETRIES] [--no-start] [--namespace NAMESPACE] [--apply-namespace-app-names true/false] [--apply-namespace-service-names true/false] [--apply-namespace-app-routes true/false] [--apply-namespace-as-suffix true/false ] [--delete-services [--override-protection]] [--delete-service-keys] [--delete-service-brokers] [--keep-files] [--no-restart-subscribed-apps] [--no-confirm] [--skip-idle-start] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT] [--smoke-tests] [--smoke-tests-config FILE] [--auto-resume-when-healthy [--health-check-timeout TIMEOUT] [--probe-idle-routes]] [--report junit=PATH|tap=PATH] [--notify-webhook URL]
 
    Perform action on an active deploy operation
    cf deploy -i OPERATION_ID -a ACTION [-u URL] ` + util.UploadEnvHelpText,
@@ -51,6 +51,7 @@ func (c *BlueGreenDeployCommand) GetPluginCommand() plugin.Command {
 				util.GetShortOption(applyNamespaceAppRoutesOpt):                 "Apply namespace to application routes: (true, false)",
 				util.GetShortOption(applyNamespaceAsSuffix):                     "Apply namespace as a suffix rather than a prefix: (true, false)",
 				util.GetShortOption(deleteServicesOpt):                          "Recreate changed services / delete discontinued services",
+				util.GetShortOption(overrideProtectionOpt):                      "Allow deleting the services of a protected multi-target app after typing its ID",
 				util.GetShortOption(deleteServiceKeysOpt):                       "Delete existing service keys and apply the new ones",
 				util.GetShortOption(deleteServiceBrokersOpt):                    "Delete discontinued service brokers",
 				util.GetShortOption(keepFilesOpt):                               "Keep files used for deployment",
@@ -59,12 +60,19 @@ func (c *BlueGreenDeployCommand) GetPluginCommand() plugin.Command {
 				util.GetShortOption(noFailOnMissingPermissionsOpt):              "Do not fail on missing permissions for admin operations",
 				util.GetShortOption(abortOnErrorOpt):                            "Auto-abort the process on any errors",
 				util.GetShortOption(retriesOpt):                                 "Retry the operation N times in case a non-content error occurs (default 3)",
+				util.GetShortOption(reportOpt):                                  "Write the results of the operations to a JUnit XML or TAP report, e.g. junit=report.xml or tap=report.tap",
+				util.GetShortOption(notifyWebhookOpt):                           "Notify the webhook at URL when the operation starts, requires an action, finishes or fails",
 				util.GetShortOption(skipIdleStart):                              "Directly start the new MTA version as 'live', skipping the 'idle' phase of the resources. Do not require further confirmation or testing before deleting the old version",
 				util.GetShortOption(stageTimeoutOpt):                            "Stage app timeout in seconds",
 				util.GetShortOption(uploadTimeoutOpt):                           "Upload app timeout in seconds",
 				util.GetShortOption(taskExecutionTimeoutOpt):                    "Task execution timeout in seconds",
 				util.CombineFullAndShortParameters(startTimeoutOpt, timeoutOpt): "Start app timeout in seconds",
 				util.GetShortOption(shouldBackupPreviousVersionOpt):             "(EXPERIMENTAL) Backup previous version of applications, use new cli command \"rollback-mta\" to rollback to the previous version",
+				util.GetShortOption(autoResumeWhenHealthyOpt):                   "Resume the process in the validation phase once all instances of the idle apps are running, or abort it after the health check timeout",
+				util.GetShortOption(healthCheckTimeoutOpt):                      "Seconds to wait for the idle apps to become healthy before the process is aborted (default 600)",
+				util.GetShortOption(probeIdleRoutesOpt):                         "Require that the routes of the idle apps respond with a successful status code before resuming the process",
+				util.GetShortOption(smokeTestsOpt):                              "Probe the routes of the deployed apps after the deployment has finished and expect a successful status code. If a probe fails, roll back to the backup of the previous version, if one was created",
+				util.GetShortOption(smokeTestsConfigOpt):                        "Run smoke tests and probe the HTTP endpoints declared per module in the YAML file instead of the app routes of these modules",
 			},
 		},
 	}
@@ -4,10 +4,25 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"os"
 	"testing"
 )
 
+var cfHome string
+
 func TestCommands(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Commands Suite")
 }
+
+// The deployment history of the monitored operations is kept out of the home directory of the user
+var _ = BeforeSuite(func() {
+	var err error
+	cfHome, err = os.MkdirTemp("", "cf-home")
+	Expect(err).ToNot(HaveOccurred())
+	os.Setenv("CF_HOME", cfHome)
+})
+
+var _ = AfterSuite(func() {
+	os.RemoveAll(cfHome)
+})
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	autoResumeWhenHealthyOpt = "auto-resume-when-healthy"
+	healthCheckTimeoutOpt    = "health-check-timeout"
+	probeIdleRoutesOpt       = "probe-idle-routes"
+
+	idleAppSuffix           = "-idle"
+	healthCheckPollInterval = 5 * time.Second
+)
+
+// autoResumeWhenHealthy waits until the idle apps of an operation in the testing phase are healthy and resumes the
+// operation through its monitor. If the apps do not become healthy before the deadline, the operation is aborted. An
+// operation without idle apps is left in the testing phase.
+func (c *DeployCommand) autoResumeWhenHealthy(mtaID string, operation *models.Operation, executionMonitor *ExecutionMonitor,
+	flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) (ExecutionStatus, *models.Operation) {
+	if operation == nil || operation.State != models.StateACTIONREQUIRED {
+		return Success, operation
+	}
+
+	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
+	timeout := time.Duration(GetUintOpt(healthCheckTimeoutOpt, flags)) * time.Second
+	ui.Say("\nWaiting up to %s for the idle apps of multi-target app %s to become healthy...", timeout, terminal.EntityNameColor(mtaID))
+	deadline := time.Now().Add(timeout)
+	for {
+		problem := ""
+		apps, err := c.CfClient.GetApplications(mtaID, namespace, cfTarget.Space.Guid)
+		if err != nil {
+			problem = fmt.Sprintf("could not get apps: %s", err)
+		} else if idleApps := getIdleApps(apps); len(idleApps) != 0 {
+			problem = c.checkIdleAppsHealth(idleApps, GetBoolOpt(probeIdleRoutesOpt, flags))
+		} else {
+			ui.Failed("No idle apps of multi-target app %s found, the process is not resumed", terminal.EntityNameColor(mtaID))
+			executionMonitor.reportAvaiableActions(executionMonitor.operationID)
+			return Failure, operation
+		}
+		if problem == "" {
+			ui.Ok()
+			return executionMonitor.resume()
+		}
+		if !time.Now().Add(healthCheckPollInterval).Before(deadline) {
+			ui.Warn("The idle apps did not become healthy within %s: %s", timeout, problem)
+			GetActionToExecute("abort", c.name, 0).Execute(executionMonitor.operationID, executionMonitor.mtaClient)
+			return Failure, operation
+		}
+		time.Sleep(healthCheckPollInterval)
+	}
+}
+
+// checkIdleAppsHealth checks whether all instances of the idle apps are running and, optionally, whether their routes
+// respond with a successful status code. It returns a description of the first problem found, or an empty string if
+// the idle apps are healthy.
+func (c *DeployCommand) checkIdleAppsHealth(apps []models.CloudFoundryApplication, probeRoutes bool) string {
+	for _, app := range apps {
+		processes, err := c.CfClient.GetAppProcessStatistics(app.Guid)
+		if err != nil {
+			return fmt.Sprintf("could not get app %q process statistics: %s", app.Name, err)
+		}
+		if runningInstances := countRunningInstances(processes); len(processes) == 0 || runningInstances != len(processes) {
+			return fmt.Sprintf("%d of %d instances of app %s are running", runningInstances, len(processes), app.Name)
+		}
+		if !probeRoutes {
+			continue
+		}
+		routes, err := c.CfClient.GetApplicationRoutes(app.Guid)
+		if err != nil {
+			return fmt.Sprintf("could not get app %q routes: %s", app.Name, err)
+		}
+		for _, route := range routes {
+			url := "https://" + route.Url
+			statusCode, err := c.HttpGetExecutor.ExecuteGetRequest(url)
+			if err != nil {
+				return fmt.Sprintf("could not probe route %s: %s", url, err)
+			}
+			if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+				return fmt.Sprintf("route %s responded with status code %d", url, statusCode)
+			}
+		}
+	}
+	return ""
+}
+
+func getIdleApps(apps []models.CloudFoundryApplication) []models.CloudFoundryApplication {
+	var idleApps []models.CloudFoundryApplication
+	for _, app := range apps {
+		if strings.HasSuffix(app.Name, idleAppSuffix) {
+			idleApps = append(idleApps, app)
+		}
+	}
+	return idleApps
+}
+
+func countRunningInstances(processes []models.ApplicationProcessStatistics) int {
+	runningInstances := 0
+	for _, process := range processes {
+		if process.State == "RUNNING" {
+			runningInstances++
+		}
+	}
+	return runningInstances
+}
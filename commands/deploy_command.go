@@ -6,6 +6,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"net/http"
 	"net/url"
@@ -28,6 +29,7 @@ import (
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	"golang.org/x/term"
 	"gopkg.in/cheggaaa/pb.v1"
 )
 
@@ -58,6 +60,7 @@ const (
 	shouldBackupPreviousVersionOpt   = "backup-previous-version"
 	requireSecureParameters          = "require-secure-parameters"
 	disposableUserProvidedServiceOpt = "disposable-user-provided-service"
+	changedSinceOpt                  = "changed-since"
 )
 
 type listFlag struct {
@@ -93,12 +96,15 @@ type DeployCommand struct {
 	FileUrlReader      fs.File
 	FileUrlReadTimeout time.Duration
 	CfClient           cfrestclient.CloudFoundryOperationsExtended
+	HttpGetExecutor    util.HttpSimpleGetExecutor
+	// Interrupts receives the interrupt signals which stop the watch mode
+	Interrupts chan os.Signal
 }
 
 // NewDeployCommand creates a new deploy command.
 func NewDeployCommand() *DeployCommand {
 	baseCmd := &BaseCommand{flagsParser: deployCommandLineArgumentsParser{}, flagsValidator: deployCommandFlagsValidator{}}
-	deployCmd := &DeployCommand{baseCmd, deployProcessParametersSetter(), &deployCommandProcessTypeProvider{}, os.Stdin, 30 * time.Second, nil}
+	deployCmd := &DeployCommand{baseCmd, deployProcessParametersSetter(), &deployCommandProcessTypeProvider{}, os.Stdin, 30 * time.Second, nil, util.NewSimpleGetExecutor(), make(chan os.Signal, 1)}
 	baseCmd.Command = deployCmd
 	return deployCmd
 }
@@ -117,13 +123,22 @@ func (c *DeployCommand) GetPluginCommand() plugin.Command {
 		UsageDetails: plugin.Usage{
 			Usage: `Deploy a multi-target app archive
 
-   cf deploy MTA [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u URL] [-f] [--retries RETRIES] [--no-start] [--namespace NAMESPACE] [--apply-namespace-app-names true/false] [--apply-namespace-service-names true/false] [--apply-namespace-app-routes true/false] [--apply-namespace-as-suffix true/false ] [--delete-services] [--delete-service-keys] [--delete-service-brokers] [--keep-files] [--no-restart-subscribed-apps] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--strategy STRATEGY] [--skip-testing-phase] [--skip-idle-start] [--require-secure-parameters] [--disposable-user-provided-service] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT]
+   cf deploy MTA [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u URL] [-f] [--retries RETRIES] [--no-start] [--namespace NAMESPACE] [--apply-namespace-app-names true/false] [--apply-namespace-service-names true/false] [--apply-namespace-app-routes true/false] [--apply-namespace-as-suffix true/false ] [--delete-services [--override-protection]] [--delete-service-keys] [--delete-service-brokers] [--keep-files] [--no-restart-subscribed-apps] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--strategy STRATEGY] [--skip-testing-phase] [--skip-idle-start] [--require-secure-parameters] [--disposable-user-provided-service] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT] [--smoke-tests] [--smoke-tests-config FILE] [--auto-resume-when-healthy [--health-check-timeout TIMEOUT] [--probe-idle-routes]] [--report junit=PATH|tap=PATH] [--notify-webhook URL]
+
+   Deploy a multi-target app archive or directory to several spaces
+   cf deploy [MTA] --targets ORG/SPACE[,...] [--max-parallel-targets N] [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u URL] [-f] [--retries RETRIES] [--namespace NAMESPACE] [--strategy STRATEGY]
+
+   Deploy the modules and resources of a multi-target app directory which have changed since a git ref
+   cf deploy [DIRECTORY] --changed-since REF [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--namespace NAMESPACE] [-u URL] [-f] [--retries RETRIES] [--strategy STRATEGY]
+
+   Deploy a multi-target app directory and redeploy its changed modules on every change
+   cf deploy [DIRECTORY] --watch [--watch-debounce SECONDS] [-e EXT_DESCRIPTOR[,...]] [-m MODULE ...] [-r RESOURCE ...] [--namespace NAMESPACE] [-u URL] [-f] [--retries RETRIES]
 
    Perform action on an active deploy operation
    cf deploy -i OPERATION_ID -a ACTION [-u URL]
 
    Deploy a multi-target app archive referenced by a remote URL
-   <write MTA archive URL to STDOUT> | cf deploy [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u MTA_CONTROLLER_URL] [--retries RETRIES] [--no-start] [--namespace NAMESPACE] [--apply-namespace-app-names true/false] [--apply-namespace-service-names true/false] [--apply-namespace-app-routes true/false] [--apply-namespace-as-suffix true/false ] [--delete-services] [--delete-service-keys] [--delete-service-brokers] [--keep-files] [--no-restart-subscribed-apps] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--strategy STRATEGY] [--skip-testing-phase] [--skip-idle-start] [require-secure-parameters] [--disposable-user-provided-service] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT]` + util.UploadEnvHelpText,
+   <write MTA archive URL to STDOUT> | cf deploy [-e EXT_DESCRIPTOR[,...]] [-t TIMEOUT] [--version-rule VERSION_RULE] [-u MTA_CONTROLLER_URL] [--retries RETRIES] [--no-start] [--namespace NAMESPACE] [--apply-namespace-app-names true/false] [--apply-namespace-service-names true/false] [--apply-namespace-app-routes true/false] [--apply-namespace-as-suffix true/false ] [--delete-services [--override-protection]] [--delete-service-keys] [--delete-service-brokers] [--keep-files] [--no-restart-subscribed-apps] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--strategy STRATEGY] [--skip-testing-phase] [--skip-idle-start] [require-secure-parameters] [--disposable-user-provided-service] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT]` + util.UploadEnvHelpText,
 
 			Options: map[string]string{
 				extDescriptorsOpt:                 "Extension descriptors",
@@ -141,6 +156,7 @@ func (c *DeployCommand) GetPluginCommand() plugin.Command {
 				util.GetShortOption(applyNamespaceAppRoutesOpt):                 "Apply namespace to application routes: (true, false)",
 				util.GetShortOption(applyNamespaceAsSuffix):                     "Apply namespace as a suffix rather than a prefix: (true, false)",
 				util.GetShortOption(deleteServicesOpt):                          "Recreate changed services / delete discontinued services",
+				util.GetShortOption(overrideProtectionOpt):                      "Allow deleting the services of a protected multi-target app after typing its ID",
 				util.GetShortOption(deleteServiceKeysOpt):                       "Delete existing service keys and apply the new ones",
 				util.GetShortOption(deleteServiceBrokersOpt):                    "Delete discontinued service brokers",
 				util.GetShortOption(keepFilesOpt):                               "Keep files used for deployment",
@@ -150,6 +166,8 @@ func (c *DeployCommand) GetPluginCommand() plugin.Command {
 				util.GetShortOption(allModulesOpt):                              "Deploy all modules which are contained in the deployment descriptor, in the current location",
 				util.GetShortOption(allResourcesOpt):                            "Deploy all resources which are contained in the deployment descriptor, in the current location",
 				util.GetShortOption(retriesOpt):                                 "Retry the operation N times in case a non-content error occurs (default 3)",
+				util.GetShortOption(reportOpt):                                  "Write the results of the operations to a JUnit XML or TAP report, e.g. junit=report.xml or tap=report.tap. Cannot be combined with targets or watch",
+				util.GetShortOption(notifyWebhookOpt):                           "Notify the webhook at URL when the operation starts, requires an action, finishes or fails. Cannot be combined with targets or watch",
 				util.GetShortOption(strategyOpt):                                "Specify the deployment strategy when updating an mta (default, blue-green, incremental-blue-green)",
 				util.GetShortOption(skipTestingPhase):                           "(STRATEGY: BLUE-GREEN, INCREMENTAL-BLUE-GREEN) Do not require confirmation for deleting the previously deployed MTA app",
 				util.GetShortOption(skipIdleStart):                              "(STRATEGY: BLUE-GREEN, INCREMENTAL-BLUE-GREEN) Directly start the new MTA version as 'live', skipping the 'idle' phase of the resources. Do not require further confirmation or testing before deleting the old version",
@@ -161,6 +179,16 @@ func (c *DeployCommand) GetPluginCommand() plugin.Command {
 				util.GetShortOption(dependencyAwareStopOrderOpt):                "(EXPERIMENTAL) (STRATEGY: BLUE-GREEN, INCREMENTAL-BLUE-GREEN) Stop apps in a dependency-aware order during the resume phase of a blue-green deployment",
 				util.GetShortOption(requireSecureParameters):                    "(EXPERIMENTAL) Pass secrets to the deploy service in a secure way",
 				util.GetShortOption(disposableUserProvidedServiceOpt):           "Deploy when --require-secure-parameters flag is active for disposable UPS to be created and then deleted at the of the operation",
+				util.GetShortOption(changedSinceOpt):                            "Deploy only the modules and resources of the directory which have changed since the git ref, and the modules which require the changed resources",
+				util.GetShortOption(targetsOpt):                                 "Deploy to each of the comma-separated ORG/SPACE targets instead of the currently targeted space",
+				util.GetShortOption(maxParallelTargetsOpt):                      "Number of targets to deploy to in parallel (default 1). With more than one, conflicting processes are only aborted with -f",
+				util.GetShortOption(autoResumeWhenHealthyOpt):                   "(STRATEGY: BLUE-GREEN, INCREMENTAL-BLUE-GREEN) Resume the process in the testing phase once all instances of the idle apps are running, or abort it after the health check timeout",
+				util.GetShortOption(healthCheckTimeoutOpt):                      "Seconds to wait for the idle apps to become healthy before the process is aborted (default 600)",
+				util.GetShortOption(probeIdleRoutesOpt):                         "Require that the routes of the idle apps respond with a successful status code before resuming the process",
+				util.GetShortOption(smokeTestsOpt):                              "Probe the routes of the deployed apps after the deployment has finished and expect a successful status code. If a probe fails, roll back to the backup of the previous version, if one was created",
+				util.GetShortOption(smokeTestsConfigOpt):                        "Run smoke tests and probe the HTTP endpoints declared per module in the YAML file instead of the app routes of these modules",
+				util.GetShortOption(watchOpt):                                   "Watch the module paths of the multi-target app directory and redeploy the changed modules, aborting a still running previous deployment",
+				util.GetShortOption(watchDebounceOpt):                           "Seconds without further changes to wait for before redeploying in watch mode (default 2)",
 			},
 		},
 	}
@@ -215,6 +243,7 @@ func (c *DeployCommand) defineCommandOptions(flags *flag.FlagSet) {
 	flags.Bool(forceOpt, false, "")
 	flags.String(versionRuleOpt, "", "")
 	flags.Bool(deleteServicesOpt, false, "")
+	flags.Bool(overrideProtectionOpt, false, "")
 	flags.Bool(noStartOpt, false, "")
 	flags.String(namespaceOpt, "", "")
 	flags.String(applyNamespaceAppNamesOpt, "", "")
@@ -231,6 +260,8 @@ func (c *DeployCommand) defineCommandOptions(flags *flag.FlagSet) {
 	flags.Bool(allModulesOpt, false, "")
 	flags.Bool(allResourcesOpt, false, "")
 	flags.Uint(retriesOpt, 3, "")
+	flags.String(reportOpt, "", "")
+	flags.String(notifyWebhookOpt, "", "")
 	flags.String(strategyOpt, "default", "")
 	flags.Bool(skipTestingPhase, false, "")
 	flags.Bool(skipIdleStart, false, "")
@@ -245,18 +276,69 @@ func (c *DeployCommand) defineCommandOptions(flags *flag.FlagSet) {
 	flags.Bool(dependencyAwareStopOrderOpt, false, "")
 	flags.Bool(requireSecureParameters, false, "")
 	flags.Bool(disposableUserProvidedServiceOpt, false, "")
+	flags.String(changedSinceOpt, "", "")
+	flags.String(targetsOpt, "", "")
+	flags.Uint(maxParallelTargetsOpt, 1, "")
+	flags.Bool(watchOpt, false, "")
+	flags.Uint(watchDebounceOpt, 2, "")
+	flags.Bool(smokeTestsOpt, false, "")
+	flags.String(smokeTestsConfigOpt, "", "")
+	flags.Bool(autoResumeWhenHealthyOpt, false, "")
+	flags.Uint(healthCheckTimeoutOpt, 600, "")
+	flags.Bool(probeIdleRoutesOpt, false, "")
+}
+
+// parseDeployFlags creates the flags of the deploy command and parses and validates the specified arguments with them.
+// It is used by the commands which deploy MTAs through the pipeline of the deploy command.
+func (c *DeployCommand) parseDeployFlags(args []string) (*flag.FlagSet, error) {
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.String(deployServiceURLOpt, "", "")
+	c.defineCommandOptions(flags)
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return flags, deployCommandFlagsValidator{}.ValidateParsedFlags(flags)
 }
 
 func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	observers, err := newOperationObservers(c.name, flags)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	status := c.executeDeployment(positionalArgs, dsHost, flags, cfTarget, observers)
+	observers.report.write(status)
+	return status
+}
+
+func (c *DeployCommand) executeDeployment(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget, observers operationObservers) ExecutionStatus {
 	operationID := GetStringOpt(operationIDOpt, flags)
 	action := GetStringOpt(actionOpt, flags)
 	retries := GetUintOpt(retriesOpt, flags)
 
 	if operationID != "" || action != "" {
-		return c.ExecuteAction(operationID, action, retries, dsHost, cfTarget)
+		return c.executeAction(operationID, action, retries, dsHost, cfTarget, c.getDeploymentHistoryContext(nil, flags, cfTarget), observers)
+	}
+
+	if GetBoolOpt(watchOpt, flags) {
+		return c.executeInWatchMode(positionalArgs, dsHost, flags, cfTarget)
 	}
 
 	mtaElementsCalculator := createMtaElementsCalculator(flags)
+	if ref := GetStringOpt(changedSinceOpt, flags); ref != "" {
+		changedElementsCalculator, hasChanges, err := createChangedSinceElementsCalculator(positionalArgs, ref)
+		if err != nil {
+			ui.Failed("Error retrieving MTA: %s", err.Error())
+			return Failure
+		}
+		if !hasChanges {
+			ui.Say("No modules or resources have changed since %s, nothing to deploy.", terminal.EntityNameColor(ref))
+			return Success
+		}
+		mtaElementsCalculator = changedElementsCalculator
+	}
 
 	rawMtaArchive, err := c.getMtaArchive(positionalArgs, mtaElementsCalculator)
 	if err != nil {
@@ -264,6 +346,50 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 		return Failure
 	}
 
+	if GetStringOpt(targetsOpt, flags) != "" {
+		return c.deployToTargets(rawMtaArchive, mtaElementsCalculator, dsHost, flags, cfTarget)
+	}
+
+	smokeTestsConfig, err := getSmokeTestsConfig(flags)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// TODO: ensure session
+	mtaClient := c.NewMtaClient(dsHost, cfTarget)
+
+	uploadStartTime := time.Now()
+	mtaID, operationLocation, status := c.deployMtaArchive(rawMtaArchive, mtaElementsCalculator, GetBoolOpt(forceOpt, flags), mtaClient, dsHost, flags, cfTarget)
+	if status == Failure {
+		return Failure
+	}
+	executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, operationLocation, retries, []*models.Message{}, mtaClient).
+		withHistoryContext(c.getDeploymentHistoryContext(rawMtaArchive, flags, cfTarget)).
+		withUploadStart(uploadStartTime).
+		withObservers(observers).
+		WithParentSpan(c.span)
+	if !GetBoolOpt(autoResumeWhenHealthyOpt, flags) && c.isInteractiveTerminal() {
+		executionMonitor.EnableActionPrompt(c.CfClient, c.getPrompt())
+	}
+	status, operation := executionMonitor.monitorOperation()
+	if status == Success && GetBoolOpt(autoResumeWhenHealthyOpt, flags) {
+		status, operation = c.autoResumeWhenHealthy(mtaID, operation, executionMonitor, flags, cfTarget)
+	}
+	if status == Failure || !shouldRunSmokeTests(flags) {
+		return status
+	}
+	if operation == nil || operation.State != models.StateFINISHED {
+		ui.Warn("The smoke tests were not run, because the process has not finished")
+		return status
+	}
+	return c.executeSmokeTests(mtaID, smokeTestsConfig, mtaClient, dsHost, flags, cfTarget)
+}
+
+// deployMtaArchive uploads the MTA archive and the extension descriptors and starts a deploy process for them.
+// It returns the MTA ID and the monitoring location of the started operation.
+func (c *DeployCommand) deployMtaArchive(rawMtaArchive interface{}, mtaElementsCalculator mtaElementsToAddCalculator, force bool,
+	mtaClient mtaclient.MtaClientOperations, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) (string, string, ExecutionStatus) {
 	isUrl, mtaArchive := parseMtaArchiveArgument(rawMtaArchive)
 
 	mtaNameToPrint := terminal.EntityNameColor(mtaArchive)
@@ -281,12 +407,7 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 	var mtaId string
 	var disposableUserProvidedServiceName string
 
-	// Check SLMP metadata
-	// TODO: ensure session
-	mtaClient := c.NewMtaClient(dsHost, cfTarget)
-
 	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
-	force := GetBoolOpt(forceOpt, flags)
 	conf := configuration.NewSnapshot()
 	uploadChunkSize := conf.GetUploadChunkSizeInMB()
 	sequentialUpload := conf.GetUploadChunksSequentially()
@@ -300,17 +421,22 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 
 		asyncUploadJobResult := c.uploadFromUrl(mtaArchive, mtaClient, namespace, disableProgressBar)
 		if asyncUploadJobResult.ExecutionStatus == Failure {
-			return Failure
+			return "", "", Failure
 		}
 		mtaId, fileId, schemaVersion = asyncUploadJobResult.MtaId, asyncUploadJobResult.FileId, asyncUploadJobResult.SchemaVersion
+		// The ID of an MTA archive from a URL is only known once the deploy service has uploaded it
+		if !c.isServicesDeletionAllowed(mtaId, namespace, flags, cfTarget) {
+			return "", "", Failure
+		}
+
 		// Check for an ongoing operation for this MTA ID and abort it
 		wasAborted, err := c.CheckOngoingOperation(mtaId, namespace, dsHost, force, cfTarget)
 		if err != nil {
 			ui.Failed("Could not get MTA operations: %s", baseclient.NewClientError(err))
-			return Failure
+			return "", "", Failure
 		}
 		if !wasAborted {
-			return Failure
+			return "", "", Failure
 		}
 
 		uploadedArchivePartIds = append(uploadedArchivePartIds, fileId)
@@ -318,7 +444,7 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 		if GetBoolOpt(requireSecureParameters, flags) {
 			result := setUpSpecificsForDeploymentUsingSecrets(flags, c, mtaId, namespace, schemaVersion, &disposableUserProvidedServiceName, &yamlBytes)
 			if result != Success {
-				return Failure
+				return "", "", Failure
 			}
 		}
 
@@ -328,44 +454,84 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 		mtaArchivePath, err := filepath.Abs(mtaArchive)
 		if err != nil {
 			ui.Failed("Could not get absolute path of file %q", mtaArchive)
-			return Failure
+			return "", "", Failure
 		}
 
 		// Extract mta id from archive file
 		descriptor, err := util.GetMtaDescriptorFromArchive(mtaArchivePath)
 		if os.IsNotExist(err) {
 			ui.Failed("Could not find file %s", terminal.EntityNameColor(mtaArchivePath))
-			return Failure
+			return "", "", Failure
 		} else if err != nil {
 			ui.Failed("Could not get MTA ID from deployment descriptor: %s", err)
-			return Failure
+			return "", "", Failure
 		}
 		mtaId = descriptor.ID
 
+		// The protection is checked before anything is changed on the deploy service
+		if !c.isServicesDeletionAllowed(mtaId, namespace, flags, cfTarget) {
+			return "", "", Failure
+		}
+
 		// Check for an ongoing operation for this MTA ID and abort it
 		wasAborted, err := c.CheckOngoingOperation(mtaId, namespace, dsHost, force, cfTarget)
 		if err != nil {
 			ui.Failed("Could not get MTA operations: %s", baseclient.NewClientError(err))
-			return Failure
+			return "", "", Failure
 		}
 		if !wasAborted {
-			return Failure
+			return "", "", Failure
 		}
 
 		if GetBoolOpt(requireSecureParameters, flags) {
 			result := setUpSpecificsForDeploymentUsingSecrets(flags, c, mtaId, namespace, descriptor.SchemaVersion, &disposableUserProvidedServiceName, &yamlBytes)
 			if result != Success {
-				return Failure
+				return "", "", Failure
 			}
 		}
 
 		// Upload the MTA archive file
 		uploadedArchivePartIds, uploadStatus = c.uploadFiles([]string{mtaArchivePath}, fileUploader)
 		if uploadStatus == Failure {
-			return Failure
+			return "", "", Failure
+		}
+	}
+
+	uploadedExtDescriptorIDs, uploadStatus := c.uploadExtDescriptors(fileUploader, flags)
+	if uploadStatus == Failure {
+		return "", "", Failure
+	}
+
+	if GetBoolOpt(requireSecureParameters, flags) {
+		secureFileID, err := fileUploader.UploadBytes("__mta.secure.mtaext", yamlBytes)
+		if err != nil {
+			ui.Failed("Could not upload secure extension: %s", err)
+			return "", "", Failure
 		}
+		uploadedExtDescriptorIDs = append(uploadedExtDescriptorIDs, secureFileID)
+	}
+
+	operationLocation, status := c.startDeployOperation(mtaId, namespace, uploadedArchivePartIds, uploadedExtDescriptorIDs, disposableUserProvidedServiceName,
+		mtaElementsCalculator, mtaClient, flags)
+	return mtaId, operationLocation, status
+}
+
+// isServicesDeletionAllowed checks the protection of the MTA if the deployment deletes its services
+func (c *DeployCommand) isServicesDeletionAllowed(mtaID, namespace string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) bool {
+	if !GetBoolOpt(deleteServicesOpt, flags) {
+		return true
+	}
+	// Concurrent prompts would compete for the standard input
+	var prompt *ui.Prompt
+	if !c.promptsDisabled {
+		prompt = c.getPrompt()
 	}
+	return ensureProtectionOverridden(mtaID, namespace, "a deployment which deletes services", c.CfClient, prompt, flags, cfTarget)
+}
 
+// uploadExtDescriptors uploads the extension descriptors specified with the extension descriptors option and returns
+// the IDs of the uploaded files
+func (c *DeployCommand) uploadExtDescriptors(fileUploader *FileUploader, flags *flag.FlagSet) ([]string, ExecutionStatus) {
 	extDescriptors := GetStringOpt(extDescriptorsOpt, flags)
 	// Get the full paths of the extension descriptors
 	var extDescriptorPaths []string
@@ -375,26 +541,19 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 			extDescriptorPath, err := filepath.Abs(extDescriptorFile)
 			if err != nil {
 				ui.Failed("Could not get absolute path of file %q", extDescriptorFile)
-				return Failure
+				return nil, Failure
 			}
 			extDescriptorPaths = append(extDescriptorPaths, extDescriptorPath)
 		}
 	}
 	// Upload the extension descriptor files
-	uploadedExtDescriptorIDs, uploadStatus := c.uploadFiles(extDescriptorPaths, fileUploader)
-	if uploadStatus == Failure {
-		return Failure
-	}
-
-	if GetBoolOpt(requireSecureParameters, flags) {
-		secureFileID, err := fileUploader.UploadBytes("__mta.secure.mtaext", yamlBytes)
-		if err != nil {
-			ui.Failed("Could not upload secure extension: %s", err)
-			return Failure
-		}
-		uploadedExtDescriptorIDs = append(uploadedExtDescriptorIDs, secureFileID)
-	}
+	return c.uploadFiles(extDescriptorPaths, fileUploader)
+}
 
+// startDeployOperation starts a deploy process for the uploaded archive and extension descriptors and returns the
+// monitoring location of the started operation
+func (c *DeployCommand) startDeployOperation(mtaId, namespace string, archivePartIds, extDescriptorIDs []string, disposableUserProvidedServiceName string,
+	mtaElementsCalculator mtaElementsToAddCalculator, mtaClient mtaclient.MtaClientOperations, flags *flag.FlagSet) (string, ExecutionStatus) {
 	// Build the process instance
 	processBuilder := NewDeploymentStrategy(flags, c.processTypeProvider).CreateProcessBuilder()
 	processBuilder.Namespace(namespace)
@@ -403,11 +562,11 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 	processBuilder.Parameter("applyNamespaceAppRoutes", GetStringOpt(applyNamespaceAppRoutesOpt, flags))
 	processBuilder.Parameter("applyNamespaceAsSuffix", GetStringOpt(applyNamespaceAsSuffix, flags))
 
-	processBuilder.Parameter("appArchiveId", strings.Join(uploadedArchivePartIds, ","))
-	processBuilder.Parameter("mtaExtDescriptorId", strings.Join(uploadedExtDescriptorIDs, ","))
+	processBuilder.Parameter("appArchiveId", strings.Join(archivePartIds, ","))
+	processBuilder.Parameter("mtaExtDescriptorId", strings.Join(extDescriptorIDs, ","))
 	processBuilder.Parameter("mtaId", mtaId)
 	processBuilder.Parameter("disposableUserProvidedServiceName", disposableUserProvidedServiceName)
-	setModulesAndResourcesListParameters(modulesList, resourcesList, processBuilder, mtaElementsCalculator)
+	setModulesAndResourcesListParameters(mtaElementsCalculator.modules, mtaElementsCalculator.resources, processBuilder, mtaElementsCalculator)
 	c.setProcessParameters(flags, processBuilder)
 
 	operation := processBuilder.Build()
@@ -416,10 +575,9 @@ func (c *DeployCommand) executeInternal(positionalArgs []string, dsHost string,
 	responseHeader, err := mtaClient.StartMtaOperation(*operation)
 	if err != nil {
 		ui.Failed("Could not create operation: %s", baseclient.NewClientError(err))
-		return Failure
+		return "", Failure
 	}
-	executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, responseHeader.Location.String(), retries, []*models.Message{}, mtaClient)
-	return executionMonitor.Monitor()
+	return responseHeader.Location.String(), Success
 }
 
 func setUpSpecificsForDeploymentUsingSecrets(flags *flag.FlagSet, c *DeployCommand, mtaId, namespace, schemaVersion string, disposableUserProvidedServiceName *string, yamlBytes *[]byte) ExecutionStatus {
@@ -483,6 +641,20 @@ func setUpSpecificsForDeploymentUsingSecrets(flags *flag.FlagSet, c *DeployComma
 	return Success
 }
 
+// getDeploymentHistoryContext returns the strategy, the options and, for local archives, the version and the digest
+// of the deployed MTA archive
+func (c *DeployCommand) getDeploymentHistoryContext(rawMtaArchive interface{}, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) deploymentHistoryContext {
+	context := newDeploymentHistoryContext(flags, cfTarget)
+	context.strategy = GetStringOpt(strategyOpt, flags)
+	if c.name == "bg-deploy" {
+		context.strategy = "blue-green"
+	}
+	if isUrl, mtaArchive := parseMtaArchiveArgument(rawMtaArchive); !isUrl && mtaArchive != "" {
+		context = context.withArchive(mtaArchive)
+	}
+	return context
+}
+
 func parseMtaArchiveArgument(rawMtaArchive interface{}) (bool, string) {
 	switch castedMtaArchive := rawMtaArchive.(type) {
 	case *url.URL:
@@ -677,6 +849,12 @@ func (c *DeployCommand) tryReadingFileUrl() string {
 	return ""
 }
 
+// isInteractiveTerminal checks whether the standard input is a terminal, so that the user can be asked for input
+func (c *DeployCommand) isInteractiveTerminal() bool {
+	file, ok := c.FileUrlReader.(interface{ Fd() uintptr })
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
 func (c *DeployCommand) tryFetchMtarSize(url string, disableProgressBar bool) *pb.ProgressBar {
 	client := http.Client{Timeout: c.FileUrlReadTimeout}
 	resp, err := client.Head(url)
@@ -696,6 +874,11 @@ func (c *DeployCommand) tryFetchMtarSize(url string, disableProgressBar bool) *p
 }
 
 func buildMtaArchiveFromDirectory(mtaDirectoryLocation string, mtaElementsCalculator mtaElementsToAddCalculator) (string, error) {
+	return buildMtaArchiveFromDirectoryTo(mtaDirectoryLocation, mtaDirectoryLocation, mtaElementsCalculator)
+}
+
+// buildMtaArchiveFromDirectoryTo builds the archive of the MTA directory in the archive directory
+func buildMtaArchiveFromDirectoryTo(mtaDirectoryLocation, archiveDirectory string, mtaElementsCalculator mtaElementsToAddCalculator) (string, error) {
 	deploymentDescriptor, _, err := util.ParseDeploymentDescriptor(mtaDirectoryLocation)
 	if err != nil {
 		return "", err
@@ -704,21 +887,88 @@ func buildMtaArchiveFromDirectory(mtaDirectoryLocation string, mtaElementsCalcul
 	modulesToAdd := mtaElementsCalculator.getModulesToAdd(deploymentDescriptor)
 	resourcesToAdd := mtaElementsCalculator.getResourcesToAdd(deploymentDescriptor)
 
-	return util.NewMtaArchiveBuilder(modulesToAdd, resourcesToAdd).Build(mtaDirectoryLocation)
+	return util.NewMtaArchiveBuilder(modulesToAdd, resourcesToAdd).BuildTo(mtaDirectoryLocation, archiveDirectory)
 }
 
 type mtaElementsToAddCalculator struct {
 	shouldAddAllModules   bool
 	shouldAddAllResources bool
+	modules               listFlag
+	resources             listFlag
 }
 
 func createMtaElementsCalculator(flags *flag.FlagSet) mtaElementsToAddCalculator {
 	return mtaElementsToAddCalculator{
 		shouldAddAllModules:   GetBoolOpt(allModulesOpt, flags) || len(modulesList.getElements()) == 0,
 		shouldAddAllResources: GetBoolOpt(allResourcesOpt, flags) || len(resourcesList.getElements()) == 0,
+		modules:               modulesList,
+		resources:             resourcesList,
 	}
 }
 
+// createChangedSinceElementsCalculator creates a calculator which selects the modules and resources of the MTA directory
+// that have changed since the specified git ref, together with the modules which require the changed resources.
+// All modules and resources are selected if the deployment descriptor itself has changed.
+func createChangedSinceElementsCalculator(positionalArgs []string, ref string) (mtaElementsToAddCalculator, bool, error) {
+	mtaDirectory, err := getMtaDirectoryForOption(positionalArgs, changedSinceOpt)
+	if err != nil {
+		return mtaElementsToAddCalculator{}, false, err
+	}
+	descriptor, descriptorLocation, err := util.ParseDeploymentDescriptor(mtaDirectory)
+	if err != nil {
+		return mtaElementsToAddCalculator{}, false, err
+	}
+	changedPaths, err := util.GetChangedPathsSinceRef(mtaDirectory, ref)
+	if err != nil {
+		return mtaElementsToAddCalculator{}, false, err
+	}
+
+	if util.Contains(changedPaths, filepath.Base(descriptorLocation)) {
+		ui.Say("The deployment descriptor has changed since %s, deploying all modules and resources.", terminal.EntityNameColor(ref))
+		return mtaElementsToAddCalculator{shouldAddAllModules: true, shouldAddAllResources: true}, true, nil
+	}
+	changedResources := util.FindChangedResources(descriptor, changedPaths)
+	affectedModules := util.FindAffectedModules(descriptor, changedPaths, changedResources)
+	if len(changedResources) == 0 && len(affectedModules) == 0 {
+		return mtaElementsToAddCalculator{}, false, nil
+	}
+	ui.Say("Modules to deploy, affected by changes since %s: %s", terminal.EntityNameColor(ref), formatElementNames(affectedModules))
+	ui.Say("Resources to deploy, changed since %s: %s\n", terminal.EntityNameColor(ref), formatElementNames(changedResources))
+	return mtaElementsToAddCalculator{
+		shouldAddAllModules:   false,
+		shouldAddAllResources: false,
+		modules:               listFlag{elements: affectedModules},
+		resources:             listFlag{elements: changedResources},
+	}, true, nil
+}
+
+func formatElementNames(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// getMtaDirectoryForOption returns the absolute path of the MTA directory specified as positional argument, or of the
+// current working directory if there is none. The option is named in the error reported for arguments which are files.
+func getMtaDirectoryForOption(positionalArgs []string, optionName string) (string, error) {
+	if len(positionalArgs) == 0 {
+		currentWorkingDirectory, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("Could not get the current working directory: %s", err.Error())
+		}
+		return currentWorkingDirectory, nil
+	}
+	fileInfo, err := os.Stat(positionalArgs[0])
+	if err != nil {
+		return "", fmt.Errorf("Could not find MTA %s", positionalArgs[0])
+	}
+	if !fileInfo.IsDir() {
+		return "", fmt.Errorf("The %q option requires a directory containing a deployment descriptor, but %s is a file", optionName, positionalArgs[0])
+	}
+	return filepath.Abs(positionalArgs[0])
+}
+
 func (c mtaElementsToAddCalculator) getModulesToAdd(deploymentDescriptor util.MtaDeploymentDescriptor) []string {
 	if c.shouldAddAllModules {
 		modulesToAdd := make([]string, 0)
@@ -728,7 +978,7 @@ func (c mtaElementsToAddCalculator) getModulesToAdd(deploymentDescriptor util.Mt
 		return modulesToAdd
 	}
 
-	return modulesList.getElements()
+	return c.modules.getElements()
 }
 
 func (c mtaElementsToAddCalculator) getResourcesToAdd(deploymentDescriptor util.MtaDeploymentDescriptor) []string {
@@ -740,7 +990,7 @@ func (c mtaElementsToAddCalculator) getResourcesToAdd(deploymentDescriptor util.
 		return resourcesToAdd
 	}
 
-	return resourcesList.getElements()
+	return c.resources.getElements()
 }
 
 type deployCommandProcessTypeProvider struct{}
@@ -775,9 +1025,12 @@ type deployCommandFlagsValidator struct{}
 
 func (deployCommandFlagsValidator) ValidateParsedFlags(flags *flag.FlagSet) error {
 	var err error
+	var elementSelectionOptions []string
 
 	flags.Visit(func(f *flag.Flag) {
 		switch f.Name {
+		case moduleOpt, resourceOpt, allModulesOpt, allResourcesOpt, watchOpt:
+			elementSelectionOptions = append(elementSelectionOptions, f.Name)
 		case strategyOpt:
 			if f.Value.String() == "" {
 				err = errors.New("strategy flag defined but no argument specified")
@@ -791,6 +1044,16 @@ func (deployCommandFlagsValidator) ValidateParsedFlags(flags *flag.FlagSet) erro
 				err = fmt.Errorf("Invalid value for namespace. The namespace cannot be more than %d symbols.", maxNamespaceSize)
 				return
 			}
+		case targetsOpt:
+			if _, e := parseDeployTargets(f.Value.String()); e != nil {
+				err = e
+				return
+			}
+		case maxParallelTargetsOpt:
+			if GetUintOpt(maxParallelTargetsOpt, flags) == 0 {
+				err = fmt.Errorf("Invalid value for %s: %s. Value must be greater than 0.", maxParallelTargetsOpt, f.Value.String())
+				return
+			}
 		case timeoutOpt, startTimeoutOpt, stageTimeoutOpt, uploadTimeoutOpt, taskExecutionTimeoutOpt:
 			if e := ValidateTimeoutOption(f.Name, flags, 259200); e != nil {
 				err = e
@@ -806,6 +1069,45 @@ func (deployCommandFlagsValidator) ValidateParsedFlags(flags *flag.FlagSet) erro
 	if err != nil {
 		return err
 	}
+	if GetStringOpt(changedSinceOpt, flags) != "" && len(elementSelectionOptions) > 0 {
+		return fmt.Errorf("Option %s cannot be combined with %s", changedSinceOpt, strings.Join(elementSelectionOptions, ", "))
+	}
+	if GetBoolOpt(autoResumeWhenHealthyOpt, flags) {
+		var conflictingOptions []string
+		flags.Visit(func(f *flag.Flag) {
+			if util.Contains([]string{skipTestingPhase, noConfirmOpt, watchOpt}, f.Name) {
+				conflictingOptions = append(conflictingOptions, f.Name)
+			}
+		})
+		if len(conflictingOptions) > 0 {
+			return fmt.Errorf("Option %s cannot be combined with %s", autoResumeWhenHealthyOpt, strings.Join(conflictingOptions, ", "))
+		}
+	}
+	if GetBoolOpt(watchOpt, flags) && shouldRunSmokeTests(flags) {
+		return fmt.Errorf("Option %s cannot be combined with %s", watchOpt, smokeTestsOpt)
+	}
+	if GetBoolOpt(watchOpt, flags) {
+		var conflictingOptions []string
+		flags.Visit(func(f *flag.Flag) {
+			if util.Contains([]string{reportOpt, notifyWebhookOpt}, f.Name) {
+				conflictingOptions = append(conflictingOptions, f.Name)
+			}
+		})
+		if len(conflictingOptions) > 0 {
+			return fmt.Errorf("Option %s cannot be combined with %s", watchOpt, strings.Join(conflictingOptions, ", "))
+		}
+	}
+	if GetStringOpt(targetsOpt, flags) != "" {
+		var conflictingOptions []string
+		flags.Visit(func(f *flag.Flag) {
+			if util.Contains([]string{operationIDOpt, actionOpt, watchOpt, requireSecureParameters, smokeTestsOpt, smokeTestsConfigOpt, autoResumeWhenHealthyOpt, reportOpt, notifyWebhookOpt}, f.Name) {
+				conflictingOptions = append(conflictingOptions, f.Name)
+			}
+		})
+		if len(conflictingOptions) > 0 {
+			return fmt.Errorf("Option %s cannot be combined with %s", targetsOpt, strings.Join(conflictingOptions, ", "))
+		}
+	}
 	return NewDefaultCommandFlagsValidator(nil).ValidateParsedFlags(flags)
 }
 
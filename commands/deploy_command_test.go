@@ -2,11 +2,14 @@ package commands_test
 
 import (
 	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,7 +20,9 @@ import (
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
 	mtafake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
+	mtav2fake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient_v2/fakes"
 
+	plugin_models "code.cloudfoundry.org/cli/v8/plugin/models"
 	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
@@ -237,6 +242,7 @@ var _ = Describe("DeployCommand", func() {
 			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
 			deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
 			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+			command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{}
 			command.FileUrlReadTimeout = time.Second
 		})
 
@@ -315,6 +321,123 @@ var _ = Describe("DeployCommand", func() {
 			})
 		})
 
+		// watch mode with an MTA archive - error
+		Context("with watch option and an mta archive", func() {
+			It("should print an error that a directory is required and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--watch"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Error retrieving MTA: The \"watch\" option requires a directory containing a deployment descriptor, but "+mtaArchivePath+" is a file")
+			})
+		})
+
+		// watch mode with a directory without a deployment descriptor - error
+		Context("with watch option and a directory without a deployment descriptor", func() {
+			It("should print an error that no deployment descriptor was found and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{testFilesLocation, "--watch"}).ToInt()
+				})
+				mtaDirectory, _ := filepath.Abs(testFilesLocation)
+				ex.ExpectFailure(status, output, "Error retrieving MTA: No deployment descriptor with name mtad.yaml was found in location "+mtaDirectory)
+			})
+		})
+
+		// watch mode with changes of a module - redeploy of the changed module
+		Context("with watch option and changes of a module", func() {
+			var mtaDirectory string
+
+			BeforeEach(func() {
+				mtaDirectory, _ = os.MkdirTemp("", "watched-mta")
+				descriptor := "_schema-version: \"3\"\nID: watched\nversion: 1.0.0\nmodules:\n  - name: web\n    type: staticfile\n    path: web\n  - name: api\n    type: nodejs\n    path: api\n"
+				Expect(os.WriteFile(filepath.Join(mtaDirectory, "mtad.yaml"), []byte(descriptor), 0644)).To(Succeed())
+				for _, module := range []string{"web", "api"} {
+					Expect(os.Mkdir(filepath.Join(mtaDirectory, module), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(mtaDirectory, module, "index.js"), []byte("initial"), 0644)).To(Succeed())
+				}
+			})
+
+			It("should redeploy the changed module once per burst of changes and stop on interrupt", func() {
+				go func() {
+					defer GinkgoRecover()
+					Eventually(mtaClient.StartMtaOperationCallCount, 10*time.Second).Should(Equal(1))
+					for i := 0; i < 3; i++ {
+						Expect(os.WriteFile(filepath.Join(mtaDirectory, "web", "index.js"), []byte(fmt.Sprintf("change %d", i)), 0644)).To(Succeed())
+						time.Sleep(100 * time.Millisecond)
+					}
+					Eventually(mtaClient.StartMtaOperationCallCount, 10*time.Second).Should(Equal(2))
+					Consistently(mtaClient.StartMtaOperationCallCount, 2*time.Second).Should(Equal(2))
+					command.Interrupts <- os.Interrupt
+				}()
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaDirectory, "--watch", "--watch-debounce", "1"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+				Expect(mtaClient.StartMtaOperationArgsForCall(0).Parameters).ToNot(HaveKey("modulesForDeployment"))
+				Expect(mtaClient.StartMtaOperationArgsForCall(1).Parameters).To(HaveKeyWithValue("modulesForDeployment", "web"))
+				Expect(output).To(ContainElement("Detected changes in modules web"))
+				Expect(output).To(ContainElement("Deployment #2 summary:"))
+				Expect(output).To(ContainElement("Stopped watching directory " + mtaDirectory + "."))
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(mtaDirectory)
+			})
+		})
+
+		// watch mode with a module at the root of the MTA directory - no redeploy of the built archive
+		Context("with watch option and a module at the root of the directory", func() {
+			var mtaDirectory string
+
+			BeforeEach(func() {
+				mtaDirectory, _ = os.MkdirTemp("", "watched-mta")
+				descriptor := "_schema-version: \"3\"\nID: watched\nversion: 1.0.0\nmodules:\n  - name: web\n    type: staticfile\n    path: .\n"
+				Expect(os.WriteFile(filepath.Join(mtaDirectory, "mtad.yaml"), []byte(descriptor), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(mtaDirectory, "index.html"), []byte("initial"), 0644)).To(Succeed())
+			})
+
+			It("should not write the archive into the directory and not redeploy it", func() {
+				go func() {
+					// the watching is stopped even if the archive is redeployed, so that the test does not hang
+					defer func() { command.Interrupts <- os.Interrupt }()
+					defer GinkgoRecover()
+					Eventually(mtaClient.StartMtaOperationCallCount, 10*time.Second).Should(Equal(1))
+					Consistently(mtaClient.StartMtaOperationCallCount, 3*time.Second).Should(Equal(1))
+				}()
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaDirectory, "--watch", "--watch-debounce", "1"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				Expect(filepath.Join(mtaDirectory, "watched.mtar")).ToNot(BeAnExistingFile())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(mtaDirectory)
+			})
+		})
+
+		// changed-since with explicitly selected modules - error
+		Context("with changed-since option and explicitly selected modules", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--changed-since", "origin/main", "-m", "module-1"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option changed-since cannot be combined with m")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		// changed-since with an MTA archive - error
+		Context("with changed-since option and an mta archive", func() {
+			It("should print an error that a directory is required and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--changed-since", "origin/main"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Error retrieving MTA: The \"changed-since\" option requires a directory containing a deployment descriptor, but "+mtaArchivePath+" is a file")
+			})
+		})
+
 		// strategy flag set to "" - error
 		Context("with strategy flag set to blank string", func() {
 			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
@@ -371,6 +494,103 @@ var _ = Describe("DeployCommand", func() {
 			})
 		})
 
+		// targets with an invalid target - error
+		Context("with targets option containing a target without a space", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a,org2"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Invalid target \"org2\", expected ORG/SPACE")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		// targets with watch mode - error
+		Context("with targets option and watch option", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a", "--watch"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option targets cannot be combined with watch")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		Context("with targets option and report option", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a", "--report", "junit=report.xml"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option targets cannot be combined with report")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		Context("with targets option and notify-webhook option", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a", "--notify-webhook", "https://hooks.example.com/secret"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option targets cannot be combined with notify-webhook")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		// targets with a space which does not exist - error
+		Context("with targets option containing a space which does not exist", func() {
+			It("should print an error and not deploy to any target", func() {
+				cliConnection.GetOrgReturns(plugin_models.GetOrg_Model{Guid: "org1-guid", Name: "org1", Spaces: []plugin_models.GetOrg_Space{
+					{Guid: "space-a-guid", Name: "space-a"},
+				}}, nil)
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a,org1/space-b"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Could not resolve target org1/space-b: Space space-b not found in org org1")
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		// targets with existing spaces - success
+		Context("with targets option containing existing spaces", func() {
+			It("should deploy to each of the targets and print a summary", func() {
+				cliConnection.GetOrgStub = func(orgName string) (plugin_models.GetOrg_Model, error) {
+					return plugin_models.GetOrg_Model{Guid: orgName + "-guid", Name: orgName, Spaces: []plugin_models.GetOrg_Space{
+						{Guid: "space-a-guid", Name: "space-a"},
+					}}, nil
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a,org2/space-a"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+				Expect(output).To(ContainElements(
+					"Deploying multi-target app archive "+mtaArchivePath+" in org org1 / space space-a as "+user+"...",
+					"Deploying multi-target app archive "+mtaArchivePath+" in org org2 / space space-a as "+user+"...",
+					"Deployment summary:",
+				))
+				Expect(output).To(ContainElement(MatchRegexp(`^org2/space-a\s+succeeded\s+1000\s+`)))
+			})
+
+			It("should not prompt for the confirmation of a protected mta when the targets are deployed in parallel", func() {
+				cliConnection.GetOrgStub = func(orgName string) (plugin_models.GetOrg_Model, error) {
+					return plugin_models.GetOrg_Model{Guid: orgName + "-guid", Name: orgName, Spaces: []plugin_models.GetOrg_Space{
+						{Guid: "space-a-guid", Name: "space-a"},
+					}}, nil
+				}
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", Annotations: map[string]string{"mta_protected": "true"}}},
+				}
+				command.Input = strings.NewReader("")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--targets", "org1/space-a,org2/space-a", "--max-parallel-targets", "2",
+						"--delete-services", "--override-protection"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+				Expect(output).ToNot(ContainElement(ContainSubstring("Type its ID to confirm")))
+			})
+		})
+
 		// TODO: can't connect to backend - error
 
 		// TODO: backend returns an an error response - error
@@ -411,6 +631,23 @@ var _ = Describe("DeployCommand", func() {
 			})
 		})
 
+		Context("with an mta protected through an annotation and the delete-services option", func() {
+			It("should refuse to deploy without the override-protection option", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", Annotations: map[string]string{"mta_protected": "true"}}},
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "-f", "-delete-services"}).ToInt()
+				})
+				ex.ExpectNonZeroStatus(status)
+				Expect(output).To(ContainElement("Multi-target app test is protected and cannot be the target of a deployment which deletes services. Use the \"--override-protection\" option to override the protection."))
+				Expect(mtaClient.GetMtaOperationsCallCount()).To(Equal(0))
+				Expect(mtaClient.ExecuteActionCallCount()).To(Equal(0))
+				Expect(mtaClient.UploadMtaFileCallCount()).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
 		// non-existing ongoing operations - success
 		// Context("with correct mta id from archive and no ongoing operations", func() {
 		// 	It("should not try to abort confliction operations", func() {
@@ -528,6 +765,71 @@ var _ = Describe("DeployCommand", func() {
 			})
 		})
 
+		Context("with valid operation id and the resume action provided", func() {
+			var historyHome string
+
+			BeforeEach(func() {
+				historyHome, _ = os.MkdirTemp("", "deploy-action")
+				os.Setenv("CF_HOME", historyHome)
+			})
+
+			It("should record the resumed operation with the target and the options of the command", func() {
+				resumedOperation := testutil.GetOperation("test-process-id", space, "test-mta-id", namespace, "deploy", "FINISHED", true)
+				testClientFactory.MtaClient = mtafake.NewFakeMtaClientBuilder().
+					GetMtaOperations(nil, nil, nil, []*models.Operation{
+						testutil.GetOperation("test-process-id", space, "test-mta-id", namespace, "deploy", "ACTION_REQUIRED", true),
+					}, nil).
+					ExecuteAction("test-process-id", "resume", mtaclient.ResponseHeader{Location: "operations/test-process-id?embed=messages"}, nil).
+					GetMtaOperation("test-process-id", "messages", resumedOperation, nil).Build()
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"-i", "test-process-id", "-a", "resume", "--strategy", "blue-green"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				records, err := util.NewDeploymentHistoryAt(filepath.Join(historyHome, ".cf", "multiapps-history.jsonl")).Query(util.DeploymentHistoryFilter{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].ProcessID).To(Equal("test-process-id"))
+				Expect(records[0].Org).To(Equal(org))
+				Expect(records[0].Space).To(Equal(space))
+				Expect(records[0].Strategy).To(Equal("blue-green"))
+				Expect(records[0].Flags).To(ContainElement("-a resume"))
+			})
+
+			It("should notify the webhook about the resumed operation and mask its URL in the deployment history", func() {
+				var payloads []util.WebhookPayload
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var payload util.WebhookPayload
+					json.NewDecoder(r.Body).Decode(&payload)
+					payloads = append(payloads, payload)
+				}))
+				defer server.Close()
+				resumedOperation := testutil.GetOperation("test-process-id", space, "test-mta-id", namespace, "deploy", "FINISHED", true)
+				testClientFactory.MtaClient = mtafake.NewFakeMtaClientBuilder().
+					GetMtaOperations(nil, nil, nil, []*models.Operation{
+						testutil.GetOperation("test-process-id", space, "test-mta-id", namespace, "deploy", "ACTION_REQUIRED", true),
+					}, nil).
+					ExecuteAction("test-process-id", "resume", mtaclient.ResponseHeader{Location: "operations/test-process-id?embed=messages"}, nil).
+					GetMtaOperation("test-process-id", "messages", resumedOperation, nil).Build()
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"-i", "test-process-id", "-a", "resume", "--notify-webhook", server.URL}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(payloads).ToNot(BeEmpty())
+				Expect(payloads[len(payloads)-1].Event).To(Equal(util.WebhookEventFinished))
+				Expect(payloads[len(payloads)-1].ProcessID).To(Equal("test-process-id"))
+				records, err := util.NewDeploymentHistoryAt(filepath.Join(historyHome, ".cf", "multiapps-history.jsonl")).Query(util.DeploymentHistoryFilter{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].Flags).To(ContainElement("-notify-webhook ********"))
+				Expect(strings.Join(records[0].Flags, " ")).ToNot(ContainSubstring(server.URL))
+			})
+
+			AfterEach(func() {
+				os.Setenv("CF_HOME", cfHome)
+				os.RemoveAll(historyHome)
+			})
+		})
+
 		Context("with --require-secure-parameters flag and a user-provided service instance which already exists", func() {
 			It("should not create a new user-provided service", func() {
 				os.Setenv("__MTA___fake-variable", "fakeSecret")
@@ -611,5 +913,313 @@ var _ = Describe("DeployCommand", func() {
 			})
 		})
 
+		Context("with smoke tests and watch option", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--watch", "--smoke-tests"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option watch cannot be combined with smoke-tests")
+			})
+		})
+
+		Context("with report and watch option", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--watch", "--report", "junit=report.xml"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option watch cannot be combined with report")
+			})
+		})
+
+		Context("with notify-webhook and watch option", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--watch", "--notify-webhook", "https://hooks.example.com/secret"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option watch cannot be combined with notify-webhook")
+			})
+		})
+
+		Context("with smoke tests", func() {
+			var smokeTestsStatusCode int
+			var backupApps []models.CloudFoundryApplication
+
+			BeforeEach(func() {
+				backupApps = []models.CloudFoundryApplication{{Name: "mta-backup-backend-app", Guid: "backup-app-guid"}}
+			})
+
+			var executeWithSmokeTests = func(args []string) ([]string, int) {
+				testClientFactory.MtaV2Client = mtav2fake.NewFakeMtaV2ClientBuilder().
+					GetMtas("test", nil, "test-space-guid", []*models.Mta{{
+						Metadata: &models.Metadata{ID: "test", Version: "0.0.1"},
+						Modules:  []*models.Module{{ModuleName: "backend", AppName: "backend-app"}},
+					}}, nil).Build()
+				command.CfClient = namespacedAppsClient{
+					FakeCloudFoundryClient: cf_client_fakes.FakeCloudFoundryClient{AppRoutes: []models.ApplicationRoute{{Url: "backend.example.com"}}},
+					appsByNamespace: map[string][]models.CloudFoundryApplication{
+						"":           {{Name: "backend-app", Guid: "backend-app-guid"}},
+						"mta-backup": backupApps,
+					},
+				}
+				command.HttpGetExecutor = util_fakes.NewFakeHttpGetExecutor(map[string]int{"https://backend.example.com/": smokeTestsStatusCode})
+				return oc.CaptureOutputAndStatus(func() int {
+					return command.Execute(args).ToInt()
+				})
+			}
+
+			Context("which pass", func() {
+				It("should probe the app routes and exit with zero status", func() {
+					smokeTestsStatusCode = 200
+					output, status := executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests"})
+					Expect(status).To(Equal(0))
+					Expect(output).To(ContainElement(ContainSubstring("Running smoke tests for multi-target app test...")))
+					Expect(output).To(ContainElement(MatchRegexp(`backend\s+https://backend.example.com/\s+2xx\s+200\s+passed`)))
+					Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("which fail after a deployment with a backup of the previous version", func() {
+				It("should roll back the multi-target app and exit with a non-zero status", func() {
+					smokeTestsStatusCode = 503
+					output, status := executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests", "--strategy", "blue-green", "--backup-previous-version"})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(MatchRegexp(`backend\s+https://backend.example.com/\s+2xx\s+503\s+failed`)))
+					Expect(output).To(ContainElement(ContainSubstring("Rolling back multi-target app test to the previous version...")))
+					Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+					rollbackOperation := mtaClient.StartMtaOperationArgsForCall(1)
+					Expect(rollbackOperation.ProcessType).To(Equal("ROLLBACK_MTA"))
+					Expect(rollbackOperation.Parameters["mtaId"]).To(Equal("test"))
+				})
+			})
+
+			Context("which fail after a deployment whose backup of the previous version no longer exists", func() {
+				It("should not roll back the multi-target app and exit with a non-zero status", func() {
+					smokeTestsStatusCode = 503
+					backupApps = nil
+					output, status := executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests", "--strategy", "blue-green", "--backup-previous-version"})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(ContainSubstring("The multi-target app is not rolled back, because no backup of the previous version exists.")))
+					Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("after a process which entered the testing phase", func() {
+				It("should not run the smoke tests until the process has finished", func() {
+					smokeTestsStatusCode = 503
+					mtaClient.GetMtaOperationReturns(testutil.GetOperation("1000", "test-space-guid", "test", "", "DEPLOY", "ACTION_REQUIRED", true), nil)
+					output, status := executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests", "--strategy", "blue-green", "--backup-previous-version"})
+					Expect(status).To(Equal(0))
+					Expect(output).To(ContainElement(ContainSubstring("The smoke tests were not run, because the process has not finished")))
+					Expect(output).NotTo(ContainElement(ContainSubstring("Running smoke tests for multi-target app test...")))
+					Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("which fail after a deployment without a backup of the previous version", func() {
+				It("should not roll back the multi-target app and exit with a non-zero status", func() {
+					smokeTestsStatusCode = 404
+					output, status := executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests"})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(ContainSubstring("The multi-target app is not rolled back, because no backup of the previous version was created.")))
+					Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("with a configuration declaring the endpoints of a module", func() {
+				It("should probe the declared endpoints instead of the app routes", func() {
+					configFile, _ := os.CreateTemp("", "smoke-tests")
+					defer os.Remove(configFile.Name())
+					configFile.WriteString("modules:\n- name: backend\n  endpoints:\n  - path: /health\n    expected-status-codes: [0]\n")
+					configFile.Close()
+					output, status := executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests-config", configFile.Name()})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(ContainSubstring("Invalid smoke tests configuration: The endpoint at position 1 of module backend has an invalid expected status code 0")))
+					Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+
+					os.WriteFile(configFile.Name(), []byte("modules:\n- name: backend\n  endpoints:\n  - path: /health\n    expected-status-codes: [401]\n"), os.ModePerm)
+					output, status = executeWithSmokeTests([]string{mtaArchivePath, "--smoke-tests-config", configFile.Name()})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(MatchRegexp(`backend\s+https://backend.example.com/health\s+401\s+0\s+failed`)))
+				})
+			})
+		})
+
+		Context("with auto-resume-when-healthy and skip-testing-phase options", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--strategy", "blue-green", "--auto-resume-when-healthy", "--skip-testing-phase"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Option auto-resume-when-healthy cannot be combined with skip-testing-phase")
+			})
+		})
+
+		Context("with auto-resume-when-healthy option and a process in the testing phase", func() {
+			var actionRequiredOperation = testutil.GetOperation("1000", "test-space-guid", "test", "", "DEPLOY", "ACTION_REQUIRED", true)
+
+			var executeWithAutoResume = func(processes []models.ApplicationProcessStatistics, args []string, apps ...models.CloudFoundryApplication) ([]string, int) {
+				if len(apps) == 0 {
+					apps = []models.CloudFoundryApplication{
+						{Name: "backend", Guid: "backend-guid"},
+						{Name: "backend-idle", Guid: "backend-idle-guid"},
+					}
+				}
+				mtaClient.GetMtaOperationStub = func(operationID, embed string) (*models.Operation, error) {
+					if mtaClient.ExecuteActionCallCount() > 0 {
+						return &testutil.OperationResult, nil
+					}
+					return actionRequiredOperation, nil
+				}
+				mtaClient.GetOperationActionsReturns([]string{"abort", "resume"}, nil)
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps:            apps,
+					AppProcessStats: processes,
+					AppRoutes:       []models.ApplicationRoute{{Url: "backend-idle.example.com"}},
+				}
+				command.HttpGetExecutor = util_fakes.NewFakeHttpGetExecutor(map[string]int{"https://backend-idle.example.com": 500})
+				return oc.CaptureOutputAndStatus(func() int {
+					return command.Execute(append([]string{mtaArchivePath, "--strategy", "blue-green", "--auto-resume-when-healthy"}, args...)).ToInt()
+				})
+			}
+
+			Context("when all instances of the idle apps are running", func() {
+				It("should resume the process", func() {
+					output, status := executeWithAutoResume([]models.ApplicationProcessStatistics{{State: "RUNNING"}, {State: "RUNNING"}}, nil)
+					Expect(status).To(Equal(0))
+					Expect(output).To(ContainElement(ContainSubstring("Waiting up to 10m0s for the idle apps of multi-target app test to become healthy...")))
+					Expect(mtaClient.ExecuteActionCallCount()).To(Equal(1))
+					_, action := mtaClient.ExecuteActionArgsForCall(0)
+					Expect(action).To(Equal("resume"))
+				})
+			})
+
+			Context("when the routes of the idle apps are probed and fail", func() {
+				It("should abort the process after the health check timeout", func() {
+					output, status := executeWithAutoResume([]models.ApplicationProcessStatistics{{State: "RUNNING"}}, []string{"--probe-idle-routes", "--health-check-timeout", "0"})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(ContainSubstring("route https://backend-idle.example.com responded with status code 500")))
+					Expect(mtaClient.ExecuteActionCallCount()).To(Equal(1))
+					_, action := mtaClient.ExecuteActionArgsForCall(0)
+					Expect(action).To(Equal("abort"))
+				})
+			})
+
+			Context("when the multi-target app has no idle apps", func() {
+				It("should neither resume nor abort the process", func() {
+					output, status := executeWithAutoResume([]models.ApplicationProcessStatistics{{State: "RUNNING"}}, nil, models.CloudFoundryApplication{Name: "backend", Guid: "backend-guid"})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(ContainSubstring("No idle apps of multi-target app test found, the process is not resumed")))
+					Expect(output).To(ContainElement("Use \"cf deploy -i 1000 -a resume\" to resume the process."))
+					Expect(mtaClient.ExecuteActionCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when not all instances of the idle apps are running", func() {
+				It("should abort the process after the health check timeout", func() {
+					output, status := executeWithAutoResume([]models.ApplicationProcessStatistics{{State: "RUNNING"}, {State: "CRASHED"}}, []string{"--health-check-timeout", "0"})
+					Expect(status).To(Equal(1))
+					Expect(output).To(ContainElement(ContainSubstring("1 of 2 instances of app backend-idle are running")))
+					_, action := mtaClient.ExecuteActionArgsForCall(0)
+					Expect(action).To(Equal("abort"))
+				})
+			})
+		})
+
+		Context("with the report option", func() {
+			var reportLocation string
+			var actionRequiredOperation *models.Operation
+			var failedOperation *models.Operation
+
+			var readJUnitReport = func() util.JUnitTestSuites {
+				content, err := os.ReadFile(reportLocation)
+				Expect(err).ToNot(HaveOccurred())
+				var report util.JUnitTestSuites
+				Expect(xml.Unmarshal(content, &report)).To(Succeed())
+				return report
+			}
+
+			var failAfterResume = func() {
+				mtaClient.GetMtaOperationStub = func(operationID, embed string) (*models.Operation, error) {
+					if mtaClient.ExecuteActionCallCount() > 0 {
+						return failedOperation, nil
+					}
+					return actionRequiredOperation, nil
+				}
+				mtaClient.GetOperationActionsReturns([]string{"abort", "retry"}, nil)
+			}
+
+			BeforeEach(func() {
+				reportDirectory, _ := os.MkdirTemp("", "deploy-report")
+				reportLocation = filepath.Join(reportDirectory, "report.xml")
+				actionRequiredOperation = testutil.GetOperation("1000", "test-space-guid", "test", "", "DEPLOY", "ACTION_REQUIRED", true)
+				failedOperation = testutil.GetOperation("1000", "test-space-guid", "test", "", "DEPLOY", "ERROR", true)
+				failedOperation.Messages = []*models.Message{{ID: 1, Type: models.MessageTypeERROR, Text: "Error staging application \"backend\""}}
+			})
+
+			It("should report the finished operation", func() {
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--report", "junit=" + reportLocation}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				report := readJUnitReport()
+				Expect(report.Suites).To(HaveLen(1))
+				Expect(report.Suites[0].Name).To(Equal("deploy 1000"))
+				Expect(report.Suites[0].Failures).To(Equal(0))
+			})
+
+			It("should report the failure of an operation which fails after it was resumed automatically", func() {
+				failAfterResume()
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps:            []models.CloudFoundryApplication{{Name: "backend-idle", Guid: "backend-idle-guid"}},
+					AppProcessStats: []models.ApplicationProcessStatistics{{State: "RUNNING"}},
+				}
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaArchivePath, "--strategy", "blue-green", "--auto-resume-when-healthy", "--report", "junit=" + reportLocation}).ToInt()
+				})
+				Expect(status).To(Equal(1))
+				report := readJUnitReport()
+				Expect(report.Suites).To(HaveLen(1))
+				Expect(report.Suites[0].Name).To(Equal("deploy 1000"))
+				Expect(report.Suites[0].Failures).ToNot(BeZero())
+			})
+
+			Context("of the bg-deploy command", func() {
+				BeforeEach(func() {
+					command = commands.NewBlueGreenDeployCommand().DeployCommand
+					testTokenFactory := commands.NewTestTokenFactory(cliConnection)
+					deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
+					command.InitializeAll("bg-deploy", cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+					command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{}
+				})
+
+				It("should report the operation which requires an action", func() {
+					mtaClient.GetMtaOperationReturns(actionRequiredOperation, nil)
+					_, status := oc.CaptureOutputAndStatus(func() int {
+						return command.Execute([]string{mtaArchivePath, "--report", "junit=" + reportLocation}).ToInt()
+					})
+					Expect(status).To(Equal(0))
+					report := readJUnitReport()
+					Expect(report.Suites).To(HaveLen(1))
+					Expect(report.Suites[0].Name).To(Equal("bg-deploy 1000"))
+					Expect(report.Suites[0].Failures).To(Equal(0))
+				})
+
+				It("should report the failure of an operation which fails after it was resumed with the resume action", func() {
+					failAfterResume()
+					mtaClient.GetMtaOperationsReturns([]*models.Operation{actionRequiredOperation}, nil)
+					_, status := oc.CaptureOutputAndStatus(func() int {
+						return command.Execute([]string{"-i", "1000", "-a", "resume", "--report", "junit=" + reportLocation}).ToInt()
+					})
+					Expect(status).To(Equal(1))
+					report := readJUnitReport()
+					Expect(report.Suites).To(HaveLen(1))
+					Expect(report.Suites[0].Name).To(Equal("bg-deploy 1000"))
+					Expect(report.Suites[0].Failures).ToNot(BeZero())
+				})
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(filepath.Dir(reportLocation))
+			})
+		})
 	})
 })
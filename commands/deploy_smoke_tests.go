@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	smokeTestsOpt       = "smoke-tests"
+	smokeTestsConfigOpt = "smoke-tests-config"
+)
+
+// smokeTestResult holds the outcome of probing a single HTTP endpoint of a deployed module
+type smokeTestResult struct {
+	module     string
+	url        string
+	endpoint   util.SmokeTestEndpoint
+	statusCode int
+	err        error
+}
+
+func (result smokeTestResult) passed() bool {
+	return result.err == nil && result.endpoint.IsExpectedStatusCode(result.statusCode)
+}
+
+func (result smokeTestResult) expectedStatusCodes() string {
+	if len(result.endpoint.ExpectedStatusCodes) == 0 {
+		return "2xx"
+	}
+	statusCodes := make([]string, 0, len(result.endpoint.ExpectedStatusCodes))
+	for _, statusCode := range result.endpoint.ExpectedStatusCodes {
+		statusCodes = append(statusCodes, fmt.Sprint(statusCode))
+	}
+	return strings.Join(statusCodes, ", ")
+}
+
+func (result smokeTestResult) actualStatus() string {
+	if result.err != nil {
+		return result.err.Error()
+	}
+	return fmt.Sprint(result.statusCode)
+}
+
+func shouldRunSmokeTests(flags *flag.FlagSet) bool {
+	return GetBoolOpt(smokeTestsOpt, flags) || GetStringOpt(smokeTestsConfigOpt, flags) != ""
+}
+
+func getSmokeTestsConfig(flags *flag.FlagSet) (util.SmokeTestsConfig, error) {
+	configLocation := GetStringOpt(smokeTestsConfigOpt, flags)
+	if configLocation == "" {
+		return util.SmokeTestsConfig{}, nil
+	}
+	return util.ParseSmokeTestsConfig(configLocation)
+}
+
+// executeSmokeTests probes the HTTP endpoints of the deployed MTA. If a probe fails and a backup of the previous version
+// was created during the deployment, the MTA is rolled back to that version.
+func (c *DeployCommand) executeSmokeTests(mtaID string, config util.SmokeTestsConfig, mtaClient mtaclient.MtaClientOperations,
+	dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
+	ui.Say("\nRunning smoke tests for multi-target app %s...", terminal.EntityNameColor(mtaID))
+	results, err := c.runSmokeTests(mtaID, namespace, config, dsHost, cfTarget)
+	if err != nil {
+		ui.Failed("Could not run smoke tests: %s", err.Error())
+		return Failure
+	}
+	if reportSmokeTestResults(results) == Success {
+		return Success
+	}
+
+	if !GetBoolOpt(shouldBackupPreviousVersionOpt, flags) {
+		ui.Warn("The multi-target app is not rolled back, because no backup of the previous version was created. Use the %q option to create one.",
+			"--"+shouldBackupPreviousVersionOpt)
+		return Failure
+	}
+	c.rollbackAfterFailedSmokeTests(mtaID, namespace, mtaClient, flags, cfTarget)
+	return Failure
+}
+
+// runSmokeTests probes the endpoints declared for the modules of the deployed MTA. The routes of the applications of
+// the modules without declared endpoints are probed by default.
+func (c *DeployCommand) runSmokeTests(mtaID, namespace string, config util.SmokeTestsConfig, dsHost string, cfTarget util.CloudFoundryTarget) ([]smokeTestResult, error) {
+	mtaV2Client := c.NewMtaV2Client(dsHost, cfTarget)
+	mtas, err := mtaV2Client.GetMtas(&mtaID, &namespace, cfTarget.Space.Guid)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get multi-target app %s: %s", mtaID, baseclient.NewClientError(err))
+	}
+	if len(mtas) == 0 {
+		return nil, fmt.Errorf("Multi-target app %s not found", mtaID)
+	}
+	apps, err := c.CfClient.GetApplications(mtaID, namespace, cfTarget.Space.Guid)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get apps: %s", err)
+	}
+	appGuids := make(map[string]string)
+	for _, app := range apps {
+		appGuids[app.Name] = app.Guid
+	}
+
+	var results []smokeTestResult
+	for _, module := range mtas[0].Modules {
+		endpoints, declared := config.GetEndpoints(module.ModuleName)
+		var routes []models.ApplicationRoute
+		if !declared || needsRoutes(endpoints) {
+			appGuid, found := appGuids[module.AppName]
+			if !found {
+				return nil, fmt.Errorf("App %s of module %s not found", module.AppName, module.ModuleName)
+			}
+			routes, err = c.CfClient.GetApplicationRoutes(appGuid)
+			if err != nil {
+				return nil, fmt.Errorf("Could not get app %q routes: %s", module.AppName, err)
+			}
+		}
+		if !declared {
+			endpoints = []util.SmokeTestEndpoint{{Path: "/"}}
+		}
+		for _, endpoint := range endpoints {
+			for _, url := range getSmokeTestURLs(endpoint, routes) {
+				statusCode, err := c.HttpGetExecutor.ExecuteGetRequest(url)
+				results = append(results, smokeTestResult{module: module.ModuleName, url: url, endpoint: endpoint, statusCode: statusCode, err: err})
+			}
+		}
+	}
+	return results, nil
+}
+
+func needsRoutes(endpoints []util.SmokeTestEndpoint) bool {
+	for _, endpoint := range endpoints {
+		if endpoint.URL == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func getSmokeTestURLs(endpoint util.SmokeTestEndpoint, routes []models.ApplicationRoute) []string {
+	if endpoint.URL != "" {
+		return []string{endpoint.URL}
+	}
+	urls := make([]string, 0, len(routes))
+	for _, route := range routes {
+		urls = append(urls, "https://"+strings.TrimSuffix(route.Url, "/")+"/"+strings.TrimPrefix(endpoint.Path, "/"))
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func reportSmokeTestResults(results []smokeTestResult) ExecutionStatus {
+	if len(results) == 0 {
+		ui.Warn("No endpoints to probe, the multi-target app has no routes and no declared endpoints")
+		return Success
+	}
+	status := Success
+	table := ui.Table([]string{"module", "url", "expected status", "status", "result"})
+	for _, result := range results {
+		outcome := "passed"
+		if !result.passed() {
+			outcome = "failed"
+			status = Failure
+		}
+		table.Add(result.module, result.url, result.expectedStatusCodes(), result.actualStatus(), outcome)
+	}
+	table.Print()
+	if status == Failure {
+		ui.Failed("Smoke tests failed")
+	} else {
+		ui.Ok()
+	}
+	return status
+}
+
+// rollbackAfterFailedSmokeTests starts a rollback process for the MTA and waits for it to complete. The MTA is only
+// rolled back if the backup apps of the previous version exist and its protection allows it.
+func (c *DeployCommand) rollbackAfterFailedSmokeTests(mtaID, namespace string, mtaClient mtaclient.MtaClientOperations,
+	flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	backupApps, err := c.CfClient.GetApplications(mtaID, getBackupNamespace(namespace), cfTarget.Space.Guid)
+	if err != nil {
+		ui.Failed("Could not get backup apps: %s", err)
+		return Failure
+	}
+	if len(backupApps) == 0 {
+		ui.Warn("The multi-target app is not rolled back, because no backup of the previous version exists.")
+		return Failure
+	}
+	if !ensureProtectionOverridden(mtaID, namespace, "a rollback", c.CfClient, c.getPrompt(), flags, cfTarget) {
+		return Failure
+	}
+
+	ui.Say("\nRolling back multi-target app %s to the previous version...", terminal.EntityNameColor(mtaID))
+	processBuilder := util.NewProcessBuilder()
+	processBuilder.ProcessType(rollbackMtaCommandProcessTypeProvider{}.GetProcessType())
+	processBuilder.Parameter("mtaId", mtaID)
+	processBuilder.Parameter("namespace", namespace)
+	operation := processBuilder.Build()
+
+	responseHeader, err := mtaClient.StartMtaOperation(*operation)
+	if err != nil {
+		ui.Failed("Could not create rollback mta process: %s", err)
+		return Failure
+	}
+	rollbackCommandName := NewRollbackMtaCommand().GetPluginCommand().Name
+	executionMonitor := NewExecutionMonitorFromLocationHeader(rollbackCommandName, responseHeader.Location.String(), GetUintOpt(retriesOpt, flags), []*models.Message{}, mtaClient).
+		WithParentSpan(c.span)
+	return executionMonitor.Monitor()
+}
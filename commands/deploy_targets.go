@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	targetsOpt            = "targets"
+	maxParallelTargetsOpt = "max-parallel-targets"
+)
+
+// deployTarget identifies a space by the names of the org and the space
+type deployTarget struct {
+	org   string
+	space string
+}
+
+func (target deployTarget) String() string {
+	return target.org + "/" + target.space
+}
+
+// deployTargetResult holds the outcome of the deployment to a single target
+type deployTargetResult struct {
+	target      deployTarget
+	operationID string
+	status      string
+	duration    time.Duration
+}
+
+// parseDeployTargets parses a comma-separated list of targets in the form ORG/SPACE
+func parseDeployTargets(value string) ([]deployTarget, error) {
+	var targets []deployTarget
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		orgAndSpace := strings.Split(element, "/")
+		if len(orgAndSpace) != 2 || orgAndSpace[0] == "" || orgAndSpace[1] == "" {
+			return nil, fmt.Errorf("Invalid target %q, expected ORG/SPACE", element)
+		}
+		target := deployTarget{org: orgAndSpace[0], space: orgAndSpace[1]}
+		for _, existingTarget := range targets {
+			if existingTarget == target {
+				return nil, fmt.Errorf("Target %s is specified more than once", target)
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// deployToTargets deploys the MTA archive to each of the targets specified with the targets option. The archive is
+// uploaded to every target space, since the file storage of the deploy service is space-scoped.
+func (c *DeployCommand) deployToTargets(rawMtaArchive interface{}, mtaElementsCalculator mtaElementsToAddCalculator, dsHost string,
+	flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	targets, err := parseDeployTargets(GetStringOpt(targetsOpt, flags))
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	cfContext := util.NewCloudFoundryContext(c.cliConnection)
+	cfTargets := make([]util.CloudFoundryTarget, 0, len(targets))
+	for _, target := range targets {
+		org, space, err := cfContext.GetOrgAndSpace(target.org, target.space)
+		if err != nil {
+			ui.Failed("Could not resolve target %s: %s", terminal.EntityNameColor(target.String()), err.Error())
+			return Failure
+		}
+		cfTargets = append(cfTargets, util.NewCFTarget(org, space, cfTarget.Username))
+	}
+
+	maxParallelTargets := GetUintOpt(maxParallelTargetsOpt, flags)
+	c.promptsDisabled = maxParallelTargets > 1
+	results := make([]*deployTargetResult, len(targets))
+	semaphore := make(chan struct{}, maxParallelTargets)
+	var waitGroup sync.WaitGroup
+	for i := range targets {
+		// acquire before starting the deployment, so that the targets are started in the specified order
+		semaphore <- struct{}{}
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			results[i] = c.deployToTarget(rawMtaArchive, mtaElementsCalculator, targets[i], dsHost, flags, cfTargets[i])
+		}(i)
+	}
+	waitGroup.Wait()
+
+	return reportTargetsSummary(results)
+}
+
+func (c *DeployCommand) deployToTarget(rawMtaArchive interface{}, mtaElementsCalculator mtaElementsToAddCalculator, target deployTarget, dsHost string,
+	flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) *deployTargetResult {
+	result := &deployTargetResult{target: target, status: deploymentFailed}
+	startTime := time.Now()
+	defer func() {
+		result.duration = time.Since(startTime)
+	}()
+
+	mtaClient := c.NewMtaClient(dsHost, cfTarget)
+	_, operationLocation, status := c.deployMtaArchive(rawMtaArchive, mtaElementsCalculator, GetBoolOpt(forceOpt, flags), mtaClient, dsHost, flags, cfTarget)
+	if status == Failure {
+		return result
+	}
+	result.operationID, _ = getMonitoringInformation(operationLocation)
+	historyContext := c.getDeploymentHistoryContext(rawMtaArchive, flags, cfTarget)
+	result.status = monitorDeployment(c.name, operationLocation, GetUintOpt(retriesOpt, flags), mtaClient, historyContext, c.span)
+	return result
+}
+
+func reportTargetsSummary(results []*deployTargetResult) ExecutionStatus {
+	status := Success
+	ui.Say("\nDeployment summary:")
+	table := ui.Table([]string{"target", "status", "operation id", "duration"})
+	for _, result := range results {
+		if result.status != deploymentSucceeded {
+			status = Failure
+		}
+		operationID := "-"
+		if result.operationID != "" {
+			operationID = result.operationID
+		}
+		table.Add(result.target.String(), result.status, operationID, result.duration.Round(time.Second).String())
+	}
+	table.Print()
+	return status
+}
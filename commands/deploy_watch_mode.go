@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	watchOpt         = "watch"
+	watchDebounceOpt = "watch-debounce"
+)
+
+// watchCycle holds the state of a single redeployment triggered in watch mode
+type watchCycle struct {
+	number      int
+	modules     []string
+	mtaID       string
+	operationID string
+	startTime   time.Time
+	status      ExecutionStatus
+	done        chan struct{}
+}
+
+func (cycle *watchCycle) isRunning() bool {
+	select {
+	case <-cycle.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// executeInWatchMode deploys the MTA directory and redeploys the changed modules every time the content of the directory changes
+func (c *DeployCommand) executeInWatchMode(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	mtaDirectory, err := getMtaDirectoryForOption(positionalArgs, watchOpt)
+	if err != nil {
+		ui.Failed("Error retrieving MTA: %s", err.Error())
+		return Failure
+	}
+	descriptor, descriptorLocation, err := util.ParseDeploymentDescriptor(mtaDirectory)
+	if err != nil {
+		ui.Failed("Error retrieving MTA: %s", err.Error())
+		return Failure
+	}
+	descriptorPath, err := filepath.Rel(mtaDirectory, descriptorLocation)
+	if err != nil {
+		ui.Failed("Error retrieving MTA: %s", err.Error())
+		return Failure
+	}
+
+	debounceInterval := time.Duration(GetUintOpt(watchDebounceOpt, flags)) * time.Second
+	watcher, err := newMtaDirectoryWatcher(mtaDirectory, descriptor, debounceInterval)
+	if err != nil {
+		ui.Failed("Could not watch directory %s: %s", terminal.EntityNameColor(mtaDirectory), err.Error())
+		return Failure
+	}
+	defer watcher.Close()
+
+	mtaClient := c.NewMtaClient(dsHost, cfTarget)
+	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
+	mtaElementsCalculator := createMtaElementsCalculator(flags)
+
+	signal.Notify(c.Interrupts, os.Interrupt)
+	defer signal.Stop(c.Interrupts)
+
+	ui.Say("Watching directory %s for changes. Press Ctrl+C to stop.\n", terminal.EntityNameColor(mtaDirectory))
+	cycle := c.startWatchCycle(1, mtaDirectory, mtaElementsCalculator.getModulesToAdd(descriptor), mtaElementsCalculator, GetBoolOpt(forceOpt, flags), mtaClient, dsHost, flags, cfTarget)
+
+	for {
+		var changedPaths []string
+		select {
+		case <-c.Interrupts:
+			return stopWatching(mtaDirectory, cycle)
+		case paths, ok := <-watcher.Changes():
+			if !ok {
+				<-cycle.done
+				return cycle.status
+			}
+			changedPaths = paths
+		}
+
+		modulesToDeploy := mtaElementsCalculator.getModulesToAdd(descriptor)
+		if util.Contains(changedPaths, descriptorPath) {
+			descriptor, _, err = util.ParseDeploymentDescriptor(mtaDirectory)
+			if err != nil {
+				ui.Warn("Changes are not deployed: %s", err.Error())
+				continue
+			}
+			if err = watcher.watchDescriptorContent(descriptor); err != nil {
+				ui.Warn("Could not watch the module paths of the changed deployment descriptor: %s", err.Error())
+			}
+			modulesToDeploy = mtaElementsCalculator.getModulesToAdd(descriptor)
+		} else {
+			modulesToDeploy = intersect(modulesToDeploy, util.FindChangedModules(descriptor, changedPaths))
+		}
+		if len(modulesToDeploy) == 0 {
+			continue
+		}
+
+		ui.Say("\nDetected changes in modules %s", strings.Join(modulesToDeploy, ", "))
+		if cycle.isRunning() && cycle.mtaID != "" {
+			ui.Say("Aborting the operation of the previous deployment...")
+			if _, err := c.CheckOngoingOperation(cycle.mtaID, namespace, dsHost, true, cfTarget); err != nil {
+				ui.Warn("Could not abort the operation of the previous deployment: %s", baseclient.NewClientError(err))
+			}
+		}
+		<-cycle.done
+
+		cycleCalculator := mtaElementsToAddCalculator{
+			shouldAddAllModules:   false,
+			shouldAddAllResources: mtaElementsCalculator.shouldAddAllResources,
+			modules:               listFlag{elements: modulesToDeploy},
+			resources:             mtaElementsCalculator.resources,
+		}
+		cycle = c.startWatchCycle(cycle.number+1, mtaDirectory, modulesToDeploy, cycleCalculator, true, mtaClient, dsHost, flags, cfTarget)
+	}
+}
+
+// stopWatching returns the status of the last deployment. A deployment which is still running is not aborted, its
+// operation continues in the background.
+func stopWatching(mtaDirectory string, cycle *watchCycle) ExecutionStatus {
+	ui.Say("\nStopped watching directory %s.", terminal.EntityNameColor(mtaDirectory))
+	if cycle.isRunning() {
+		if cycle.operationID != "" {
+			ui.Say("Operation %s of deployment #%d continues in the background.", cycle.operationID, cycle.number)
+		}
+		return Success
+	}
+	return cycle.status
+}
+
+// startWatchCycle builds an archive with the specified modules and starts a deploy operation for it. The operation is
+// monitored in the background and the returned cycle is marked as done once the monitoring completes.
+func (c *DeployCommand) startWatchCycle(number int, mtaDirectory string, modules []string, mtaElementsCalculator mtaElementsToAddCalculator, force bool,
+	mtaClient mtaclient.MtaClientOperations, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) *watchCycle {
+	cycle := &watchCycle{number: number, modules: modules, startTime: time.Now(), status: Failure, done: make(chan struct{})}
+
+	// The archive is built outside of the watched directory, so that writing it is not detected as a change
+	archiveDirectory, err := os.MkdirTemp("", "mta-watch")
+	if err != nil {
+		ui.Failed("Error retrieving MTA: %s", err.Error())
+		c.reportWatchCycleSummary(cycle, nil)
+		close(cycle.done)
+		return cycle
+	}
+	defer os.RemoveAll(archiveDirectory)
+	mtaArchive, err := buildMtaArchiveFromDirectoryTo(mtaDirectory, archiveDirectory, mtaElementsCalculator)
+	if err != nil {
+		ui.Failed("Error retrieving MTA: %s", err.Error())
+		c.reportWatchCycleSummary(cycle, nil)
+		close(cycle.done)
+		return cycle
+	}
+
+	mtaID, operationLocation, status := c.deployMtaArchive(mtaArchive, mtaElementsCalculator, force, mtaClient, dsHost, flags, cfTarget)
+	historyContext := c.getDeploymentHistoryContext(mtaArchive, flags, cfTarget)
+	cycle.mtaID = mtaID
+	if status == Failure {
+		c.reportWatchCycleSummary(cycle, nil)
+		close(cycle.done)
+		return cycle
+	}
+	cycle.operationID, _ = getMonitoringInformation(operationLocation)
+
+	go func() {
+		defer close(cycle.done)
+		executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, operationLocation, GetUintOpt(retriesOpt, flags), []*models.Message{}, mtaClient).
+			withHistoryContext(historyContext).
+			WithParentSpan(c.span)
+		cycle.status = executionMonitor.Monitor()
+		operation, err := mtaClient.GetMtaOperation(cycle.operationID, "")
+		if err != nil {
+			operation = nil
+		}
+		c.reportWatchCycleSummary(cycle, operation)
+	}()
+	return cycle
+}
+
+func (c *DeployCommand) reportWatchCycleSummary(cycle *watchCycle, operation *models.Operation) {
+	result := "Failed before an operation was started"
+	if operation != nil {
+		result = string(operation.State)
+	}
+	operationID := cycle.operationID
+	if operationID == "" {
+		operationID = "-"
+	}
+	ui.Say("\nDeployment #%d summary:", cycle.number)
+	ui.Say("Modules: %s", strings.Join(cycle.modules, ", "))
+	ui.Say("Operation ID: %s", operationID)
+	ui.Say("Result: %s", result)
+	ui.Say("Duration: %s", time.Since(cycle.startTime).Round(time.Second))
+	ui.Say("\nWaiting for changes...")
+}
+
+func intersect(elements, otherElements []string) []string {
+	result := make([]string, 0)
+	for _, element := range elements {
+		if util.Contains(otherElements, element) {
+			result = append(result, element)
+		}
+	}
+	return result
+}
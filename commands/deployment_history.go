@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"flag"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+// maskedOptionValue replaces the values of the options which contain secrets in the deployment history
+const maskedOptionValue = "********"
+
+// deploymentHistoryContext holds the details of a monitored operation which are known to the command only
+type deploymentHistoryContext struct {
+	org           string
+	space         string
+	user          string
+	strategy      string
+	flags         []string
+	mtaVersion    string
+	archiveDigest string
+}
+
+// newDeploymentHistoryContext returns the target and the options with which the command was called. The URL of the
+// webhook is masked, as it usually contains the secret of the webhook.
+func newDeploymentHistoryContext(flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) deploymentHistoryContext {
+	context := deploymentHistoryContext{org: cfTarget.Org.Name, space: cfTarget.Space.Name, user: cfTarget.Username}
+	flags.Visit(func(f *flag.Flag) {
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+			context.flags = append(context.flags, util.GetShortOption(f.Name))
+			return
+		}
+		value := f.Value.String()
+		if f.Name == notifyWebhookOpt {
+			value = maskedOptionValue
+		}
+		context.flags = append(context.flags, util.GetShortOption(f.Name)+" "+value)
+	})
+	return context
+}
+
+// withArchive adds the version and the SHA256 digest of a local MTA archive. Archives which cannot be read are
+// recorded without them.
+func (c deploymentHistoryContext) withArchive(mtaArchive string) deploymentHistoryContext {
+	if descriptor, err := util.GetMtaDescriptorFromArchive(mtaArchive); err == nil {
+		c.mtaVersion = descriptor.Version
+	}
+	if digest, err := util.ComputeFileChecksum(mtaArchive, "SHA256"); err == nil {
+		c.archiveDigest = digest
+	}
+	return c
+}
+
+// recordOperation adds the monitored operation to the deployment history. Failures are only reported as warnings,
+// as they do not affect the operation.
+func (m *ExecutionMonitor) recordOperation(operation *models.Operation) {
+	history, err := util.NewDeploymentHistory()
+	if err == nil {
+		err = history.Add(m.newDeploymentRecord(operation))
+	}
+	if err != nil {
+		ui.Warn("Could not record operation %s in the deployment history: %s", m.operationID, err)
+	}
+}
+
+func (m *ExecutionMonitor) newDeploymentRecord(operation *models.Operation) util.DeploymentRecord {
+	now := time.Now()
+	startTime, err := parseOperationStartTime(operation.StartedAt)
+	if err != nil {
+		startTime = m.startTime
+	}
+	user := m.historyContext.user
+	if user == "" {
+		user = operation.User
+	}
+	return util.DeploymentRecord{
+		RecordedAt:    now,
+		ProcessID:     m.operationID,
+		ProcessType:   operation.ProcessType,
+		MtaID:         operation.MtaID,
+		MtaVersion:    m.historyContext.mtaVersion,
+		Namespace:     operation.Namespace,
+		Org:           m.historyContext.org,
+		Space:         m.historyContext.space,
+		SpaceID:       operation.SpaceID,
+		User:          user,
+		Strategy:      m.historyContext.strategy,
+		Flags:         m.historyContext.flags,
+		ArchiveDigest: m.historyContext.archiveDigest,
+		State:         string(operation.State),
+		DurationInSec: int64(now.Sub(startTime).Seconds()),
+	}
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+// timingReportEnv is the environment variable with the location of the JUnit XML file to which the timing breakdown of
+// the monitored operations is written
+const timingReportEnv = "MULTIAPPS_TIMING_REPORT"
+
+// Phases of an operation, as derived from its messages
+const (
+	phaseUpload            = "upload"
+	phaseStaging           = "staging"
+	phaseStarting          = "starting"
+	phaseServiceOperations = "service operations"
+	phaseTesting           = "testing"
+	phaseCleanup           = "cleanup"
+	phaseOther             = "other"
+)
+
+// phasePatterns classify the messages of the controller, the first matching pattern wins. Messages which match none
+// of them belong to the phase of the previous message.
+var phasePatterns = []struct {
+	phase   string
+	pattern *regexp.Regexp
+}{
+	{phaseTesting, regexp.MustCompile(`(?i)\b(testing|validation) phase\b|\bsmoke test|\bexecuting task\b`)},
+	{phaseCleanup, regexp.MustCompile(`(?i)^(deleting|removing|unbinding|unmapping|stopping|renaming)\b|\b(discontinued|idle)\b.*\b(deleted|removed)\b`)},
+	{phaseServiceOperations, regexp.MustCompile(`(?i)\bservices?\b.*\b(creat|updat|bind|binding|polling|key)|^(creating|updating|binding)\b.*\bservice`)},
+	{phaseStaging, regexp.MustCompile(`(?i)\bstag(ing|ed)\b`)},
+	{phaseStarting, regexp.MustCompile(`(?i)\bstart(ing|ed)\b|\bavailable at\b|\binstances? (running|crashed)\b`)},
+	{phaseUpload, regexp.MustCompile(`(?i)\buploa(d|ding|ded)\b`)},
+}
+
+// timedMessage is a message of the operation together with the time at which it was reported
+type timedMessage struct {
+	text       string
+	reportedAt time.Time
+	phase      string
+}
+
+// timingReport collects the timing breakdowns of the operations which are monitored during the execution of a command,
+// so that the report is written once when the command completes
+type timingReport struct {
+	mutex  sync.Mutex
+	suites []util.JUnitTestSuite
+}
+
+// The timing report of the plugin process. The operations may be monitored concurrently, e.g. by mta-release.
+var currentTimingReport = &timingReport{}
+
+// stepTiming is the time an operation spent in a phase or on a module
+type stepTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// classifyMessage returns the phase to which the message belongs, or an empty string if it is not specific to a phase
+func classifyMessage(text string) string {
+	for _, phasePattern := range phasePatterns {
+		if phasePattern.pattern.MatchString(text) {
+			return phasePattern.phase
+		}
+	}
+	return ""
+}
+
+// getPhaseTimings returns the time spent in each phase, in the order in which the phases were first entered. The
+// phase of a message lasts until the next message is reported or the operation ends.
+func getPhaseTimings(messages []timedMessage, endTime time.Time) []stepTiming {
+	var timings []stepTiming
+	positions := make(map[string]int)
+	for i, message := range messages {
+		nextTime := endTime
+		if i+1 < len(messages) {
+			nextTime = messages[i+1].reportedAt
+		}
+		position, exists := positions[message.phase]
+		if !exists {
+			position = len(timings)
+			positions[message.phase] = position
+			timings = append(timings, stepTiming{name: message.phase})
+		}
+		timings[position].duration += nextTime.Sub(message.reportedAt)
+	}
+	return timings
+}
+
+// addTimedMessage records when the message was reported and to which phase it belongs
+func (m *ExecutionMonitor) addTimedMessage(text string, reportedAt time.Time) {
+	phase := classifyMessage(text)
+	if phase == "" {
+		phase = phaseOther
+		// The upload of the archive by the command ends when the controller reports its first message
+		if count := len(m.timedMessages); count != 0 && m.timedMessages[count-1].text != "" {
+			phase = m.timedMessages[count-1].phase
+		}
+	}
+	m.timedMessages = append(m.timedMessages, timedMessage{text: text, reportedAt: reportedAt, phase: phase})
+}
+
+// reportPhaseTimings prints the time spent in each phase of the operation and adds it to the timing report, if one is
+// configured. Breakdowns of operations which completed within a second are not printed.
+func (m *ExecutionMonitor) reportPhaseTimings(operation *models.Operation) {
+	endTime := time.Now()
+	timings := getPhaseTimings(m.timedMessages, endTime)
+	var total time.Duration
+	for _, timing := range timings {
+		total += timing.duration
+	}
+	if total.Round(time.Second) > 0 {
+		ui.Say("Timing by phase:")
+		table := ui.Table([]string{"phase", "duration", "share"})
+		for _, timing := range timings {
+			table.Add(timing.name, timing.duration.Round(time.Second).String(), fmt.Sprintf("%.0f%%", 100*timing.duration.Seconds()/total.Seconds()))
+		}
+		table.Print()
+	}
+
+	if os.Getenv(timingReportEnv) == "" {
+		return
+	}
+	var cases []util.JUnitTestCase
+	for _, timing := range timings {
+		cases = append(cases, util.JUnitTestCase{Name: timing.name, ClassName: operation.MtaID, Time: util.JUnitSeconds(timing.duration)})
+	}
+	currentTimingReport.addSuite(util.NewJUnitTestSuite(m.getReportSuiteName(), m.startTime, cases))
+}
+
+// addSuite adds the timing breakdown of an operation. A resumed operation replaces the breakdown which was added when
+// it required an action.
+func (r *timingReport) addSuite(suite util.JUnitTestSuite) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.suites {
+		if r.suites[i].Name == suite.Name {
+			r.suites[i] = suite
+			return
+		}
+	}
+	r.suites = append(r.suites, suite)
+}
+
+// FlushTimingReport writes the timing breakdowns of the operations monitored since the last flush to the file in
+// MULTIAPPS_TIMING_REPORT. Nothing is written if no operation was monitored or no report is configured.
+func FlushTimingReport() error {
+	currentTimingReport.mutex.Lock()
+	suites := currentTimingReport.suites
+	currentTimingReport.suites = nil
+	currentTimingReport.mutex.Unlock()
+
+	location := os.Getenv(timingReportEnv)
+	if location == "" || len(suites) == 0 {
+		return nil
+	}
+	if err := util.WriteJUnitReport(location, suites); err != nil {
+		return fmt.Errorf("Could not write the timing report to %s: %s", location, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const operationMetadataEntryName = "metadata.json"
+
+// operationMetadata is stored next to the logs of each operation in a logs archive
+type operationMetadata struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	MtaID     string `json:"mtaId,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	State     string `json:"state"`
+	StartedAt string `json:"startedAt"`
+	User      string `json:"user"`
+}
+
+// archiveLogsForProcesses writes the logs and the metadata of the operations to a single archive, with a directory
+// per operation
+func archiveLogsForProcesses(operations []*models.Operation, archiveLocation string, maxParallelDownloads uint, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	archive, err := util.NewLogsArchive(archiveLocation)
+	if err != nil {
+		ui.Failed("Could not create archive %s: %s", terminal.EntityNameColor(archiveLocation), err)
+		return Failure
+	}
+	for _, operation := range operations {
+		if err = addLogsToArchive(operation.ProcessID, archive, maxParallelDownloads, redactor, mtaClient, cfTarget); err != nil {
+			break
+		}
+	}
+	if closeErr := archive.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("Could not write archive %s: %s", terminal.EntityNameColor(archiveLocation), closeErr)
+	}
+	if err != nil {
+		os.Remove(archiveLocation)
+		ui.Failed(err.Error())
+		return Failure
+	}
+	return Success
+}
+
+func addLogsToArchive(operationId string, archive *util.LogsArchive, maxParallelDownloads uint, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) error {
+	logs, err := getLogsForProcess(operationId, mtaClient, cfTarget)
+	if err != nil {
+		return err
+	}
+	stagingDir, err := downloadLogsToStagingDirectory(operationId, logs, maxParallelDownloads, redactor, mtaClient)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+	operation, err := mtaClient.GetMtaOperation(operationId, "")
+	if err != nil {
+		return fmt.Errorf("Could not get multi-target app operation %s: %s", terminal.EntityNameColor(operationId), baseclient.NewClientError(err))
+	}
+	ui.Ok()
+
+	archiveLocation, _ := filepath.Abs(archive.Location())
+	ui.Say("Saving logs to %s...", terminal.EntityNameColor(archiveLocation))
+	entryDir := defaultDownloadDirPrefix + operationId + "/"
+	for _, logx := range logs {
+		ui.Say("  %s", logx.ID)
+		if err := archive.AddFile(entryDir+logx.ID, filepath.Join(stagingDir, logx.ID)); err != nil {
+			return fmt.Errorf("Could not save log %s: %s", terminal.EntityNameColor(logx.ID), err)
+		}
+	}
+	metadata, _ := json.MarshalIndent(operationMetadata{
+		ID:        operation.ProcessID,
+		Type:      operation.ProcessType,
+		MtaID:     operation.MtaID,
+		Namespace: operation.Namespace,
+		State:     string(operation.State),
+		StartedAt: operation.StartedAt,
+		User:      operation.User,
+	}, "", "  ")
+	ui.Say("  %s", operationMetadataEntryName)
+	if err := archive.AddContent(entryDir+operationMetadataEntryName, metadata); err != nil {
+		return fmt.Errorf("Could not save operation metadata: %s", err)
+	}
+	ui.Ok()
+	return nil
+}
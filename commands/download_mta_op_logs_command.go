@@ -3,11 +3,16 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
@@ -21,6 +26,9 @@ const (
 	mtaOpt                   string = "mta"
 	lastOpt                  string = "last"
 	directoryOpt             string = "d"
+	maxParallelDownloadsOpt  string = "max-parallel-downloads"
+	overwriteOpt             string = "overwrite"
+	updateOpt                string = "update"
 )
 
 // DownloadMtaOperationLogsCommand is a command for retrieving the logs of an MTA operation
@@ -42,15 +50,28 @@ func (c *DownloadMtaOperationLogsCommand) GetPluginCommand() plugin.Command {
 		Alias:    "dmol",
 		HelpText: "Download logs of multi-target app operation",
 		UsageDetails: plugin.Usage{
-			Usage: `cf download-mta-op-logs -i OPERATION_ID [-d DIRECTORY] [-u URL]
+			Usage: `cf download-mta-op-logs -i OPERATION_ID [-d DIRECTORY] [--overwrite | --update] [-u URL]
 
-   cf download-mta-op-logs --mta MTA [--last NUM] [-d DIRECTORY] [-u URL]` + util.BaseEnvHelpText,
+   cf download-mta-op-logs -i OPERATION_ID --follow [-u URL]
+
+   cf download-mta-op-logs --mta MTA [--last NUM] [-d DIRECTORY] [--overwrite | --update] [-u URL]
+
+   cf download-mta-op-logs (-i OPERATION_ID | --mta MTA [--last NUM]) --archive ARCHIVE [-u URL]
+
+   cf download-mta-op-logs (-i OPERATION_ID | --mta MTA [--last NUM]) (--grep PATTERN | --errors-only) [-u URL]` + util.BaseEnvHelpText,
 			Options: map[string]string{
-				operationIDOpt:               "Operation ID",
-				util.GetShortOption(mtaOpt):  "ID of the deployed MTA",
-				util.GetShortOption(lastOpt): "Downloads last NUM operation logs. If not specified, logs for each process with the specified MTA_ID are downloaded",
-				directoryOpt:                 "Root directory to download logs, by default the current working directory",
-				deployServiceURLOpt:          "Deploy service URL, by default 'deploy-service.<system-domain>'",
+				operationIDOpt:                               "Operation ID",
+				util.GetShortOption(mtaOpt):                  "ID of the deployed MTA",
+				util.GetShortOption(lastOpt):                 "Downloads last NUM operation logs. If not specified, logs for each process with the specified MTA_ID are downloaded",
+				directoryOpt:                                 "Root directory to download logs, by default the current working directory",
+				util.GetShortOption(overwriteOpt):            "Replace the download directory of an operation if it already exists",
+				util.GetShortOption(updateOpt):               "Download again only the logs which changed since the previous download to the directory",
+				util.GetShortOption(archiveOpt):              "Write the logs and the metadata of the operations to a .zip, .tar.gz or .tgz archive instead of a directory",
+				util.GetShortOption(maxParallelDownloadsOpt): "Maximum number of logs downloaded in parallel (default 4)",
+				util.GetShortOption(grepOpt):                 "Print the log lines which match the regular expression PATTERN, instead of downloading the logs",
+				util.GetShortOption(errorsOnlyOpt):           "Print the errors and stacktraces found in the logs, each distinct error once, instead of downloading the logs",
+				util.GetShortOption(followOpt):               "Print the new content of the logs until the operation completes, instead of downloading them",
+				deployServiceURLOpt:                          "Deploy service URL, by default 'deploy-service.<system-domain>'",
 			},
 		},
 	}
@@ -61,6 +82,13 @@ func (c *DownloadMtaOperationLogsCommand) defineCommandOptions(flags *flag.FlagS
 	flags.String(directoryOpt, "", "")
 	flags.String(mtaOpt, "", "")
 	flags.Uint(lastOpt, 0, "")
+	flags.Bool(followOpt, false, "")
+	flags.String(archiveOpt, "", "")
+	flags.Uint(maxParallelDownloadsOpt, 4, "")
+	flags.String(grepOpt, "", "")
+	flags.Bool(errorsOnlyOpt, false, "")
+	flags.Bool(overwriteOpt, false, "")
+	flags.Bool(updateOpt, false, "")
 }
 
 func (c *DownloadMtaOperationLogsCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
@@ -71,25 +99,49 @@ func (c *DownloadMtaOperationLogsCommand) executeInternal(positionalArgs []strin
 	last := GetUintOpt(lastOpt, flags)
 	operationId := GetStringOpt(operationIDOpt, flags)
 	downloadDirName := GetStringOpt(directoryOpt, flags)
+	archiveLocation := GetStringOpt(archiveOpt, flags)
+	maxParallelDownloads := GetUintOpt(maxParallelDownloadsOpt, flags)
+	redactor := newRedactor()
+
+	if GetBoolOpt(followOpt, flags) {
+		return followLogsForProcess(operationId, redactor, mtaClient, cfTarget)
+	}
+	if maxParallelDownloads == 0 {
+		ui.Failed("The %q option must be a positive number", "--"+maxParallelDownloadsOpt)
+		return Failure
+	}
+	var searchPattern *regexp.Regexp
+	if grep := GetStringOpt(grepOpt, flags); grep != "" {
+		var err error
+		if searchPattern, err = regexp.Compile(grep); err != nil {
+			ui.Failed("Invalid search pattern %s: %s", terminal.EntityNameColor(grep), err)
+			return Failure
+		}
+	}
 
-	var operationIds []string
+	var operations []*models.Operation
 
 	if mtaId != "" {
-		operations, err := mtaClient.GetMtaOperations(&mtaId, getOperationsCount(last), nil)
+		var err error
+		operations, err = mtaClient.GetMtaOperations(&mtaId, getOperationsCount(last), nil)
 		if err != nil {
 			ui.Failed("Could not get operations for MTA with ID %s: %s", mtaId, baseclient.NewClientError(err))
 			return Failure
 		}
-		for _, op := range operations {
-			operationIds = append(operationIds, op.ProcessID)
-		}
 	} else {
-		operationIds = append(operationIds, operationId)
+		operations = append(operations, &models.Operation{ProcessID: operationId})
+	}
+
+	if searchPattern != nil || GetBoolOpt(errorsOnlyOpt, flags) {
+		return searchLogsForProcesses(operations, searchPattern, maxParallelDownloads, redactor, mtaClient, cfTarget)
+	}
+	if archiveLocation != "" {
+		return archiveLogsForProcesses(operations, archiveLocation, maxParallelDownloads, redactor, mtaClient, cfTarget)
 	}
 
-	for _, opId := range operationIds {
-		downloadPath := filepath.Join(downloadDirName, defaultDownloadDirPrefix+opId)
-		err := downloadLogsForProcess(opId, downloadPath, mtaClient, cfTarget)
+	for _, operation := range operations {
+		downloadPath := filepath.Join(downloadDirName, defaultDownloadDirPrefix+operation.ProcessID)
+		err := downloadLogsForProcess(operation.ProcessID, downloadPath, GetBoolOpt(overwriteOpt, flags), GetBoolOpt(updateOpt, flags), maxParallelDownloads, redactor, mtaClient, cfTarget)
 		if err != nil {
 			ui.Failed(err.Error())
 			return Failure
@@ -98,46 +150,108 @@ func (c *DownloadMtaOperationLogsCommand) executeInternal(positionalArgs []strin
 	return Success
 }
 
-func downloadLogsForProcess(operationId string, downloadPath string, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) error {
-	// Print initial message
-	ui.Say("Downloading logs of multi-target app operation with ID %s in org %s / space %s as %s...",
-		terminal.EntityNameColor(operationId), terminal.EntityNameColor(cfTarget.Org.Name),
-		terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
-
-	// Download all logs
-	downloadedLogs := make(map[string]*string)
-	logs, err := mtaClient.GetMtaOperationLogs(operationId)
+func downloadLogsForProcess(operationId string, downloadPath string, overwrite, update bool, maxParallelDownloads uint, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) error {
+	logs, err := getLogsForProcess(operationId, mtaClient, cfTarget)
 	if err != nil {
-		return fmt.Errorf("Could not get process logs: %s", baseclient.NewClientError(err))
+		return err
 	}
-	for _, logx := range logs {
-		content, err := mtaClient.GetMtaOperationLogContent(operationId, logx.ID)
+
+	// Only the logs which changed since the previous download are downloaded again in update mode
+	logsToDownload := logs
+	if update {
+		index, err := readLogsIndex(downloadPath)
 		if err != nil {
-			return fmt.Errorf("Could not get content of log %s: %s", terminal.EntityNameColor(logx.ID), baseclient.NewClientError(err))
+			return fmt.Errorf("Could not read the index of the previously downloaded logs: %s", err)
 		}
-		downloadedLogs[logx.ID] = &content
+		logsToDownload = index.getChangedLogs(logs)
 	}
+
+	// Download the logs to a staging directory
+	stagingDir, err := downloadLogsToStagingDirectory(operationId, logsToDownload, maxParallelDownloads, redactor, mtaClient)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
 	ui.Ok()
 
 	// Create the download directory
-	downloadDir, err := createDownloadDirectory(downloadPath)
+	downloadDir, err := createDownloadDirectory(downloadPath, overwrite, update)
 	if err != nil {
 		return fmt.Errorf("Could not create download directory %s: %s", terminal.EntityNameColor(downloadPath), baseclient.NewClientError(err))
 	}
 
-	// Get all logs and save their contents to the download directory
+	// Move the logs from the staging directory to the download directory
 	ui.Say("Saving logs to %s...", terminal.EntityNameColor(downloadDir))
-	for logID, content := range downloadedLogs {
-		err = saveLogContent(downloadDir, logID, content)
+	for _, logx := range logsToDownload {
+		err = saveLogContent(downloadDir, logx.ID, filepath.Join(stagingDir, logx.ID))
 		if err != nil {
-			return fmt.Errorf("Could not save log %s: %s", terminal.EntityNameColor(logID), baseclient.NewClientError(err))
+			return fmt.Errorf("Could not save log %s: %s", terminal.EntityNameColor(logx.ID), baseclient.NewClientError(err))
 		}
 	}
+	if len(logsToDownload) == 0 {
+		ui.Say("  All logs are up to date")
+	}
+	if err := writeLogsIndex(downloadDir, logs); err != nil {
+		return fmt.Errorf("Could not write the index of the downloaded logs: %s", err)
+	}
 	ui.Ok()
 	return nil
 }
 
-func createDownloadDirectory(downloadDirName string) (string, error) {
+func getLogsForProcess(operationId string, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) ([]*models.Log, error) {
+	// Print initial message
+	ui.Say("Downloading logs of multi-target app operation with ID %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(operationId), terminal.EntityNameColor(cfTarget.Org.Name),
+		terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+
+	logs, err := mtaClient.GetMtaOperationLogs(operationId)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get process logs: %s", baseclient.NewClientError(err))
+	}
+	return logs, nil
+}
+
+// downloadLogsToStagingDirectory downloads the logs of an operation in parallel to a temporary directory, so that
+// the content of the logs is not held in memory. The secrets in the logs are redacted before they are saved.
+func downloadLogsToStagingDirectory(operationId string, logs []*models.Log, maxParallelDownloads uint, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations) (string, error) {
+	stagingDir, err := os.MkdirTemp("", defaultDownloadDirPrefix+operationId+"-")
+	if err != nil {
+		return "", fmt.Errorf("Could not create temporary directory: %s", err)
+	}
+
+	semaphore := make(chan struct{}, maxParallelDownloads)
+	var waitGroup sync.WaitGroup
+	errs := make([]error, len(logs))
+	for i, logx := range logs {
+		semaphore <- struct{}{}
+		waitGroup.Add(1)
+		go func(i int, logID string) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			content, err := mtaClient.GetMtaOperationLogContent(operationId, logID)
+			if err != nil {
+				errs[i] = fmt.Errorf("Could not get content of log %s: %s", terminal.EntityNameColor(logID), baseclient.NewClientError(err))
+				return
+			}
+			if err := os.WriteFile(filepath.Join(stagingDir, logID), []byte(redactor.Redact(content)), 0644); err != nil {
+				errs[i] = fmt.Errorf("Could not save log %s: %s", terminal.EntityNameColor(logID), err)
+			}
+		}(i, logx.ID)
+	}
+	waitGroup.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			os.RemoveAll(stagingDir)
+			return "", err
+		}
+	}
+	return stagingDir, nil
+}
+
+// createDownloadDirectory creates the download directory. An existing directory is removed first if overwrite is
+// set, and is reused if update is set.
+func createDownloadDirectory(downloadDirName string, overwrite, update bool) (string, error) {
 	// Check if directory name ends with the os specific path separator
 	if !strings.HasSuffix(downloadDirName, string(os.PathSeparator)) {
 		//If there is no os specific path separator, put it at the end of the directory name
@@ -146,7 +260,13 @@ func createDownloadDirectory(downloadDirName string) (string, error) {
 
 	// Check if the directory already exists
 	if stat, _ := os.Stat(downloadDirName); stat != nil {
-		return "", fmt.Errorf("File or directory already exists.")
+		if overwrite {
+			if err := os.RemoveAll(downloadDirName); err != nil {
+				return "", err
+			}
+		} else if !update {
+			return "", fmt.Errorf("File or directory already exists.")
+		}
 	}
 
 	// Create the directory
@@ -159,9 +279,30 @@ func createDownloadDirectory(downloadDirName string) (string, error) {
 	return filepath.Abs(filepath.Dir(downloadDirName))
 }
 
-func saveLogContent(downloadDir, logID string, content *string) error {
+func saveLogContent(downloadDir, logID, stagedLocation string) error {
 	ui.Say("  %s", logID)
-	return os.WriteFile(filepath.Join(downloadDir, logID), []byte(*content), 0644)
+	return moveFile(stagedLocation, filepath.Join(downloadDir, logID))
+}
+
+// moveFile renames the file and falls back to copying it when the target is on another file system
+func moveFile(source, target string) error {
+	if err := os.Rename(source, target); err == nil {
+		return nil
+	}
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+	targetFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(targetFile, sourceFile); err != nil {
+		targetFile.Close()
+		return err
+	}
+	return targetFile.Close()
 }
 
 type dmolCommandFlagsValidator struct{}
@@ -170,6 +311,26 @@ func (dmolCommandFlagsValidator) ValidateParsedFlags(flags *flag.FlagSet) error
 	if hasValue(flags, "i") && hasValue(flags, "mta") {
 		return fmt.Errorf("Option -i and option --mta are incompatible")
 	}
+	if GetBoolOpt(followOpt, flags) && hasValue(flags, mtaOpt) {
+		return fmt.Errorf("Option --follow and option --mta are incompatible")
+	}
+	if hasValue(flags, archiveOpt) && hasValue(flags, directoryOpt) {
+		return fmt.Errorf("Option -d and option --archive are incompatible")
+	}
+	if GetBoolOpt(overwriteOpt, flags) && GetBoolOpt(updateOpt, flags) {
+		return fmt.Errorf("Option --overwrite and option --update are incompatible")
+	}
+	if (GetBoolOpt(overwriteOpt, flags) || GetBoolOpt(updateOpt, flags)) && hasValue(flags, archiveOpt) {
+		return fmt.Errorf("Options --overwrite and --update cannot be used together with --archive")
+	}
+	if hasValue(flags, grepOpt) && GetBoolOpt(errorsOnlyOpt, flags) {
+		return fmt.Errorf("Option --grep and option --errors-only are incompatible")
+	}
+	if hasValue(flags, grepOpt) || GetBoolOpt(errorsOnlyOpt, flags) {
+		if hasValue(flags, directoryOpt) || hasValue(flags, archiveOpt) || GetBoolOpt(followOpt, flags) {
+			return fmt.Errorf("Options -d, --archive and --follow cannot be used when searching logs")
+		}
+	}
 	return NewDefaultCommandFlagsValidator(map[string]bool{
 		operationIDOpt: !hasValue(flags, mtaOpt),
 		mtaOpt:         hasValue(flags, mtaOpt)}).ValidateParsedFlags(flags)
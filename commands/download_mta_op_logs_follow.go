@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	followOpt = "follow"
+
+	followLogsPollInterval = 3 * time.Second
+)
+
+var (
+	privateKeyBeginPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEndPattern   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// logsFollower prints the content which has been appended to the logs of an operation since the last poll
+type logsFollower struct {
+	operationID string
+	mtaClient   mtaclient.MtaClientOperations
+	redactor    *secure_parameters.Redactor
+	// The number of bytes of each log which have already been printed, by log ID
+	offsets map[string]int
+}
+
+func newLogsFollower(operationID string, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations) *logsFollower {
+	return &logsFollower{operationID: operationID, mtaClient: mtaClient, redactor: redactor, offsets: make(map[string]int)}
+}
+
+// followLogsForProcess prints the new content of the operation logs until the operation reaches a terminal state
+func followLogsForProcess(operationID string, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	ui.Say("Following logs of multi-target app operation with ID %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(operationID), terminal.EntityNameColor(cfTarget.Org.Name),
+		terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+
+	follower := newLogsFollower(operationID, redactor, mtaClient)
+	for {
+		// The state is checked before the logs are read, so that the content written right before the operation
+		// completed is still printed
+		operation, err := mtaClient.GetMtaOperation(operationID, "")
+		if err != nil {
+			ui.Failed("Could not get multi-target app operation %s: %s", terminal.EntityNameColor(operationID), baseclient.NewClientError(err))
+			return Failure
+		}
+		completed := operation.State == models.StateFINISHED || operation.State == models.StateABORTED
+		if err := follower.printNewContent(completed); err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		if completed {
+			ui.Say("Multi-target app operation %s completed with status %s", terminal.EntityNameColor(operationID), operation.State)
+			if operation.State == models.StateABORTED {
+				return Failure
+			}
+			return Success
+		}
+		time.Sleep(followLogsPollInterval)
+	}
+}
+
+// printNewContent prints the complete lines which have been appended to each log since the last call. The last
+// incomplete line of a log and the lines of a private key whose end has not been logged yet are printed only if flush
+// is set, because they can be redacted only as a whole.
+func (f *logsFollower) printNewContent(flush bool) error {
+	logs, err := f.mtaClient.GetMtaOperationLogs(f.operationID)
+	if err != nil {
+		return fmt.Errorf("Could not get process logs: %s", baseclient.NewClientError(err))
+	}
+	for _, log := range logs {
+		content, err := f.mtaClient.GetMtaOperationLogContent(f.operationID, log.ID)
+		if err != nil {
+			return fmt.Errorf("Could not get content of log %s: %s", terminal.EntityNameColor(log.ID), baseclient.NewClientError(err))
+		}
+		offset := f.offsets[log.ID]
+		if offset > len(content) {
+			// The log has been truncated, so it is printed from the start
+			offset = 0
+		}
+		newContent := content[offset:]
+		if !flush {
+			newContent = newContent[:strings.LastIndex(newContent, "\n")+1]
+			newContent = newContent[:getUnterminatedPrivateKeyStart(newContent)]
+		}
+		f.offsets[log.ID] = offset + len(newContent)
+		for _, line := range strings.Split(strings.TrimSuffix(f.redactor.Redact(newContent), "\n"), "\n") {
+			if line != "" {
+				ui.Say("[%s] %s", log.ID, line)
+			}
+		}
+	}
+	return nil
+}
+
+// getUnterminatedPrivateKeyStart returns the start of the line with the last private key in the content whose end is
+// not part of the content, or the length of the content if there is no such key
+func getUnterminatedPrivateKeyStart(content string) int {
+	beginIndices := privateKeyBeginPattern.FindAllStringIndex(content, -1)
+	if len(beginIndices) == 0 {
+		return len(content)
+	}
+	lastBegin := beginIndices[len(beginIndices)-1]
+	if privateKeyEndPattern.MatchString(content[lastBegin[1]:]) {
+		return len(content)
+	}
+	return strings.LastIndex(content[:lastBegin[0]], "\n") + 1
+}
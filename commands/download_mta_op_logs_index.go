@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+)
+
+// The index file records the logs downloaded to a directory, so that only the changed logs are downloaded on update
+const logsIndexFileName = ".dmol-index.json"
+
+type logsIndex struct {
+	Logs map[string]logsIndexEntry `json:"logs"`
+}
+
+type logsIndexEntry struct {
+	LastModified time.Time `json:"lastModified"`
+	Size         int64     `json:"size"`
+}
+
+func newLogsIndexEntry(logx *models.Log) logsIndexEntry {
+	return logsIndexEntry{LastModified: time.Time(logx.LastModified).UTC(), Size: logx.Size}
+}
+
+// readLogsIndex reads the index of the download directory. A directory without an index results in an empty index,
+// so that all logs are downloaded.
+func readLogsIndex(downloadDir string) (logsIndex, error) {
+	index := logsIndex{Logs: make(map[string]logsIndexEntry)}
+	content, err := os.ReadFile(filepath.Join(downloadDir, logsIndexFileName))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return index, err
+	}
+	return index, nil
+}
+
+func writeLogsIndex(downloadDir string, logs []*models.Log) error {
+	index := logsIndex{Logs: make(map[string]logsIndexEntry)}
+	for _, logx := range logs {
+		index.Logs[logx.ID] = newLogsIndexEntry(logx)
+	}
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(downloadDir, logsIndexFileName), content, 0644)
+}
+
+// getChangedLogs returns the logs which are not in the index or whose size or modification time differ from the index
+func (i logsIndex) getChangedLogs(logs []*models.Log) []*models.Log {
+	var changedLogs []*models.Log
+	for _, logx := range logs {
+		entry, exists := i.Logs[logx.ID]
+		if !exists || !entry.LastModified.Equal(newLogsIndexEntry(logx).LastModified) || entry.Size != logx.Size {
+			changedLogs = append(changedLogs, logx)
+		}
+	}
+	return changedLogs
+}
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	grepOpt       = "grep"
+	errorsOnlyOpt = "errors-only"
+
+	maxLogLineLength = 1024 * 1024
+)
+
+var (
+	// The timestamp with which the controller starts each log line, e.g. [2024-05-14 10:21:33.128]
+	logTimestampPattern = regexp.MustCompile(`^\[?\d{4}-\d{2}-\d{2}[T ][0-9:.,]+(Z|[+-]\d{2}:?\d{2})?\]?\s*`)
+	// A line which starts an error block, either because it is logged with the error level or because it names an exception
+	errorStartPattern = regexp.MustCompile(`\bERROR\b|[\w.$]+(Exception|Error)(:|$)`)
+	// A line which continues the stacktrace of an error block
+	stackTraceLinePattern = regexp.MustCompile(`^\s+at\s|^\s*Caused by:|^\s*Suppressed:|^\s+\.\.\. \d+ (more|common frames omitted)`)
+)
+
+// logLocation identifies a line in the log of an operation
+type logLocation struct {
+	operationID string
+	logID       string
+	line        int
+}
+
+func (l logLocation) String() string {
+	return fmt.Sprintf("%s %s:%d", terminal.EntityNameColor(l.operationID), l.logID, l.line)
+}
+
+// errorBlock is an error line of a log together with its stacktrace
+type errorBlock struct {
+	location     logLocation
+	lines        []string
+	operationIDs []string
+}
+
+// key returns the content of the error block without the timestamp, so that the same error logged by several
+// operations is reported once
+func (b *errorBlock) key() string {
+	return logTimestampPattern.ReplaceAllString(strings.Join(b.lines, "\n"), "")
+}
+
+// logsSearch collects the matching lines or the error blocks of the searched logs
+type logsSearch struct {
+	pattern      *regexp.Regexp
+	matches      []string
+	errorBlocks  []*errorBlock
+	blocksByKeys map[string]*errorBlock
+}
+
+// searchLogsForProcesses downloads the logs of the operations and prints the lines which match the pattern, or the
+// error blocks if no pattern is specified
+func searchLogsForProcesses(operations []*models.Operation, pattern *regexp.Regexp, maxParallelDownloads uint, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	search := &logsSearch{pattern: pattern, blocksByKeys: make(map[string]*errorBlock)}
+	for _, operation := range operations {
+		if err := search.searchLogsForProcess(operation.ProcessID, maxParallelDownloads, redactor, mtaClient, cfTarget); err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+	}
+
+	if pattern != nil {
+		search.printMatches()
+	} else {
+		search.printErrorBlocks()
+	}
+	return Success
+}
+
+func (s *logsSearch) searchLogsForProcess(operationId string, maxParallelDownloads uint, redactor *secure_parameters.Redactor, mtaClient mtaclient.MtaClientOperations, cfTarget util.CloudFoundryTarget) error {
+	logs, err := getLogsForProcess(operationId, mtaClient, cfTarget)
+	if err != nil {
+		return err
+	}
+	stagingDir, err := downloadLogsToStagingDirectory(operationId, logs, maxParallelDownloads, redactor, mtaClient)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+	ui.Ok()
+
+	for _, logx := range logs {
+		var currentBlock *errorBlock
+		err := scanLogLines(filepath.Join(stagingDir, logx.ID), func(number int, line string) {
+			location := logLocation{operationID: operationId, logID: logx.ID, line: number}
+			if s.pattern != nil {
+				if s.pattern.MatchString(line) {
+					s.matches = append(s.matches, fmt.Sprintf("%s: %s", location, line))
+				}
+				return
+			}
+			if currentBlock != nil && stackTraceLinePattern.MatchString(line) {
+				currentBlock.lines = append(currentBlock.lines, line)
+				return
+			}
+			s.addErrorBlock(currentBlock)
+			currentBlock = nil
+			if errorStartPattern.MatchString(line) {
+				currentBlock = &errorBlock{location: location, lines: []string{line}, operationIDs: []string{operationId}}
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("Could not read log %s: %s", terminal.EntityNameColor(logx.ID), err)
+		}
+		s.addErrorBlock(currentBlock)
+	}
+	return nil
+}
+
+func (s *logsSearch) addErrorBlock(block *errorBlock) {
+	if block == nil {
+		return
+	}
+	key := block.key()
+	existingBlock, exists := s.blocksByKeys[key]
+	if !exists {
+		s.blocksByKeys[key] = block
+		s.errorBlocks = append(s.errorBlocks, block)
+		return
+	}
+	if !util.Contains(existingBlock.operationIDs, block.location.operationID) {
+		existingBlock.operationIDs = append(existingBlock.operationIDs, block.location.operationID)
+	}
+}
+
+func (s *logsSearch) printMatches() {
+	if len(s.matches) == 0 {
+		ui.Say("\nNo lines match %s", terminal.EntityNameColor(s.pattern.String()))
+		return
+	}
+	ui.Say("\nLines matching %s:", terminal.EntityNameColor(s.pattern.String()))
+	for _, match := range s.matches {
+		ui.Say("%s", match)
+	}
+}
+
+func (s *logsSearch) printErrorBlocks() {
+	if len(s.errorBlocks) == 0 {
+		ui.Say("\nNo errors found")
+		return
+	}
+	ui.Say("\nFound %d distinct errors:", len(s.errorBlocks))
+	for _, block := range s.errorBlocks {
+		ui.Say("\n%s (operations: %s)", block.location, strings.Join(block.operationIDs, ", "))
+		for _, line := range block.lines {
+			ui.Say("  %s", line)
+		}
+	}
+}
+
+// scanLogLines calls the handler for each line of the log, with line numbers starting at 1
+func scanLogLines(location string, handle func(number int, line string)) error {
+	file, err := os.Open(location)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLogLineLength)
+	for number := 1; scanner.Scan(); number++ {
+		handle(number, scanner.Text())
+	}
+	return scanner.Err()
+}
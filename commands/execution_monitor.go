@@ -7,14 +7,25 @@ import (
 	"net/url"
 
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	mtaclient "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
 )
 
+// Results of deployments which are started and monitored on behalf of the user
+const (
+	deploymentSucceeded      = "succeeded"
+	deploymentFailed         = "failed"
+	deploymentActionRequired = "action required"
+	deploymentSkipped        = "skipped"
+)
+
 // ExecutionMonitor monitors execution of a process
 type ExecutionMonitor struct {
 	mtaClient          mtaclient.MtaClientOperations
@@ -24,6 +35,15 @@ type ExecutionMonitor struct {
 	operationID        string
 	embed              string
 	retries            uint
+	prompt             *ui.Prompt
+	cfClient           cfrestclient.CloudFoundryOperationsExtended
+	redactor           *secure_parameters.Redactor
+	startTime          time.Time
+	historyContext     deploymentHistoryContext
+	timedMessages      []timedMessage
+	observers          operationObservers
+	notifiedEvent      string
+	parentSpan         *tracing.Span
 }
 
 func NewExecutionMonitorFromLocationHeader(commandName, location string, retries uint, reportedOperationMessages []*models.Message, mtaClient mtaclient.MtaClientOperations) *ExecutionMonitor {
@@ -35,6 +55,8 @@ func NewExecutionMonitorFromLocationHeader(commandName, location string, retries
 		operationID:      operationID,
 		embed:            embed,
 		retries:          retries,
+		redactor:         newRedactor(),
+		startTime:        time.Now(),
 	}
 }
 
@@ -54,9 +76,20 @@ func NewExecutionMonitor(commandName, operationID, embed string, retries uint, r
 		operationID:      operationID,
 		embed:            embed,
 		retries:          retries,
+		redactor:         newRedactor(),
+		startTime:        time.Now(),
 	}
 }
 
+// EnableActionPrompt makes the monitor ask the user through the prompt whether to resume, abort or detach from the
+// process when it requires an action, instead of only reporting the available actions. The CF client is used to show
+// the URLs of the idle apps.
+func (m *ExecutionMonitor) EnableActionPrompt(cfClient cfrestclient.CloudFoundryOperationsExtended, prompt *ui.Prompt) *ExecutionMonitor {
+	m.prompt = prompt
+	m.cfClient = cfClient
+	return m
+}
+
 func getAlreadyReportedOperationMessages(reportedOperationMessages []*models.Message) map[int64]bool {
 	result := make(map[int64]bool)
 	for _, message := range reportedOperationMessages {
@@ -65,26 +98,117 @@ func getAlreadyReportedOperationMessages(reportedOperationMessages []*models.Mes
 	return result
 }
 
+// withHistoryContext adds the details which only the command knows, such as the target and the archive, to the record
+// of the operation in the deployment history
+func (m *ExecutionMonitor) withHistoryContext(context deploymentHistoryContext) *ExecutionMonitor {
+	m.historyContext = context
+	return m
+}
+
+// withUploadStart adds the upload of the MTA archive, which precedes the monitored operation, to its phase timings
+func (m *ExecutionMonitor) withUploadStart(uploadStartTime time.Time) *ExecutionMonitor {
+	m.timedMessages = append([]timedMessage{{reportedAt: uploadStartTime, phase: phaseUpload}}, m.timedMessages...)
+	return m
+}
+
+// withObservers makes the monitor add the result of the operation to the report of the command and notify the webhook
+// about its state changes
+func (m *ExecutionMonitor) withObservers(observers operationObservers) *ExecutionMonitor {
+	m.observers = observers
+	return m
+}
+
+// WithParentSpan makes the spans of the monitored operation children of the span, usually the span of the command.
+// The parent is passed explicitly, because operations may be monitored concurrently. Operations are not traced
+// without a parent span.
+func (m *ExecutionMonitor) WithParentSpan(span *tracing.Span) *ExecutionMonitor {
+	m.parentSpan = span
+	return m
+}
+
+// Monitor reports the messages of the operation until it completes or requires an action, reports the time spent in
+// each of its phases and records it in the deployment history
 func (m *ExecutionMonitor) Monitor() ExecutionStatus {
+	status, _ := m.monitorOperation()
+	return status
+}
+
+// monitorOperation monitors the operation like Monitor and returns its last state, or nil if it could not be
+// determined. An operation which the user resumes through the action prompt is monitored until it completes.
+func (m *ExecutionMonitor) monitorOperation() (ExecutionStatus, *models.Operation) {
+	span := m.parentSpan.StartChild("monitor operation")
+	span.SetAttribute("operation.id", m.operationID)
+	status, operation := m.monitor(span)
+	if status == Failure {
+		span.Fail("The operation did not finish successfully")
+	}
+	span.End()
+	if operation == nil {
+		return status, nil
+	}
+	m.completeStage(operation)
+	if operation.State == models.StateACTIONREQUIRED && m.prompt != nil {
+		return m.askForAction(operation)
+	}
+	return status, operation
+}
+
+// completeStage notifies the observers about the state in which the monitoring stopped, reports the time spent in each
+// phase and records the operation in the deployment history
+func (m *ExecutionMonitor) completeStage(operation *models.Operation) {
+	m.notifyStateChange(operation)
+	m.reportPhaseTimings(operation)
+	m.observers.report.addOperation(m, operation)
+	m.recordOperation(operation)
+}
+
+// resume executes the resume action on the operation, which requires an action, and monitors it until it completes or
+// requires an action again
+func (m *ExecutionMonitor) resume() (ExecutionStatus, *models.Operation) {
+	resumeAction := newAction("resume", VerbosityLevelVERBOSE)
+	if resumeAction.Execute(m.operationID, m.mtaClient) == Failure {
+		return Failure, nil
+	}
+	return m.monitorOperation()
+}
+
+// pollOperation gets the current state of the operation within a child of the span of the monitoring, so that the
+// polls are visible in the traces
+func (m *ExecutionMonitor) pollOperation(monitorSpan *tracing.Span) (*models.Operation, error) {
+	span := monitorSpan.StartChild("poll operation")
+	defer span.End()
+	span.SetAttribute("operation.id", m.operationID)
+	operation, err := m.mtaClient.GetMtaOperation(m.operationID, m.embed)
+	if err != nil {
+		span.Fail(err.Error())
+		return nil, err
+	}
+	span.SetAttribute("operation.state", string(operation.State))
+	return operation, nil
+}
+
+// monitor returns the last state of the operation, or nil if it should not be recorded
+func (m *ExecutionMonitor) monitor(span *tracing.Span) (ExecutionStatus, *models.Operation) {
 	totalRetries := m.retries
 	for {
-		operation, err := m.mtaClient.GetMtaOperation(m.operationID, m.embed)
+		operation, err := m.pollOperation(span)
 		if err != nil {
 			ui.Failed("Could not get ongoing operation: %s", baseclient.NewClientError(err))
-			return Failure
+			return Failure, nil
 		}
 		m.reportOperationMessages(operation)
 		switch operation.State {
 		case models.StateRUNNING:
+			m.notifyStateChange(operation)
 			time.Sleep(3 * time.Second)
 		case models.StateFINISHED:
 			ui.Say("Process finished.")
 			m.reportCommandForDownloadOfProcessLogs(m.operationID)
-			return Success
+			return Success, operation
 		case models.StateABORTED:
 			ui.Say("Process was aborted.")
 			m.reportCommandForDownloadOfProcessLogs(m.operationID)
-			return Failure
+			return Failure, operation
 		case models.StateERROR:
 			if canRetry(m.retries, operation) {
 				ui.Say("Proceeding with automatic retry... (%d of %d attempts left)", m.retries, totalRetries)
@@ -94,25 +218,103 @@ func (m *ExecutionMonitor) Monitor() ExecutionStatus {
 			messageInError := findErrorMessage(operation.Messages)
 			if messageInError == nil {
 				ui.Failed("There is no error message for operation with ID %s", m.operationID)
-				return Failure
+				return Failure, operation
 			}
 			ui.Say("Process failed.")
 			m.reportAvaiableActions(m.operationID)
 			m.reportCommandForDownloadOfProcessLogs(m.operationID)
-			return Failure
+			return Failure, operation
 		case models.StateACTIONREQUIRED:
+			if m.prompt != nil {
+				return Success, operation
+			}
 			intermediatePhase, flag := getIntermediatePhaseAndFlag(m.commandName)
 			ui.Say("Process has entered %s phase. After testing your new deployment you can resume or abort the process.", intermediatePhase)
 			m.reportAvaiableActions(m.operationID)
 			ui.Say("Hint: Use the %q option of the %s command to skip this phase.", flag, m.commandName)
-			return Success
+			return Success, operation
 		default:
 			ui.Failed("Process is in illegal state %s.", terminal.EntityNameColor(string(operation.State)))
-			return Failure
+			return Failure, operation
 		}
 	}
 }
 
+// monitorDeployment monitors the operation at the monitoring location until it completes and returns the result of
+// the deployment, which is one of deploymentSucceeded, deploymentActionRequired and deploymentFailed
+func monitorDeployment(commandName, operationLocation string, retries uint, mtaClient mtaclient.MtaClientOperations,
+	historyContext deploymentHistoryContext, parentSpan *tracing.Span) string {
+	executionMonitor := NewExecutionMonitorFromLocationHeader(commandName, operationLocation, retries, []*models.Message{}, mtaClient).
+		withHistoryContext(historyContext).
+		WithParentSpan(parentSpan)
+	if executionMonitor.Monitor() == Failure {
+		return deploymentFailed
+	}
+	operationID, _ := getMonitoringInformation(operationLocation)
+	operation, err := mtaClient.GetMtaOperation(operationID, "")
+	if err == nil && operation.State == models.StateACTIONREQUIRED {
+		return deploymentActionRequired
+	}
+	return deploymentSucceeded
+}
+
+// askForAction shows the URLs of the idle apps of the operation and executes the action chosen by the user. If the
+// user detaches, the available actions are reported as in non-interactive sessions.
+func (m *ExecutionMonitor) askForAction(operation *models.Operation) (ExecutionStatus, *models.Operation) {
+	intermediatePhase, flag := getIntermediatePhaseAndFlag(m.commandName)
+	ui.Say("Process has entered %s phase. After testing your new deployment you can resume or abort the process.", intermediatePhase)
+	m.reportIdleAppURLs(operation)
+	for {
+		answer := strings.ToLower(strings.TrimSpace(m.prompt.Ask("Resume, abort or detach from the process? (resume/abort/detach)")))
+		switch answer {
+		case "resume":
+			return m.resume()
+		case "abort":
+			if GetActionToExecute(answer, m.commandName, 0).Execute(m.operationID, m.mtaClient) == Failure {
+				return Failure, operation
+			}
+			ui.Say("Process was aborted.")
+			m.reportCommandForDownloadOfProcessLogs(m.operationID)
+			abortedOperation := *operation
+			abortedOperation.State = models.StateABORTED
+			m.completeStage(&abortedOperation)
+			return Failure, &abortedOperation
+		case "detach", "":
+			m.reportAvaiableActions(m.operationID)
+			ui.Say("Hint: Use the %q option of the %s command to skip this phase.", flag, m.commandName)
+			return Success, operation
+		default:
+			ui.Say("Invalid choice %s", terminal.EntityNameColor(answer))
+		}
+	}
+}
+
+func (m *ExecutionMonitor) reportIdleAppURLs(operation *models.Operation) {
+	apps, err := m.cfClient.GetApplications(operation.MtaID, operation.Namespace, operation.SpaceID)
+	if err != nil {
+		ui.Warn("Could not get the URLs of the idle apps: %s", err)
+		return
+	}
+	var urls []string
+	for _, app := range getIdleApps(apps) {
+		routes, err := m.cfClient.GetApplicationRoutes(app.Guid)
+		if err != nil {
+			ui.Warn("Could not get the URLs of the idle apps: %s", err)
+			return
+		}
+		for _, route := range routes {
+			urls = append(urls, "https://"+route.Url)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+	ui.Say("Idle app URLs:")
+	for _, url := range urls {
+		ui.Say("  %s", url)
+	}
+}
+
 func getIntermediatePhaseAndFlag(commandName string) (string, string) {
 	//for backwards compatibility until the bg-deploy deprecation period expires
 	if commandName == "bg-deploy" {
@@ -141,12 +343,15 @@ func findErrorMessage(messages models.OperationMessages) *models.Message {
 }
 
 func (m *ExecutionMonitor) reportOperationMessages(operation *models.Operation) {
+	reportedAt := time.Now()
 	for _, message := range operation.Messages {
 		if m.reportedMessages[message.ID] {
 			continue
 		}
 		m.reportedMessages[message.ID] = true
-		ui.Say("%s", message.Text)
+		text := m.redactor.Redact(message.Text)
+		m.addTimedMessage(text, reportedAt)
+		ui.Say("%s", text)
 	}
 }
 
@@ -158,11 +363,15 @@ func (m *ExecutionMonitor) reportAvaiableActions(operationID string) {
 }
 
 func (m *ExecutionMonitor) reportCommandForDownloadOfProcessLogs(operationID string) {
+	ui.Say("Use \"%s\" to download the logs of the process.", getDownloadLogsCommand(operationID))
+}
+
+func getDownloadLogsCommand(operationID string) string {
 	downloadProcessLogsCommand := DownloadMtaOperationLogsCommand{}
 	commandBuilder := util.NewCfCommandStringBuilder()
 	commandBuilder.SetName(downloadProcessLogsCommand.GetPluginCommand().Alias)
 	commandBuilder.AddOption(operationIDOpt, operationID)
-	ui.Say("Use \"%s\" to download the logs of the process.", commandBuilder.Build())
+	return commandBuilder.Build()
 }
 
 func (m *ExecutionMonitor) reportAvailableAction(action, operationID string) {
@@ -1,15 +1,26 @@
 package commands_test
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	cf_client_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("ExecutionMonitor", func() {
@@ -127,6 +138,116 @@ var _ = Describe("ExecutionMonitor", func() {
 			})
 		})
 
+		Context("with process task in state finished and a deployment history", func() {
+			var historyHome string
+
+			BeforeEach(func() {
+				historyHome, _ = os.MkdirTemp("", "execution-monitor")
+				os.Setenv("CF_HOME", historyHome)
+			})
+
+			It("should record the operation in the deployment history", func() {
+				client = fakeMtaClientBuilder.
+					GetMtaOperation(processID, "messages", &models.Operation{
+						ProcessType: "DEPLOY",
+						MtaID:       "shop",
+						Namespace:   "dev",
+						State:       "FINISHED",
+						StartedAt:   time.Now().Add(-time.Minute).Format(time.RFC3339Nano),
+						User:        "test-user",
+						Messages:    []*models.Message{},
+					}, nil).Build()
+				monitor = commands.NewExecutionMonitor(commandName, processID, "messages", 0, []*models.Message{}, client)
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return monitor.Monitor().ToInt()
+				})
+				Expect(status).To(Equal(0))
+				records, err := util.NewDeploymentHistoryAt(filepath.Join(historyHome, ".cf", "multiapps-history.jsonl")).Query(util.DeploymentHistoryFilter{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].ProcessID).To(Equal(processID))
+				Expect(records[0].ProcessType).To(Equal("DEPLOY"))
+				Expect(records[0].MtaID).To(Equal("shop"))
+				Expect(records[0].Namespace).To(Equal("dev"))
+				Expect(records[0].User).To(Equal("test-user"))
+				Expect(records[0].State).To(Equal("FINISHED"))
+				Expect(records[0].DurationInSec).To(BeNumerically("~", 60, 5))
+			})
+
+			AfterEach(func() {
+				os.Setenv("CF_HOME", cfHome)
+				os.RemoveAll(historyHome)
+			})
+		})
+
+		Context("with process task in state finished and a timing report", func() {
+			var reportDirectory string
+
+			BeforeEach(func() {
+				reportDirectory, _ = os.MkdirTemp("", "timing-report")
+				os.Setenv("MULTIAPPS_TIMING_REPORT", filepath.Join(reportDirectory, "timing.xml"))
+			})
+
+			It("should write the phases derived from the progress messages to the report", func() {
+				client = fakeMtaClientBuilder.
+					GetMtaOperation(processID, "messages", &models.Operation{
+						MtaID: "shop",
+						State: "FINISHED",
+						Messages: []*models.Message{
+							{ID: 0, Text: "Detected MTA schema version: \"3\""},
+							{ID: 1, Text: "Creating service \"shop-db\" from MTA resource \"shop-db\"..."},
+							{ID: 2, Text: "Uploading application \"shop-web\"..."},
+							{ID: 3, Text: "Staging application \"shop-web\"..."},
+							{ID: 4, Text: "Application \"shop-web\" started and available at \"shop.example.com\""},
+							{ID: 5, Text: "Deleting discontinued route \"old.example.com\"..."},
+							{ID: 6, Text: "Process finished."},
+						},
+					}, nil).Build()
+				monitor = commands.NewExecutionMonitor(commandName, processID, "messages", 0, []*models.Message{}, client)
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return monitor.Monitor().ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(filepath.Join(reportDirectory, "timing.xml")).ToNot(BeAnExistingFile())
+				Expect(commands.FlushTimingReport()).To(Succeed())
+				content, err := os.ReadFile(filepath.Join(reportDirectory, "timing.xml"))
+				Expect(err).ToNot(HaveOccurred())
+				var report util.JUnitTestSuites
+				Expect(xml.Unmarshal(content, &report)).To(Succeed())
+				Expect(report.Suites).To(HaveLen(1))
+				Expect(report.Suites[0].Name).To(Equal("deploy " + processID))
+				var phases []string
+				for _, testCase := range report.Suites[0].Cases {
+					Expect(testCase.ClassName).To(Equal("shop"))
+					phases = append(phases, testCase.Name)
+				}
+				Expect(phases).To(Equal([]string{"other", "service operations", "upload", "staging", "starting", "cleanup"}))
+			})
+
+			It("should write the breakdowns of all monitored operations to the report once", func() {
+				client = fakeMtaClientBuilder.
+					GetMtaOperation(processID, "messages", &models.Operation{MtaID: "shop", State: "FINISHED", Messages: []*models.Message{}}, nil).
+					Build()
+				oc.CaptureOutputAndStatus(func() int {
+					commands.NewExecutionMonitor(commandName, processID, "messages", 0, []*models.Message{}, client).Monitor()
+					return commands.NewExecutionMonitor(commandName, "other-process-id", "messages", 0, []*models.Message{}, client).Monitor().ToInt()
+				})
+				Expect(commands.FlushTimingReport()).To(Succeed())
+				content, err := os.ReadFile(filepath.Join(reportDirectory, "timing.xml"))
+				Expect(err).ToNot(HaveOccurred())
+				var report util.JUnitTestSuites
+				Expect(xml.Unmarshal(content, &report)).To(Succeed())
+				Expect(report.Suites).To(HaveLen(2))
+				Expect(report.Suites[0].Name).To(Equal("deploy " + processID))
+				Expect(report.Suites[1].Name).To(Equal("deploy other-process-id"))
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("MULTIAPPS_TIMING_REPORT")
+				os.RemoveAll(reportDirectory)
+			})
+		})
+
 		Context("with process task in state finished and progress messages with non-repeating ids in the tasklist", func() {
 			It("should print all progress messages and exit with zero status", func() {
 				const processStatus = models.StateFINISHED
@@ -146,6 +267,26 @@ var _ = Describe("ExecutionMonitor", func() {
 				ex.ExpectSuccessWithOutput(status, output, getOutputLines(processStatus, "", []string{"test-message-1", "test-message-2", "test-message-3"}))
 			})
 		})
+		Context("with process task in state finished and progress messages with secrets in the tasklist", func() {
+			It("should print the progress messages with the secrets redacted and exit with zero status", func() {
+				const processStatus = models.StateFINISHED
+				os.Setenv("__MTA___dbUser", "admin-user")
+				defer os.Unsetenv("__MTA___dbUser")
+				client = fakeMtaClientBuilder.
+					GetMtaOperation(processID, "messages", &models.Operation{
+						State: "FINISHED",
+						Messages: []*models.Message{
+							testutil.GetMessage(1, "Creating service db with user admin-user"),
+							testutil.GetMessage(2, "Setting DB_PASSWORD=p4ssw0rd"),
+						},
+					}, nil).Build()
+				monitor = commands.NewExecutionMonitor(commandName, processID, "messages", 0, []*models.Message{}, client)
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return monitor.Monitor().ToInt()
+				})
+				ex.ExpectSuccessWithOutput(status, output, getOutputLines(processStatus, "", []string{"Creating service db with user ********", "Setting DB_PASSWORD=********"}))
+			})
+		})
 		Context("with process task in state finished and progress messages with repeating ids in the tasklist", func() {
 			It("should print progress messages and exit with zero status", func() {
 				const processStatus = models.StateFINISHED
@@ -182,5 +323,153 @@ var _ = Describe("ExecutionMonitor", func() {
 				ex.ExpectSuccessWithOutput(status, output, getOutputLines(processStatus, "", []string{}))
 			})
 		})
+		Context("with process task in state action required and the action prompt enabled", func() {
+			var cfClient = cf_client_fakes.FakeCloudFoundryClient{
+				Apps:      []models.CloudFoundryApplication{{Name: "backend", Guid: "backend-guid"}, {Name: "backend-idle", Guid: "backend-idle-guid"}},
+				AppRoutes: []models.ApplicationRoute{{Url: "backend-idle.example.com"}},
+			}
+			var fakeClient *fakes.FakeMtaClientOperations
+
+			var monitorWithInput = func(input string) ([]string, int) {
+				fakeClient = fakes.NewFakeMtaClientBuilder().
+					GetOperationActions(processID, []string{"resume", "abort"}, nil).Build()
+				fakeClient.GetMtaOperationStub = func(operationID, embed string) (*models.Operation, error) {
+					if fakeClient.ExecuteActionCallCount() > 0 {
+						return &models.Operation{State: "FINISHED", Messages: []*models.Message{}}, nil
+					}
+					return &models.Operation{State: "ACTION_REQUIRED", MtaID: "test", SpaceID: "test-space-guid", Messages: []*models.Message{}}, nil
+				}
+				monitor = commands.NewExecutionMonitor(commandName, processID, "messages", 0, []*models.Message{}, fakeClient).
+					EnableActionPrompt(cfClient, ui.NewPrompt(strings.NewReader(input)))
+				return oc.CaptureOutputAndStatus(func() int {
+					return monitor.Monitor().ToInt()
+				})
+			}
+
+			It("should show the idle app URLs and resume the process when the user chooses to resume", func() {
+				output, status := monitorWithInput("invalid\nresume\n")
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElements("Idle app URLs:", "  https://backend-idle.example.com", "Process finished."))
+				Expect(output).To(ContainElement(ContainSubstring("Invalid choice invalid")))
+				Expect(fakeClient.ExecuteActionCallCount()).To(Equal(1))
+				_, action := fakeClient.ExecuteActionArgsForCall(0)
+				Expect(action).To(Equal("resume"))
+			})
+
+			It("should abort the process and exit with non-zero status when the user chooses to abort", func() {
+				output, status := monitorWithInput("abort\n")
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement("Process was aborted."))
+				_, action := fakeClient.ExecuteActionArgsForCall(0)
+				Expect(action).To(Equal("abort"))
+			})
+
+			It("should report the available actions and exit with zero status when the user detaches", func() {
+				output, status := monitorWithInput("detach\n")
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElement(fmt.Sprintf("Use \"cf %s -i %s -a resume\" to resume the process.", commandName, processID)))
+				Expect(fakeClient.ExecuteActionCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with two operations which are monitored concurrently and tracing", func() {
+			var tracesDirectory string
+
+			type exportedSpan struct {
+				SpanID       string `json:"spanId"`
+				ParentSpanID string `json:"parentSpanId"`
+				Name         string `json:"name"`
+				Attributes   []struct {
+					Key   string                 `json:"key"`
+					Value map[string]interface{} `json:"value"`
+				} `json:"attributes"`
+			}
+			var getOperationID = func(span exportedSpan) interface{} {
+				for _, attribute := range span.Attributes {
+					if attribute.Key == "operation.id" {
+						return attribute.Value["stringValue"]
+					}
+				}
+				return nil
+			}
+
+			BeforeEach(func() {
+				tracesDirectory, _ = os.MkdirTemp("", "execution-monitor-traces")
+				tracing.Configure(tracing.Config{File: filepath.Join(tracesDirectory, "traces.jsonl")})
+			})
+
+			It("should trace the polls of each operation as children of its monitoring", func() {
+				// Both operations are polled only after both monitorings have started
+				var polls sync.WaitGroup
+				polls.Add(2)
+				newClient := func() *fakes.FakeMtaClientOperations {
+					fakeClient := fakes.NewFakeMtaClientBuilder().Build()
+					fakeClient.GetMtaOperationStub = func(operationID, embed string) (*models.Operation, error) {
+						polls.Done()
+						polls.Wait()
+						return &models.Operation{ProcessID: operationID, State: models.StateFINISHED}, nil
+					}
+					return fakeClient
+				}
+				root := tracing.StartSpan("cf mta-release")
+				var monitors sync.WaitGroup
+				oc.CaptureOutputAndStatus(func() int {
+					for _, operationID := range []string{"1000", "2000"} {
+						monitors.Add(1)
+						go func(operationID string) {
+							defer monitors.Done()
+							commands.NewExecutionMonitor(commandName, operationID, "messages", 0, []*models.Message{}, newClient()).
+								WithParentSpan(root).
+								Monitor()
+						}(operationID)
+					}
+					monitors.Wait()
+					return 0
+				})
+				root.End()
+				Expect(tracing.Flush()).To(Succeed())
+
+				content, err := os.ReadFile(filepath.Join(tracesDirectory, "traces.jsonl"))
+				Expect(err).ToNot(HaveOccurred())
+				var traces struct {
+					ResourceSpans []struct {
+						ScopeSpans []struct {
+							Spans []exportedSpan `json:"spans"`
+						} `json:"scopeSpans"`
+					} `json:"resourceSpans"`
+				}
+				Expect(json.Unmarshal(content, &traces)).To(Succeed())
+				spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+				monitorSpans := make(map[string]exportedSpan)
+				var rootSpanID string
+				for _, span := range spans {
+					switch span.Name {
+					case "cf mta-release":
+						rootSpanID = span.SpanID
+					case "monitor operation":
+						monitorSpans[span.SpanID] = span
+					}
+				}
+				Expect(monitorSpans).To(HaveLen(2))
+				for _, span := range monitorSpans {
+					Expect(span.ParentSpanID).To(Equal(rootSpanID))
+				}
+				pollCount := 0
+				for _, span := range spans {
+					if span.Name != "poll operation" {
+						continue
+					}
+					pollCount++
+					Expect(monitorSpans).To(HaveKey(span.ParentSpanID))
+					Expect(getOperationID(span)).To(Equal(getOperationID(monitorSpans[span.ParentSpanID])))
+				}
+				Expect(pollCount).To(Equal(2))
+			})
+
+			AfterEach(func() {
+				tracing.Configure(tracing.Config{})
+				os.RemoveAll(tracesDirectory)
+			})
+		})
 	})
 })
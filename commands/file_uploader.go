@@ -17,6 +17,7 @@ import (
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/log"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 )
@@ -155,8 +156,17 @@ func (f *FileUploader) UploadFiles(files []string) ([]*models.FileMetadata, Exec
 	return uploadedFiles, Success
 }
 
-func (f *FileUploader) uploadInChunks(fileToUpload *os.File) ([]*models.FileMetadata, error) {
-	err := util.ValidateChunkSize(fileToUpload.Name(), f.uploadChunkSizeInMB)
+func (f *FileUploader) uploadInChunks(fileToUpload *os.File) (uploadedFileParts []*models.FileMetadata, err error) {
+	span := tracing.StartSpan("upload file")
+	span.SetAttribute("file.name", filepath.Base(fileToUpload.Name()))
+	defer func() {
+		if err != nil {
+			span.Fail(err.Error())
+		}
+		span.End()
+	}()
+
+	err = util.ValidateChunkSize(fileToUpload.Name(), f.uploadChunkSizeInMB)
 	if err != nil {
 		return nil, fmt.Errorf("Could not valide file %q: %v", fileToUpload.Name(), err)
 	}
@@ -166,7 +176,6 @@ func (f *FileUploader) uploadInChunks(fileToUpload *os.File) ([]*models.FileMeta
 	}
 	defer attemptToRemoveFileParts(fileToUploadParts)
 
-	var uploadedFileParts []*models.FileMetadata
 	uploadedFilesChannel := make(chan *models.FileMetadata)
 	errorChannel := make(chan error)
 
@@ -182,11 +191,17 @@ func (f *FileUploader) uploadInChunks(fileToUpload *os.File) ([]*models.FileMeta
 	progressBar.Start()
 	defer progressBar.Finish()
 
-	for _, fileToUploadPart := range fileToUploadParts {
+	span.SetAttribute("file.size", fileInfo.Size())
+	span.SetAttribute("file.chunks", len(fileToUploadParts))
+	for i, fileToUploadPart := range fileToUploadParts {
 		filePartCopy := fileToUploadPart
+		chunkSpan := span.StartChild("upload chunk")
+		chunkSpan.SetAttribute("chunk.index", i)
 		go func() {
+			defer chunkSpan.End()
 			file, err := f.uploadFilePart(filePartCopy, fileToUpload.Name(), progressBar)
 			if err != nil {
+				chunkSpan.Fail(err.Error())
 				errorChannel <- err
 				return
 			}
@@ -3,6 +3,7 @@ package commands
 import (
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 )
 
@@ -10,6 +11,9 @@ import (
 type MonitorAction struct {
 	commandName       string
 	monitoringRetries uint
+	historyContext    deploymentHistoryContext
+	observers         operationObservers
+	parentSpan        *tracing.Span
 }
 
 // Execute executes monitor action on process with the specified id
@@ -20,5 +24,9 @@ func (a *MonitorAction) Execute(operationID string, mtaClient mtaclient.MtaClien
 		return Failure
 	}
 
-	return NewExecutionMonitor(a.commandName, operationID, "messages", a.monitoringRetries, operation.Messages, mtaClient).Monitor()
+	return NewExecutionMonitor(a.commandName, operationID, "messages", a.monitoringRetries, operation.Messages, mtaClient).
+		withHistoryContext(a.historyContext).
+		withObservers(a.observers).
+		WithParentSpan(a.parentSpan).
+		Monitor()
 }
@@ -0,0 +1,336 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"code.cloudfoundry.org/cli/v8/plugin"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/resilient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	namespacePatternOpt      = "namespace-pattern"
+	olderThanOpt             = "older-than"
+	maxParallelUndeploysOpt  = "max-parallel-undeploys"
+	undeploymentSucceeded    = "undeployed"
+	undeploymentFailed       = "failed"
+	unknownDeploymentTimeTag = "unknown"
+)
+
+// cleanupCandidate is an MTA which matches the namespace pattern. It is undeployed unless a reason to skip it is set.
+type cleanupCandidate struct {
+	mta        *models.Mta
+	deployedAt time.Time
+	skipReason string
+}
+
+// cleanupResult holds the outcome of the undeployment of a single MTA
+type cleanupResult struct {
+	candidate   cleanupCandidate
+	operationID string
+	status      string
+	duration    time.Duration
+}
+
+// MtaCleanupCommand is a command for undeploying all MTAs whose namespace matches a pattern
+type MtaCleanupCommand struct {
+	*BaseCommand
+
+	CfClient cfrestclient.CloudFoundryOperationsExtended
+}
+
+func NewMtaCleanupCommand() *MtaCleanupCommand {
+	baseCmd := &BaseCommand{flagsParser: NewDefaultCommandFlagsParser(nil), flagsValidator: NewDefaultCommandFlagsValidator(map[string]bool{namespacePatternOpt: true})}
+	mtaCleanupCmd := &MtaCleanupCommand{baseCmd, nil}
+	baseCmd.Command = mtaCleanupCmd
+	return mtaCleanupCmd
+}
+
+func (c *MtaCleanupCommand) Initialize(name string, cliConnection plugin.CliConnection) {
+	c.BaseCommand.Initialize(name, cliConnection)
+	delegate := cfrestclient.NewCloudFoundryRestClient(cliConnection)
+	c.CfClient = resilient.NewResilientCloudFoundryClient(delegate, maxRetriesCount, retryIntervalInSeconds)
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *MtaCleanupCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "mta-cleanup",
+		HelpText: "Undeploy the multi-target apps whose namespace matches a pattern",
+		UsageDetails: plugin.Usage{
+			Usage: `Undeploy the multi-target apps whose namespace matches a pattern
+   cf mta-cleanup --namespace-pattern PATTERN [--older-than AGE] [--max-parallel-undeploys COUNT] [-u URL] [-f] [--dry-run] [--retries RETRIES] [--delete-services] [--delete-service-keys] [--delete-service-brokers] [--do-not-fail-on-missing-permissions]` + util.BaseEnvHelpText,
+			Options: map[string]string{
+				deployServiceURLOpt:                                "Deploy service URL, by default 'deploy-service.<system-domain>'",
+				forceOpt:                                           "Undeploy without confirmation",
+				util.GetShortOption(namespacePatternOpt):           "Undeploy the multi-target apps whose namespace matches the pattern, e.g. 'pr-*'",
+				util.GetShortOption(olderThanOpt):                  "Undeploy only the multi-target apps last deployed before this duration, e.g. 7d, 12h",
+				util.GetShortOption(maxParallelUndeploysOpt):       "Maximum number of multi-target apps undeployed in parallel (default 3)",
+				util.GetShortOption(dryRunOpt):                     "List the multi-target apps which would be undeployed, without undeploying them",
+				util.GetShortOption(deleteServicesOpt):             "Delete services",
+				util.GetShortOption(deleteServiceKeysOpt):          "Delete existing service keys",
+				util.GetShortOption(deleteServiceBrokersOpt):       "Delete service brokers",
+				util.GetShortOption(noFailOnMissingPermissionsOpt): "Do not fail on missing permissions for admin operations",
+				util.GetShortOption(retriesOpt):                    "Retry the operation N times in case a non-content error occurs (default 3)",
+			},
+		},
+	}
+}
+
+func (c *MtaCleanupCommand) defineCommandOptions(flags *flag.FlagSet) {
+	flags.String(namespacePatternOpt, "", "")
+	flags.String(olderThanOpt, "", "")
+	flags.Uint(maxParallelUndeploysOpt, 3, "")
+	flags.Bool(forceOpt, false, "")
+	flags.Bool(dryRunOpt, false, "")
+	flags.Bool(deleteServicesOpt, false, "")
+	flags.Bool(deleteServiceKeysOpt, false, "")
+	flags.Bool(deleteServiceBrokersOpt, false, "")
+	flags.Bool(noFailOnMissingPermissionsOpt, false, "")
+	flags.Uint(retriesOpt, 3, "")
+}
+
+func (c *MtaCleanupCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	namespacePattern := GetStringOpt(namespacePatternOpt, flags)
+	if _, err := path.Match(namespacePattern, ""); err != nil {
+		ui.Failed("Invalid namespace pattern %q: %s", namespacePattern, err)
+		return Failure
+	}
+	var olderThan time.Duration
+	if value := GetStringOpt(olderThanOpt, flags); value != "" {
+		var err error
+		olderThan, err = util.ParseDuration(value)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+	}
+	if GetUintOpt(maxParallelUndeploysOpt, flags) == 0 {
+		ui.Failed("The %q option must be a positive number", "--"+maxParallelUndeploysOpt)
+		return Failure
+	}
+
+	ui.Say("Getting multi-target apps with namespace matching %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(namespacePattern), terminal.EntityNameColor(cfTarget.Org.Name),
+		terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+	mtaClient := c.NewMtaClient(dsHost, cfTarget)
+	candidates, err := c.getCleanupCandidates(namespacePattern, olderThan, mtaClient, dsHost, cfTarget)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	ui.Ok()
+
+	toUndeploy := reportCleanupCandidates(candidates)
+	if len(toUndeploy) == 0 {
+		ui.Say("No multi-target apps to undeploy")
+		return Success
+	}
+	if GetBoolOpt(dryRunOpt, flags) {
+		ui.Say("\nThis is a dry run, no multi-target app was undeployed.")
+		return Success
+	}
+	if !GetBoolOpt(forceOpt, flags) && !c.getPrompt().Confirm("Really undeploy %d multi-target apps in org %s / space %s? (y/n)", len(toUndeploy),
+		terminal.EntityNameColor(cfTarget.Org.Name), terminal.EntityNameColor(cfTarget.Space.Name)) {
+		ui.Warn("Cleanup cancelled")
+		return Failure
+	}
+
+	results := make([]*cleanupResult, len(toUndeploy))
+	semaphore := make(chan struct{}, GetUintOpt(maxParallelUndeploysOpt, flags))
+	var waitGroup sync.WaitGroup
+	for i := range toUndeploy {
+		semaphore <- struct{}{}
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			results[i] = c.undeploy(toUndeploy[i], mtaClient, flags, cfTarget)
+		}(i)
+	}
+	waitGroup.Wait()
+
+	return reportCleanupResults(results)
+}
+
+// getCleanupCandidates finds the MTAs whose namespace matches the pattern. The MTAs which were deployed recently, whose
+// deployment time is unknown, which have an ongoing operation or which are protected are skipped.
+func (c *MtaCleanupCommand) getCleanupCandidates(namespacePattern string, olderThan time.Duration, mtaClient mtaclient.MtaClientOperations,
+	dsHost string, cfTarget util.CloudFoundryTarget) ([]cleanupCandidate, error) {
+	mtas, err := c.NewMtaV2Client(dsHost, cfTarget).GetMtasForThisSpace(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get multi-target apps: %s", baseclient.NewClientError(err))
+	}
+
+	var candidates []cleanupCandidate
+	for _, mta := range mtas {
+		mtaID, namespace := mta.Metadata.ID, mta.Metadata.Namespace
+		if namespace == "" {
+			continue
+		}
+		if matches, _ := path.Match(namespacePattern, namespace); !matches {
+			continue
+		}
+		apps, err := c.CfClient.GetApplications(mtaID, namespace, cfTarget.Space.Guid)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get apps of multi-target app %s: %s", terminal.EntityNameColor(mtaID), err)
+		}
+		deployOperation, err := findLatestDeployOperation(mtaID, namespace, mtaClient)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get operations of multi-target app %s: %s", terminal.EntityNameColor(mtaID), baseclient.NewClientError(err))
+		}
+		candidate := cleanupCandidate{mta: mta, deployedAt: getLastDeploymentTime(apps, deployOperation)}
+		candidate.skipReason, err = c.getSkipReason(candidate, olderThan, mtaClient, cfTarget)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// getLastDeploymentTime returns the latest time at which an app of the MTA was created or updated. The start of the
+// latest deploy operation is used if it is later, e.g. because the operations of older deployments have been deleted
+// already. The zero time is returned if the deployment time is unknown.
+func getLastDeploymentTime(apps []models.CloudFoundryApplication, deployOperation *models.Operation) time.Time {
+	var deployedAt time.Time
+	for _, app := range apps {
+		for _, timestamp := range []string{app.CreatedAt, app.UpdatedAt} {
+			if appTime, err := time.Parse(time.RFC3339Nano, timestamp); err == nil && appTime.After(deployedAt) {
+				deployedAt = appTime
+			}
+		}
+	}
+	if deployOperation != nil {
+		if startTime, err := parseOperationStartTime(deployOperation.StartedAt); err == nil && startTime.After(deployedAt) {
+			deployedAt = startTime
+		}
+	}
+	return deployedAt
+}
+
+func (c *MtaCleanupCommand) getSkipReason(candidate cleanupCandidate, olderThan time.Duration, mtaClient mtaclient.MtaClientOperations,
+	cfTarget util.CloudFoundryTarget) (string, error) {
+	mtaID, namespace := candidate.mta.Metadata.ID, candidate.mta.Metadata.Namespace
+	if olderThan > 0 {
+		if candidate.deployedAt.IsZero() {
+			return "deployment time unknown", nil
+		}
+		if time.Since(candidate.deployedAt) < olderThan {
+			return "deployed recently", nil
+		}
+	}
+	ongoingOperation, err := c.findOngoingOperation(mtaID, namespace, mtaClient, cfTarget)
+	if err != nil {
+		return "", err
+	}
+	if ongoingOperation != nil {
+		return "ongoing operation " + ongoingOperation.ProcessID, nil
+	}
+	protected, err := isMtaProtected(mtaID, namespace, c.CfClient, cfTarget)
+	if err != nil {
+		return "", err
+	}
+	if protected {
+		return "protected", nil
+	}
+	return "", nil
+}
+
+// parseOperationStartTime parses the start time of an operation, which may be followed by the zone ID in brackets
+func parseOperationStartTime(startedAt string) (time.Time, error) {
+	if index := strings.Index(startedAt, "["); index != -1 {
+		startedAt = startedAt[:index]
+	}
+	return time.Parse(time.RFC3339Nano, startedAt)
+}
+
+// reportCleanupCandidates prints the matching MTAs and returns the ones which would be undeployed
+func reportCleanupCandidates(candidates []cleanupCandidate) []cleanupCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+	var toUndeploy []cleanupCandidate
+	table := ui.Table([]string{"mta id", "namespace", "version", "last deployed", "action"})
+	for _, candidate := range candidates {
+		action := "undeploy"
+		if candidate.skipReason != "" {
+			action = "skip (" + candidate.skipReason + ")"
+		} else {
+			toUndeploy = append(toUndeploy, candidate)
+		}
+		deployedAt := unknownDeploymentTimeTag
+		if !candidate.deployedAt.IsZero() {
+			deployedAt = candidate.deployedAt.UTC().Format(time.RFC3339)
+		}
+		table.Add(candidate.mta.Metadata.ID, candidate.mta.Metadata.Namespace, util.GetMtaVersionAsString(candidate.mta), deployedAt, action)
+	}
+	table.Print()
+	return toUndeploy
+}
+
+// undeploy starts an undeploy process for the MTA and waits for it to complete
+func (c *MtaCleanupCommand) undeploy(candidate cleanupCandidate, mtaClient mtaclient.MtaClientOperations, flags *flag.FlagSet,
+	cfTarget util.CloudFoundryTarget) *cleanupResult {
+	result := &cleanupResult{candidate: candidate, status: undeploymentFailed}
+	startTime := time.Now()
+	defer func() {
+		result.duration = time.Since(startTime)
+	}()
+
+	mtaID, namespace := candidate.mta.Metadata.ID, candidate.mta.Metadata.Namespace
+	processBuilder := util.NewProcessBuilder()
+	processBuilder.ProcessType(undeployCommandProcessTypeProvider{}.GetProcessType())
+	processBuilder.Parameter("mtaId", mtaID)
+	processBuilder.Parameter("deleteServices", strconv.FormatBool(GetBoolOpt(deleteServicesOpt, flags)))
+	processBuilder.Parameter("deleteServiceKeys", strconv.FormatBool(GetBoolOpt(deleteServiceKeysOpt, flags)))
+	processBuilder.Parameter("deleteServiceBrokers", strconv.FormatBool(GetBoolOpt(deleteServiceBrokersOpt, flags)))
+	processBuilder.Parameter("noFailOnMissingPermissions", strconv.FormatBool(GetBoolOpt(noFailOnMissingPermissionsOpt, flags)))
+	processBuilder.Parameter("namespace", namespace)
+	operation := processBuilder.Build()
+
+	responseHeader, err := mtaClient.StartMtaOperation(*operation)
+	if err != nil {
+		ui.Warn("Could not create undeploy process for multi-target app %s with namespace %s: %s", mtaID, namespace, err)
+		return result
+	}
+	result.operationID, _ = getMonitoringInformation(responseHeader.Location.String())
+	undeployCommandName := NewUndeployCommand().GetPluginCommand().Name
+	executionMonitor := NewExecutionMonitorFromLocationHeader(undeployCommandName, responseHeader.Location.String(), GetUintOpt(retriesOpt, flags), []*models.Message{}, mtaClient).
+		withHistoryContext(newDeploymentHistoryContext(flags, cfTarget)).
+		WithParentSpan(c.span)
+	if executionMonitor.Monitor() == Success {
+		result.status = undeploymentSucceeded
+	}
+	return result
+}
+
+func reportCleanupResults(results []*cleanupResult) ExecutionStatus {
+	status := Success
+	ui.Say("\nCleanup summary:")
+	table := ui.Table([]string{"mta id", "namespace", "status", "operation id", "duration"})
+	for _, result := range results {
+		if result.status != undeploymentSucceeded {
+			status = Failure
+		}
+		operationID := "-"
+		if result.operationID != "" {
+			operationID = result.operationID
+		}
+		table.Add(result.candidate.mta.Metadata.ID, result.candidate.mta.Metadata.Namespace, result.status, operationID, result.duration.Round(time.Second).String())
+	}
+	table.Print()
+	return status
+}
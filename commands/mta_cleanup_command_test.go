@@ -0,0 +1,177 @@
+package commands_test
+
+import (
+	"strings"
+	"time"
+
+	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
+	cli_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
+	cf_client_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	mtafake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
+	mtav2fake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient_v2/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	util_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/util/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MtaCleanupCommand", func() {
+	Describe("Execute", func() {
+		var name string
+		var cliConnection *plugin_fakes.FakeCliConnection
+		var mtaClient *mtafake.FakeMtaClientOperations
+		var testClientFactory *commands.TestClientFactory
+		var command *commands.MtaCleanupCommand
+		var deployOperations []*models.Operation
+		var oc = testutil.NewUIOutputCapturer()
+		var ex = testutil.NewUIExpector()
+
+		var newMta = func(id, namespace string) *models.Mta {
+			return &models.Mta{Metadata: &models.Metadata{ID: id, Namespace: namespace, Version: "1.0.0"}}
+		}
+
+		var newDeployOperation = func(id, namespace string, startedAt time.Time) *models.Operation {
+			return &models.Operation{ProcessID: id + "-deploy", ProcessType: "DEPLOY", MtaID: id, Namespace: namespace,
+				State: models.StateFINISHED, StartedAt: startedAt.UTC().Format(time.RFC3339) + "[Etc/UTC]"}
+		}
+
+		BeforeEach(func() {
+			ui.DisableTerminalOutput(true)
+			command = commands.NewMtaCleanupCommand()
+			name = command.GetPluginCommand().Name
+			cliConnection = cli_fakes.NewFakeCliConnectionBuilder().
+				CurrentOrg("test-org-guid", "test-org", nil).
+				CurrentSpace("test-space-guid", "test-space", nil).
+				Username("test-user", nil).
+				AccessToken("bearer test-token", nil).Build()
+			mtaClient = mtafake.NewFakeMtaClientBuilder().
+				StartMtaOperation(testutil.OperationResult, mtaclient.ResponseHeader{Location: "operations/1000?embed=messages"}, nil).
+				GetMtaOperation("1000", "messages", &testutil.OperationResult, nil).Build()
+			deployedLongAgo := time.Now().Add(-30 * 24 * time.Hour)
+			deployOperations = []*models.Operation{
+				newDeployOperation("shop", "pr-1", deployedLongAgo),
+				newDeployOperation("shop", "pr-2", time.Now().Add(-time.Hour)),
+				newDeployOperation("billing", "pr-1", deployedLongAgo),
+				newDeployOperation("shop", "main", deployedLongAgo),
+			}
+			mtaClient.GetMtaOperationsStub = func(mtaId *string, last *int64, status []string) ([]*models.Operation, error) {
+				if len(status) == 1 && status[0] == string(models.StateFINISHED) {
+					return deployOperations, nil
+				}
+				return nil, nil
+			}
+			mtaV2Client := mtav2fake.NewFakeMtaV2ClientBuilder().
+				GetMtasForThisSpace("", nil, []*models.Mta{
+					newMta("shop", "pr-1"), newMta("shop", "pr-2"), newMta("billing", "pr-1"), newMta("shop", "main"), newMta("shop", ""),
+				}, nil).Build()
+			testClientFactory = commands.NewTestClientFactory(mtaClient, mtaV2Client, nil)
+			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
+			deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
+			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+			command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{}
+		})
+
+		Context("without a namespace pattern", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"-f"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Missing required options '[namespace-pattern]'")
+			})
+		})
+
+		Context("with an invalid age", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--namespace-pattern", "pr-*", "--older-than", "a week"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Invalid duration \"a week\", expected a value like 7d, 12h or 30m")
+			})
+		})
+
+		Context("with the dry-run option", func() {
+			It("should list the matching multi-target apps without undeploying them", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--namespace-pattern", "pr-*", "--older-than", "7d", "--dry-run"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				joinedOutput := strings.Join(output, "\n")
+				Expect(joinedOutput).To(MatchRegexp(`shop\s+pr-1\s+1\.0\.0\s+\S+\s+undeploy`))
+				Expect(joinedOutput).To(MatchRegexp(`shop\s+pr-2\s+1\.0\.0\s+\S+\s+skip \(deployed recently\)`))
+				Expect(joinedOutput).To(MatchRegexp(`billing\s+pr-1\s+1\.0\.0\s+\S+\s+undeploy`))
+				Expect(joinedOutput).ToNot(ContainSubstring("main"))
+				Expect(output).To(ContainElement("This is a dry run, no multi-target app was undeployed."))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with matching multi-target apps", func() {
+			It("should undeploy them and print a summary", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--namespace-pattern", "pr-*", "--older-than", "7d", "-f", "--max-parallel-undeploys", "2"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+				undeployedNamespaces := []string{}
+				for i := 0; i < mtaClient.StartMtaOperationCallCount(); i++ {
+					operation := mtaClient.StartMtaOperationArgsForCall(i)
+					Expect(operation.ProcessType).To(Equal("UNDEPLOY"))
+					undeployedNamespaces = append(undeployedNamespaces, operation.Parameters["mtaId"].(string)+"/"+operation.Parameters["namespace"].(string))
+				}
+				Expect(undeployedNamespaces).To(ConsistOf("shop/pr-1", "billing/pr-1"))
+				Expect(output).To(ContainElement("Cleanup summary:"))
+				Expect(strings.Join(output, "\n")).To(MatchRegexp(`billing\s+pr-1\s+undeployed\s+1000`))
+			})
+		})
+
+		Context("with apps updated recently by a deployment whose operation is older", func() {
+			It("should skip the multi-target apps as deployed recently", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", CreatedAt: "2020-01-01T00:00:00Z", UpdatedAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)}},
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--namespace-pattern", "pr-1", "--older-than", "7d", "-f"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				joinedOutput := strings.Join(output, "\n")
+				Expect(joinedOutput).To(MatchRegexp(`shop\s+pr-1\s+1\.0\.0\s+\S+\s+skip \(deployed recently\)`))
+				Expect(joinedOutput).To(MatchRegexp(`billing\s+pr-1\s+1\.0\.0\s+\S+\s+skip \(deployed recently\)`))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with apps deployed long ago by operations which no longer exist", func() {
+			It("should undeploy the multi-target apps", func() {
+				deployOperations = nil
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", CreatedAt: "2020-01-01T00:00:00Z", UpdatedAt: "2020-01-02T00:00:00Z"}},
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--namespace-pattern", "pr-1", "--older-than", "7d", "-f"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(strings.Join(output, "\n")).To(MatchRegexp(`shop\s+pr-1\s+1\.0\.0\s+2020-01-02T00:00:00Z\s+undeploy`))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("with a protected multi-target app", func() {
+			It("should skip it", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", Annotations: map[string]string{"mta_protected": "true"}}},
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--namespace-pattern", "pr-1", "-f"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(strings.Join(output, "\n")).To(MatchRegexp(`shop\s+pr-1\s+1\.0\.0\s+\S+\s+skip \(protected\)`))
+				Expect(output).To(ContainElement("No multi-target apps to undeploy"))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
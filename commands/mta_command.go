@@ -136,7 +136,7 @@ func (c *MtaCommand) executeInternal(positionalArgs []string, dsHost string, fla
 	table = ui.Table([]string{"name", "service", "plan", "bound apps", "last operation"})
 
 	for _, service := range services {
-		serviceBindings, err := c.CfClient.GetServiceBindings(service.Name)
+		serviceBindings, err := c.CfClient.GetServiceBindings(service.Guid)
 		if err != nil {
 			ui.Failed("Could not get service bindings: %s", err)
 			return Failure
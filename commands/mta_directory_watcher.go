@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/log"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	"github.com/fsnotify/fsnotify"
+)
+
+// mtaDirectoryWatcher watches the deployment descriptor and the module paths of an MTA directory and reports the
+// changed paths, relative to the MTA directory, once no further changes have occurred for the debounce interval
+type mtaDirectoryWatcher struct {
+	watcher          *fsnotify.Watcher
+	mtaDirectory     string
+	debounceInterval time.Duration
+	changes          chan []string
+}
+
+func newMtaDirectoryWatcher(mtaDirectory string, descriptor util.MtaDeploymentDescriptor, debounceInterval time.Duration) (*mtaDirectoryWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	directoryWatcher := &mtaDirectoryWatcher{
+		watcher:          watcher,
+		mtaDirectory:     mtaDirectory,
+		debounceInterval: debounceInterval,
+		changes:          make(chan []string),
+	}
+	err = directoryWatcher.watchDescriptorContent(descriptor)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go directoryWatcher.run()
+	return directoryWatcher, nil
+}
+
+// watchDescriptorContent starts watching the MTA directory itself and the content paths of all modules of the descriptor
+func (w *mtaDirectoryWatcher) watchDescriptorContent(descriptor util.MtaDeploymentDescriptor) error {
+	err := w.watcher.Add(w.mtaDirectory)
+	if err != nil {
+		return err
+	}
+	for _, module := range descriptor.Modules {
+		for _, contentPath := range module.ContentPaths() {
+			contentLocation := filepath.Join(w.mtaDirectory, contentPath)
+			matches, err := filepath.Glob(contentLocation)
+			if err != nil {
+				return err
+			}
+			// watch the parent directory as well, so that files which are replaced or created later on are noticed
+			w.watchDirectory(filepath.Dir(contentLocation))
+			for _, match := range matches {
+				w.watchRecursively(match)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *mtaDirectoryWatcher) watchRecursively(location string) {
+	filepath.WalkDir(location, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			w.watchDirectory(path)
+		}
+		return nil
+	})
+}
+
+func (w *mtaDirectoryWatcher) watchDirectory(location string) {
+	info, err := os.Stat(location)
+	if err != nil || !info.IsDir() {
+		return
+	}
+	if err := w.watcher.Add(location); err != nil {
+		log.Tracef("Could not watch directory %s: %v\n", location, err)
+	}
+}
+
+func (w *mtaDirectoryWatcher) run() {
+	defer close(w.changes)
+	changedPaths := make(map[string]bool)
+	debounceTimer := time.NewTimer(w.debounceInterval)
+	debounceTimer.Stop()
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Op.Has(fsnotify.Create) {
+				w.watchRecursively(event.Name)
+			}
+			changedPath, err := filepath.Rel(w.mtaDirectory, event.Name)
+			if err != nil {
+				continue
+			}
+			changedPaths[changedPath] = true
+			debounceTimer.Reset(w.debounceInterval)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Tracef("Error while watching %s: %v\n", w.mtaDirectory, err)
+		case <-debounceTimer.C:
+			w.changes <- getSortedPaths(changedPaths)
+			changedPaths = make(map[string]bool)
+		}
+	}
+}
+
+// Changes returns the channel on which the debounced changed paths are reported
+func (w *mtaDirectoryWatcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Close stops watching the MTA directory
+func (w *mtaDirectoryWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+func getSortedPaths(paths map[string]bool) []string {
+	result := make([]string, 0, len(paths))
+	for path := range paths {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
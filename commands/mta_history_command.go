@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/plugin"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	spaceOpt       = "space"
+	stateOpt       = "state"
+	processTypeOpt = "type"
+	sinceOpt       = "since"
+)
+
+// MtaHistoryCommand is a command for listing the operations recorded in the local deployment history
+type MtaHistoryCommand struct {
+	*BaseCommand
+}
+
+func NewMtaHistoryCommand() *MtaHistoryCommand {
+	baseCmd := &BaseCommand{flagsParser: NewDefaultCommandFlagsParser(nil), flagsValidator: NewDefaultCommandFlagsValidator(nil)}
+	mtaHistoryCmd := &MtaHistoryCommand{baseCmd}
+	baseCmd.Command = mtaHistoryCmd
+	return mtaHistoryCmd
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *MtaHistoryCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "mta-history",
+		HelpText: "List the multi-target app operations started or monitored from this machine",
+		UsageDetails: plugin.Usage{
+			Usage: `cf mta-history [--mta MTA] [--namespace NAMESPACE] [--space SPACE] [--state STATE] [--type TYPE] [--since DURATION] [--last NUM]
+
+   The operations are recorded in multiapps-history.jsonl in the CF home directory.` + util.BaseEnvHelpText,
+			Options: map[string]string{
+				util.GetShortOption(mtaOpt):         "ID of the deployed package",
+				util.GetShortOption(namespaceOpt):   "Namespace of the deployed package",
+				util.GetShortOption(spaceOpt):       "Name of the space in which the operations were started",
+				util.GetShortOption(stateOpt):       "List only the operations with this final state, e.g. FINISHED, ERROR, ABORTED",
+				util.GetShortOption(processTypeOpt): "List only the operations of this type, e.g. DEPLOY, BLUE_GREEN_DEPLOY, UNDEPLOY",
+				util.GetShortOption(sinceOpt):       "List only the operations recorded within this duration, e.g. 7d, 12h",
+				util.GetShortOption(lastOpt):        "List last NUM operations",
+			},
+		},
+	}
+}
+
+func (c *MtaHistoryCommand) defineCommandOptions(flags *flag.FlagSet) {
+	flags.String(mtaOpt, "", "")
+	flags.String(namespaceOpt, "", "")
+	flags.String(spaceOpt, "", "")
+	flags.String(stateOpt, "", "")
+	flags.String(processTypeOpt, "", "")
+	flags.String(sinceOpt, "", "")
+	flags.Uint(lastOpt, 0, "")
+}
+
+func (c *MtaHistoryCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	filter := util.DeploymentHistoryFilter{
+		MtaID:       GetStringOpt(mtaOpt, flags),
+		Namespace:   GetStringOpt(namespaceOpt, flags),
+		Space:       GetStringOpt(spaceOpt, flags),
+		State:       strings.ToUpper(GetStringOpt(stateOpt, flags)),
+		ProcessType: strings.ToUpper(GetStringOpt(processTypeOpt, flags)),
+	}
+	if since := GetStringOpt(sinceOpt, flags); since != "" {
+		duration, err := util.ParseDuration(since)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		filter.Since = time.Now().Add(-duration)
+	}
+
+	ui.Say("Getting recorded multi-target app operations...")
+	history, err := util.NewDeploymentHistory()
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	records, err := history.Query(filter)
+	if err != nil {
+		ui.Failed("Could not read the deployment history: %s", err)
+		return Failure
+	}
+	if last := int(GetUintOpt(lastOpt, flags)); last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+	ui.Ok()
+
+	if len(records) == 0 {
+		ui.Say("No multi-target app operations recorded")
+		return Success
+	}
+	table := ui.Table([]string{"recorded at", "id", "type", "mta id", "version", "namespace", "org / space", "strategy", "state", "duration"})
+	for _, record := range records {
+		table.Add(record.RecordedAt.Local().Format(time.DateTime), record.ProcessID, record.ProcessType, record.MtaID,
+			record.MtaVersion, record.Namespace, getRecordTarget(record), record.Strategy, record.State,
+			(time.Duration(record.DurationInSec) * time.Second).String())
+	}
+	table.Print()
+	return Success
+}
+
+func getRecordTarget(record util.DeploymentRecord) string {
+	if record.Org == "" && record.Space == "" {
+		return record.SpaceID
+	}
+	return record.Org + " / " + record.Space
+}
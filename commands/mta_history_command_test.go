@@ -0,0 +1,134 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
+	cli_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
+	mtafake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	util_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/util/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MtaHistoryCommand", func() {
+	Describe("Execute", func() {
+		var name string
+		var cliConnection *plugin_fakes.FakeCliConnection
+		var command *commands.MtaHistoryCommand
+		var historyHome string
+		var oc = testutil.NewUIOutputCapturer()
+		var ex = testutil.NewUIExpector()
+		var recordedAt = time.Now().Add(-48 * time.Hour)
+		var latestRecordedAt = time.Now()
+
+		var addRecord = func(record util.DeploymentRecord) {
+			history := util.NewDeploymentHistoryAt(filepath.Join(historyHome, ".cf", "multiapps-history.jsonl"))
+			Expect(history.Add(record)).To(Succeed())
+		}
+
+		var getRecordLine = func(recordedAt time.Time, details ...string) []string {
+			return append([]string{recordedAt.Local().Format(time.DateTime)}, details...)
+		}
+
+		var getOutputLines = func(records ...[]string) []string {
+			lines := []string{"Getting recorded multi-target app operations...", "OK"}
+			return append(lines, testutil.GetTableOutputLines([]string{"recorded at", "id", "type", "mta id", "version", "namespace", "org / space", "strategy", "state", "duration"}, records)...)
+		}
+
+		BeforeEach(func() {
+			ui.DisableTerminalOutput(true)
+			historyHome, _ = os.MkdirTemp("", "mta-history")
+			os.Setenv("CF_HOME", historyHome)
+			name = command.GetPluginCommand().Name
+			cliConnection = cli_fakes.NewFakeCliConnectionBuilder().
+				CurrentOrg("test-org-guid", "test-org", nil).
+				CurrentSpace("test-space-guid", "test-space", nil).
+				Username("test-user", nil).
+				AccessToken("bearer test-token", nil).Build()
+			clientFactory := commands.NewTestClientFactory(mtafake.NewFakeMtaClientBuilder().Build(), nil, nil)
+			command = commands.NewMtaHistoryCommand()
+			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
+			deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
+			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), clientFactory, testTokenFactory, deployServiceURLCalculator)
+		})
+
+		Context("without recorded operations", func() {
+			It("should report that no operations are recorded", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{}).ToInt()
+				})
+				ex.ExpectSuccessWithOutput(status, output, []string{"Getting recorded multi-target app operations...", "OK", "No multi-target app operations recorded"})
+			})
+		})
+
+		Context("with recorded operations", func() {
+			BeforeEach(func() {
+				addRecord(util.DeploymentRecord{RecordedAt: recordedAt, ProcessID: "1", ProcessType: "DEPLOY", MtaID: "shop", MtaVersion: "1.0.0",
+					Org: "test-org", Space: "test-space", Strategy: "default", State: "FINISHED", DurationInSec: 95})
+				addRecord(util.DeploymentRecord{RecordedAt: recordedAt.Add(time.Hour), ProcessID: "2", ProcessType: "UNDEPLOY", MtaID: "billing",
+					SpaceID: "test-space-guid", State: "ERROR", DurationInSec: 30})
+				addRecord(util.DeploymentRecord{RecordedAt: latestRecordedAt, ProcessID: "3", ProcessType: "BLUE_GREEN_DEPLOY", MtaID: "shop", MtaVersion: "1.1.0",
+					Namespace: "dev", Org: "test-org", Space: "test-space", Strategy: "blue-green", State: "ABORTED", DurationInSec: 3600})
+			})
+
+			It("should list all recorded operations", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{}).ToInt()
+				})
+				ex.ExpectSuccessWithOutput(status, output, getOutputLines(
+					getRecordLine(recordedAt, "1", "DEPLOY", "shop", "1.0.0", "", "test-org / test-space", "default", "FINISHED", "1m35s"),
+					getRecordLine(recordedAt.Add(time.Hour), "2", "UNDEPLOY", "billing", "", "", "test-space-guid", "", "ERROR", "30s"),
+					getRecordLine(latestRecordedAt, "3", "BLUE_GREEN_DEPLOY", "shop", "1.1.0", "dev", "test-org / test-space", "blue-green", "ABORTED", "1h0m0s"),
+				))
+			})
+
+			It("should list the operations which match the filters", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--mta", "shop", "--state", "finished"}).ToInt()
+				})
+				ex.ExpectSuccessWithOutput(status, output, getOutputLines(
+					getRecordLine(recordedAt, "1", "DEPLOY", "shop", "1.0.0", "", "test-org / test-space", "default", "FINISHED", "1m35s"),
+				))
+			})
+
+			It("should list the operations recorded within the duration", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--since", "1d", "--type", "blue_green_deploy"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(HaveLen(4))
+				Expect(output[3]).To(ContainSubstring("blue-green"))
+			})
+
+			It("should list the last operations", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--last", "1"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(HaveLen(4))
+				Expect(output[3]).To(ContainSubstring("ABORTED"))
+			})
+		})
+
+		Context("with an invalid duration", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"--since", "yesterday"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, `Invalid duration "yesterday", expected a value like 7d, 12h or 30m`)
+			})
+		})
+
+		AfterEach(func() {
+			os.Setenv("CF_HOME", cfHome)
+			os.RemoveAll(historyHome)
+		})
+	})
+})
@@ -0,0 +1,245 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"code.cloudfoundry.org/cli/v8/plugin"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	jsonOpt = "json"
+
+	maskedParameterValue = "********"
+	unknownDuration      = "unknown"
+)
+
+// The values of the operation parameters whose names contain one of these words are masked
+var secureParameterNameParts = []string{"password", "secret", "token", "credential", "certificate", "privatekey", "private-key"}
+
+// operationDetails is the detail view of an operation, as printed in JSON form
+type operationDetails struct {
+	ID           string             `json:"id"`
+	Type         string             `json:"type"`
+	MtaID        string             `json:"mtaId,omitempty"`
+	Namespace    string             `json:"namespace,omitempty"`
+	State        string             `json:"state"`
+	ErrorType    string             `json:"errorType,omitempty"`
+	StartedAt    string             `json:"startedAt"`
+	StartedBy    string             `json:"startedBy"`
+	Duration     string             `json:"duration"`
+	AcquiredLock bool               `json:"acquiredLock"`
+	Actions      []string           `json:"actions"`
+	Parameters   map[string]string  `json:"parameters"`
+	Messages     []operationMessage `json:"messages"`
+}
+
+type operationMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// MtaOperationCommand is a command for showing the details of a single MTA operation
+type MtaOperationCommand struct {
+	*BaseCommand
+}
+
+func NewMtaOperationCommand() *MtaOperationCommand {
+	baseCmd := &BaseCommand{flagsParser: NewDefaultCommandFlagsParser([]string{"OPERATION_ID"}), flagsValidator: NewDefaultCommandFlagsValidator(nil)}
+	mtaOpCmd := &MtaOperationCommand{baseCmd}
+	baseCmd.Command = mtaOpCmd
+	return mtaOpCmd
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *MtaOperationCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "mta-op",
+		HelpText: "Display the details of a multi-target app operation",
+		UsageDetails: plugin.Usage{
+			Usage: "cf mta-op OPERATION_ID [-u URL] [--json]" + util.BaseEnvHelpText,
+			Options: map[string]string{
+				deployServiceURLOpt:          "Deploy service URL, by default 'deploy-service.<system-domain>'",
+				util.GetShortOption(jsonOpt): "Print the details in JSON form",
+			},
+		},
+	}
+}
+
+func (c *MtaOperationCommand) defineCommandOptions(flags *flag.FlagSet) {
+	flags.Bool(jsonOpt, false, "")
+}
+
+func (c *MtaOperationCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	operationID := positionalArgs[0]
+	printJson := GetBoolOpt(jsonOpt, flags)
+	var redactor *secure_parameters.Redactor
+	if printJson {
+		redactor = newJsonOutputRedactor()
+	} else {
+		redactor = newRedactor()
+		ui.Say("Getting multi-target app operation %s in org %s / space %s as %s...",
+			terminal.EntityNameColor(operationID), terminal.EntityNameColor(cfTarget.Org.Name),
+			terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+	}
+
+	mtaClient := c.NewMtaClient(dsHost, cfTarget)
+	details, err := getOperationDetails(operationID, mtaClient, redactor)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	if printJson {
+		detailsJson, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			ui.Failed("Could not marshal operation details to JSON: %s", err)
+			return Failure
+		}
+		ui.Say("%s", detailsJson)
+		return Success
+	}
+	ui.Ok()
+	printOperationDetails(details)
+	return Success
+}
+
+func getOperationDetails(operationID string, mtaClient mtaclient.MtaClientOperations, redactor *secure_parameters.Redactor) (operationDetails, error) {
+	operation, err := mtaClient.GetMtaOperation(operationID, "messages")
+	if err != nil {
+		ce, ok := err.(*baseclient.ClientError)
+		if ok && ce.Code == 404 {
+			return operationDetails{}, fmt.Errorf("Multi-target app operation with ID %s not found", terminal.EntityNameColor(operationID))
+		}
+		return operationDetails{}, fmt.Errorf("Could not get multi-target app operation %s: %s", terminal.EntityNameColor(operationID), baseclient.NewClientError(err))
+	}
+	actions, err := mtaClient.GetOperationActions(operationID)
+	if err != nil {
+		return operationDetails{}, fmt.Errorf("Could not get actions of multi-target app operation %s: %s", terminal.EntityNameColor(operationID), baseclient.NewClientError(err))
+	}
+
+	details := operationDetails{
+		ID:           operation.ProcessID,
+		Type:         operation.ProcessType,
+		MtaID:        operation.MtaID,
+		Namespace:    operation.Namespace,
+		State:        string(operation.State),
+		ErrorType:    string(operation.ErrorType),
+		StartedAt:    operation.StartedAt,
+		StartedBy:    operation.User,
+		Duration:     getOperationDuration(operation, mtaClient),
+		AcquiredLock: operation.AcquiredLock,
+		Actions:      append([]string{}, actions...),
+		Parameters:   maskSecureParameters(operation.Parameters),
+		Messages:     []operationMessage{},
+	}
+	for _, message := range operation.Messages {
+		details.Messages = append(details.Messages, operationMessage{Type: string(message.Type), Text: redactor.Redact(message.Text)})
+	}
+	return details, nil
+}
+
+// getOperationDuration returns the time elapsed since the start of an active operation. The duration of a completed
+// operation is computed from the last modification of its logs.
+func getOperationDuration(operation *models.Operation, mtaClient mtaclient.MtaClientOperations) string {
+	startedAt, err := parseOperationStartTime(operation.StartedAt)
+	if err != nil {
+		return unknownDuration
+	}
+	if operation.State == models.StateRUNNING || operation.State == models.StateERROR || operation.State == models.StateACTIONREQUIRED {
+		return time.Since(startedAt).Round(time.Second).String() + " (active)"
+	}
+	logs, err := mtaClient.GetMtaOperationLogs(operation.ProcessID)
+	if err != nil {
+		return unknownDuration
+	}
+	var endedAt time.Time
+	for _, log := range logs {
+		if lastModified := time.Time(log.LastModified); lastModified.After(endedAt) {
+			endedAt = lastModified
+		}
+	}
+	if endedAt.Before(startedAt) {
+		return unknownDuration
+	}
+	return endedAt.Sub(startedAt).Round(time.Second).String()
+}
+
+// maskSecureParameters converts the operation parameters to strings and masks the values of the secure ones
+func maskSecureParameters(parameters map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if isSecureParameter(name) {
+			result[name] = maskedParameterValue
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			valueJson, _ := json.Marshal(value)
+			result[name] = string(valueJson)
+		default:
+			result[name] = fmt.Sprint(value)
+		}
+	}
+	return result
+}
+
+func isSecureParameter(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range secureParameterNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func printOperationDetails(details operationDetails) {
+	ui.Say("Type: %s", details.Type)
+	ui.Say("MTA ID: %s", valueOrNotAvailable(details.MtaID))
+	ui.Say("Namespace: %s", details.Namespace)
+	ui.Say("Status: %s", details.State)
+	ui.Say("Error type: %s", valueOrNotAvailable(details.ErrorType))
+	ui.Say("Started at: %s", details.StartedAt)
+	ui.Say("Started by: %s", details.StartedBy)
+	ui.Say("Duration: %s", details.Duration)
+	ui.Say("Lock acquired: %t", details.AcquiredLock)
+	ui.Say("Actions: %s", valueOrNotAvailable(strings.Join(details.Actions, ", ")))
+
+	ui.Say("\nParameters:")
+	names := make([]string, 0, len(details.Parameters))
+	for name := range details.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	table := ui.Table([]string{"name", "value"})
+	for _, name := range names {
+		table.Add(name, details.Parameters[name])
+	}
+	table.Print()
+
+	ui.Say("\nMessages:")
+	table = ui.Table([]string{"type", "text"})
+	for _, message := range details.Messages {
+		table.Add(message.Type, message.Text)
+	}
+	table.Print()
+}
+
+func valueOrNotAvailable(value string) string {
+	if value == "" {
+		return "N/A"
+	}
+	return value
+}
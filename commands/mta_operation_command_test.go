@@ -0,0 +1,154 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+
+	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
+	cli_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	mtafake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	util_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/util/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MtaOperationCommand", func() {
+	Describe("Execute", func() {
+		var name string
+		var cliConnection *plugin_fakes.FakeCliConnection
+		var mtaClientBuilder *mtafake.FakeMtaClientBuilder
+		var command *commands.MtaOperationCommand
+		var oc = testutil.NewUIOutputCapturer()
+		var ex = testutil.NewUIExpector()
+
+		var operation = &models.Operation{
+			ProcessID:   "1000",
+			ProcessType: "DEPLOY",
+			MtaID:       "shop",
+			State:       models.StateABORTED,
+			ErrorType:   models.ErrorTypeCONTENT,
+			StartedAt:   "2016-03-04T14:23:24.521Z[Etc/UTC]",
+			User:        "admin",
+			Parameters:  map[string]interface{}{"mtaId": "shop", "dbPassword": "s3cr3t"},
+			Messages: models.OperationMessages{
+				{Type: models.MessageTypeINFO, Text: "Uploading file"},
+				{Type: models.MessageTypeERROR, Text: "Error processing descriptor"},
+			},
+		}
+		var logs = []*models.Log{
+			{ID: "OPERATION.log", LastModified: strfmt.DateTime(time.Date(2016, 3, 4, 14, 25, 24, 521000000, time.UTC))},
+		}
+
+		var initializeCommand = func() {
+			cliConnection = cli_fakes.NewFakeCliConnectionBuilder().
+				CurrentOrg("test-org-guid", "test-org", nil).
+				CurrentSpace("test-space-guid", "test-space", nil).
+				Username("test-user", nil).
+				AccessToken("bearer test-token", nil).Build()
+			testClientFactory := commands.NewTestClientFactory(mtaClientBuilder.Build(), nil, nil)
+			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
+			deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
+			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+		}
+
+		BeforeEach(func() {
+			ui.DisableTerminalOutput(true)
+			command = commands.NewMtaOperationCommand()
+			name = command.GetPluginCommand().Name
+			mtaClientBuilder = mtafake.NewFakeMtaClientBuilder().
+				GetMtaOperation("1000", "messages", operation, nil).
+				GetOperationActions("1000", []string{"retry", "abort"}, nil).
+				GetMtaOperationLogs("1000", logs, nil)
+		})
+
+		Context("with an operation that exists", func() {
+			It("should print its details with the secure parameters masked", func() {
+				initializeCommand()
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"1000"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElement("Getting multi-target app operation 1000 in org test-org / space test-space as test-user..."))
+				Expect(output).To(ContainElement("Status: ABORTED"))
+				Expect(output).To(ContainElement("Error type: CONTENT"))
+				Expect(output).To(ContainElement("Duration: 2m0s"))
+				Expect(output).To(ContainElement("Actions: retry, abort"))
+				joinedOutput := strings.Join(output, "\n")
+				Expect(joinedOutput).To(MatchRegexp(`dbPassword\s+\*{8}`))
+				Expect(joinedOutput).To(MatchRegexp(`mtaId\s+shop`))
+				Expect(joinedOutput).To(MatchRegexp(`ERROR\s+Error processing descriptor`))
+				Expect(joinedOutput).ToNot(ContainSubstring("s3cr3t"))
+			})
+		})
+
+		Context("with the json option", func() {
+			It("should print the details in JSON form", func() {
+				initializeCommand()
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"1000", "--json"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				var details map[string]interface{}
+				Expect(json.Unmarshal([]byte(strings.Join(output, "\n")), &details)).To(Succeed())
+				Expect(details["id"]).To(Equal("1000"))
+				Expect(details["state"]).To(Equal("ABORTED"))
+				Expect(details["duration"]).To(Equal("2m0s"))
+				Expect(details["parameters"]).To(HaveKeyWithValue("dbPassword", "********"))
+				Expect(details["actions"]).To(ConsistOf("retry", "abort"))
+				Expect(details["messages"]).To(HaveLen(2))
+			})
+		})
+
+		Context("with the json option and redaction patterns which cannot be loaded", func() {
+			BeforeEach(func() {
+				os.Setenv(secure_parameters.RedactionPatternsEnv, "not-existing-redaction-patterns.txt")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv(secure_parameters.RedactionPatternsEnv)
+			})
+
+			It("should print only the details in JSON form", func() {
+				initializeCommand()
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"1000", "--json"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				var details map[string]interface{}
+				Expect(json.Unmarshal([]byte(strings.Join(output, "\n")), &details)).To(Succeed())
+				Expect(details["id"]).To(Equal("1000"))
+			})
+
+			It("should warn about the redaction without the json option", func() {
+				initializeCommand()
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"1000"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElement(ContainSubstring("Some secrets might not be redacted")))
+			})
+		})
+
+		Context("with an operation that does not exist", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				mtaClientBuilder = mtafake.NewFakeMtaClientBuilder().
+					GetMtaOperation("999", "messages", nil, &baseclient.ClientError{Code: 404, Status: "404 Not Found"})
+				initializeCommand()
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"999"}).ToInt()
+				})
+				ex.ExpectFailureOnLine(status, output, "Multi-target app operation with ID 999 not found", 2)
+			})
+		})
+	})
+})
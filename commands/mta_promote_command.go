@@ -0,0 +1,424 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"code.cloudfoundry.org/cli/v8/plugin"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/configuration"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	fromOpt                    = "from"
+	toOpt                      = "to"
+	archiveOpt                 = "archive"
+	skipArchiveVerificationOpt = "skip-archive-verification"
+)
+
+var errArchiveNotStored = errors.New("archive is not stored")
+
+// MtaPromoteCommand is a command for promoting the deployed version of an MTA from one space to another
+type MtaPromoteCommand struct {
+	*DeployCommand
+}
+
+// NewMtaPromoteCommand creates a new mta-promote command
+func NewMtaPromoteCommand() *MtaPromoteCommand {
+	requiredFlags := map[string]bool{fromOpt: true, toOpt: true}
+	baseCmd := &BaseCommand{flagsParser: NewDefaultCommandFlagsParser([]string{"MTA_ID"}), flagsValidator: NewDefaultCommandFlagsValidator(requiredFlags)}
+	deployCmd := &DeployCommand{baseCmd, deployProcessParametersSetter(), &deployCommandProcessTypeProvider{}, os.Stdin, 30 * time.Second, nil, util.NewSimpleGetExecutor(), make(chan os.Signal, 1)}
+	promoteCmd := &MtaPromoteCommand{deployCmd}
+	baseCmd.Command = promoteCmd
+	return promoteCmd
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *MtaPromoteCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "mta-promote",
+		HelpText: "Promote the deployed version of a multi-target app from one space to another",
+		UsageDetails: plugin.Usage{
+			Usage: `cf mta-promote MTA_ID --from [ORG/]SPACE --to [ORG/]SPACE [--archive MTA_ARCHIVE [--skip-archive-verification]] [-e EXT_DESCRIPTOR[,...]] [--namespace NAMESPACE] [-f] [--retries RETRIES] [-u URL]
+
+   Without an archive, the archive uploaded by the latest deploy operation is reused, which requires that this operation deployed the version and kept its files (see the "--keep-files" option of the deploy command). Since the file storage of the deploy service is space-scoped and stored archives cannot be downloaded, it can only be reused if an identical archive is stored in the target space, e.g. because it was deployed there before.
+
+   Otherwise, the archive must contain the version of the multi-target app which is deployed in the source space. It is verified against the digests of the archive which was uploaded by the operation that deployed this version.` + util.UploadEnvHelpText,
+			Options: map[string]string{
+				deployServiceURLOpt:                             "Deploy service URL, by default 'deploy-service.<system-domain>'",
+				util.GetShortOption(fromOpt):                    "Space in which the version to promote is deployed, in the targeted org unless an org is specified",
+				util.GetShortOption(toOpt):                      "Space to promote the multi-target app to, in the targeted org unless an org is specified",
+				util.GetShortOption(archiveOpt):                 "Archive of the deployed version of the multi-target app, if the uploaded archive cannot be reused",
+				extDescriptorsOpt:                               "Extension descriptors of the target space",
+				util.GetShortOption(namespaceOpt):               "Namespace of the multi-target app in both spaces",
+				util.GetShortOption(skipArchiveVerificationOpt): "Do not verify that the archive is identical to the one deployed in the source space",
+				forceOpt:                        "Force deploy without confirmation for aborting conflicting processes",
+				util.GetShortOption(retriesOpt): "Retry the operation N times in case a non-content error occurs (default 3)",
+			},
+		},
+	}
+}
+
+func (c *MtaPromoteCommand) defineCommandOptions(flags *flag.FlagSet) {
+	flags.String(fromOpt, "", "")
+	flags.String(toOpt, "", "")
+	flags.String(archiveOpt, "", "")
+	flags.String(extDescriptorsOpt, "", "")
+	flags.String(namespaceOpt, "", "")
+	flags.Bool(skipArchiveVerificationOpt, false, "")
+	flags.Bool(forceOpt, false, "")
+	flags.Uint(retriesOpt, 3, "")
+}
+
+func (c *MtaPromoteCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	mtaID := positionalArgs[0]
+	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
+
+	sourceTarget, err := c.resolveSpace(GetStringOpt(fromOpt, flags), cfTarget)
+	if err != nil {
+		ui.Failed("Could not resolve source space: %s", err.Error())
+		return Failure
+	}
+	destinationTarget, err := c.resolveSpace(GetStringOpt(toOpt, flags), cfTarget)
+	if err != nil {
+		ui.Failed("Could not resolve target space: %s", err.Error())
+		return Failure
+	}
+	if sourceTarget.Space.Guid == destinationTarget.Space.Guid {
+		ui.Failed("The source and the target space must be different")
+		return Failure
+	}
+
+	ui.Say("Promoting multi-target app %s from org %s / space %s to org %s / space %s as %s...",
+		terminal.EntityNameColor(mtaID), terminal.EntityNameColor(sourceTarget.Org.Name), terminal.EntityNameColor(sourceTarget.Space.Name),
+		terminal.EntityNameColor(destinationTarget.Org.Name), terminal.EntityNameColor(destinationTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+
+	deployedVersion, err := c.getDeployedVersion(mtaID, namespace, dsHost, sourceTarget)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	sourceMtaClient := c.NewMtaClient(dsHost, sourceTarget)
+	sourceOperation, err := findLatestDeployOperation(mtaID, namespace, sourceMtaClient)
+	if err != nil {
+		ui.Failed("Could not get operations for multi-target app %s: %s", terminal.EntityNameColor(mtaID), baseclient.NewClientError(err))
+		return Failure
+	}
+	if sourceOperation == nil {
+		ui.Failed("No finished deploy operation of multi-target app %s found in space %s", terminal.EntityNameColor(mtaID), terminal.EntityNameColor(sourceTarget.Space.Name))
+		return Failure
+	}
+	archive := GetStringOpt(archiveOpt, flags)
+	operationVersion := getOperationVersion(sourceOperation)
+	if operationVersion != "" && operationVersion != deployedVersion {
+		ui.Failed("The latest deploy operation %s of multi-target app %s deployed version %s, but version %s is deployed. Use the %q option to promote a copy of the deployed version.",
+			sourceOperation.ProcessID, mtaID, operationVersion, deployedVersion, "--"+archiveOpt)
+		return Failure
+	}
+	if operationVersion == "" && archive == "" {
+		ui.Failed("The latest deploy operation %s of multi-target app %s did not record the deployed version, so its archive cannot be reused. Use the %q option to promote a copy of the deployed version.",
+			sourceOperation.ProcessID, mtaID, "--"+archiveOpt)
+		return Failure
+	}
+	if operationVersion != "" {
+		ui.Say("Version %s was deployed by operation %s started at %s", terminal.EntityNameColor(deployedVersion),
+			terminal.EntityNameColor(sourceOperation.ProcessID), sourceOperation.StartedAt)
+	} else {
+		ui.Say("The latest deploy operation of multi-target app %s is operation %s started at %s", terminal.EntityNameColor(mtaID),
+			terminal.EntityNameColor(sourceOperation.ProcessID), sourceOperation.StartedAt)
+	}
+
+	deployFlags, err := c.createDeployFlags(namespace, GetStringOpt(extDescriptorsOpt, flags), GetUintOpt(retriesOpt, flags))
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	mtaClient := c.NewMtaClient(dsHost, destinationTarget)
+	var operationLocation string
+	var status ExecutionStatus
+	if archive != "" {
+		operationLocation, status = c.deployArchive(archive, mtaID, deployedVersion, namespace, sourceOperation, sourceMtaClient, mtaClient, dsHost, deployFlags, flags, destinationTarget)
+	} else {
+		operationLocation, status = c.deployStoredArchive(mtaID, namespace, sourceOperation, sourceMtaClient, mtaClient, dsHost, deployFlags, GetBoolOpt(forceOpt, flags), destinationTarget)
+	}
+	if status == Failure {
+		return Failure
+	}
+	deployCommandName := NewDeployCommand().GetPluginCommand().Name
+	historyContext := newDeploymentHistoryContext(flags, destinationTarget)
+	if archive != "" {
+		historyContext = historyContext.withArchive(archive)
+	}
+	executionMonitor := NewExecutionMonitorFromLocationHeader(deployCommandName, operationLocation, GetUintOpt(retriesOpt, flags), []*models.Message{}, mtaClient).
+		withHistoryContext(historyContext).
+		WithParentSpan(c.span)
+	return executionMonitor.Monitor()
+}
+
+// resolveSpace resolves a space specified as SPACE or ORG/SPACE. Spaces without an org are looked up in the targeted org.
+func (c *MtaPromoteCommand) resolveSpace(value string, cfTarget util.CloudFoundryTarget) (util.CloudFoundryTarget, error) {
+	orgName, spaceName := cfTarget.Org.Name, value
+	if strings.Contains(value, "/") {
+		target, err := parseDeployTargets(value)
+		if err != nil {
+			return util.CloudFoundryTarget{}, err
+		}
+		orgName, spaceName = target[0].org, target[0].space
+	}
+	cfContext := util.NewCloudFoundryContext(c.cliConnection)
+	org, space, err := cfContext.GetOrgAndSpace(orgName, spaceName)
+	if err != nil {
+		return util.CloudFoundryTarget{}, err
+	}
+	return util.NewCFTarget(org, space, cfTarget.Username), nil
+}
+
+func (c *MtaPromoteCommand) getDeployedVersion(mtaID, namespace, dsHost string, cfTarget util.CloudFoundryTarget) (string, error) {
+	mtaV2Client := c.NewMtaV2Client(dsHost, cfTarget)
+	mtas, err := mtaV2Client.GetMtas(&mtaID, &namespace, cfTarget.Space.Guid)
+	if err != nil {
+		ce, ok := err.(*baseclient.ClientError)
+		if ok && ce.Code == 404 {
+			return "", fmt.Errorf("Multi-target app %s not found in space %s", terminal.EntityNameColor(mtaID), terminal.EntityNameColor(cfTarget.Space.Name))
+		}
+		return "", fmt.Errorf("Could not get multi-target app %s: %s", terminal.EntityNameColor(mtaID), baseclient.NewClientError(err))
+	}
+	if len(mtas) == 0 {
+		return "", fmt.Errorf("Multi-target app %s not found in space %s", terminal.EntityNameColor(mtaID), terminal.EntityNameColor(cfTarget.Space.Name))
+	}
+	if len(mtas) > 1 {
+		return "", fmt.Errorf("Multiple multi-target apps exist for name %s, please enter namespace", terminal.EntityNameColor(mtaID))
+	}
+	return mtas[0].Metadata.Version, nil
+}
+
+// findLatestDeployOperation finds the most recently started finished deploy operation of the MTA. Its version is not
+// necessarily the deployed one, e.g. if the MTA was rolled back since.
+func findLatestDeployOperation(mtaID, namespace string, mtaClient mtaclient.MtaClientOperations) (*models.Operation, error) {
+	operations, err := mtaClient.GetMtaOperations(&mtaID, nil, []string{string(models.StateFINISHED)})
+	if err != nil {
+		return nil, err
+	}
+	deployProcessTypes := []string{deployCommandProcessTypeProvider{}.GetProcessType(), blueGreenDeployCommandProcessTypeProvider{}.GetProcessType()}
+	var latestOperation *models.Operation
+	for _, operation := range operations {
+		if operation.MtaID != mtaID || operation.Namespace != namespace || !util.Contains(deployProcessTypes, operation.ProcessType) {
+			continue
+		}
+		if latestOperation == nil || operation.StartedAt > latestOperation.StartedAt {
+			latestOperation = operation
+		}
+	}
+	return latestOperation, nil
+}
+
+// getOperationVersion returns the version of the MTA deployed by the operation or an empty string if the operation did not
+// record it
+func getOperationVersion(operation *models.Operation) string {
+	version, _ := operation.Parameters["mtaVersion"].(string)
+	return version
+}
+
+// deployArchive verifies the specified archive against the one uploaded by the source operation and deploys it
+func (c *MtaPromoteCommand) deployArchive(archive, mtaID, deployedVersion, namespace string, sourceOperation *models.Operation, sourceMtaClient, mtaClient mtaclient.MtaClientOperations,
+	dsHost string, deployFlags, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) (string, ExecutionStatus) {
+	archivePath, err := filepath.Abs(archive)
+	if err != nil {
+		ui.Failed("Could not get absolute path of file %q", archive)
+		return "", Failure
+	}
+	if status := c.verifyArchive(archivePath, mtaID, deployedVersion, namespace, sourceOperation, sourceMtaClient, GetBoolOpt(skipArchiveVerificationOpt, flags)); status == Failure {
+		return "", Failure
+	}
+	mtaElementsCalculator := mtaElementsToAddCalculator{shouldAddAllModules: true, shouldAddAllResources: true}
+	_, operationLocation, status := c.deployMtaArchive(archivePath, mtaElementsCalculator, GetBoolOpt(forceOpt, flags), mtaClient, dsHost, deployFlags, cfTarget)
+	return operationLocation, status
+}
+
+// deployStoredArchive deploys the archive uploaded by the source operation without uploading it again. The deploy
+// service stores files per space and does not offer downloads of stored files, so the archive is reused from the
+// identical files stored in the target space.
+func (c *MtaPromoteCommand) deployStoredArchive(mtaID, namespace string, sourceOperation *models.Operation, sourceMtaClient, mtaClient mtaclient.MtaClientOperations,
+	dsHost string, deployFlags *flag.FlagSet, force bool, cfTarget util.CloudFoundryTarget) (string, ExecutionStatus) {
+	ui.Say("Looking up the archive uploaded by operation %s in space %s...", terminal.EntityNameColor(sourceOperation.ProcessID), terminal.EntityNameColor(cfTarget.Space.Name))
+	archiveParts, err := getStoredArchiveParts(namespace, sourceOperation, sourceMtaClient)
+	if errors.Is(err, errArchiveNotStored) {
+		ui.Failed("The archive uploaded by operation %s is no longer stored. Use the %q option to promote a copy of the archive.",
+			sourceOperation.ProcessID, "--"+archiveOpt)
+		return "", Failure
+	}
+	if err != nil {
+		ui.Failed("Could not get the archive uploaded by operation %s: %s", sourceOperation.ProcessID, err.Error())
+		return "", Failure
+	}
+	targetFiles, err := mtaClient.GetMtaFiles(&namespace)
+	if err != nil {
+		ui.Failed("Could not get the files stored in space %s: %s", terminal.EntityNameColor(cfTarget.Space.Name), baseclient.NewClientError(err))
+		return "", Failure
+	}
+	archivePartIDs := findIdenticalFiles(archiveParts, targetFiles)
+	if archivePartIDs == nil {
+		ui.Failed("The archive uploaded by operation %s is not stored in space %s and stored archives cannot be downloaded. Use the %q option to promote a copy of the archive, which is verified against the stored one.",
+			sourceOperation.ProcessID, cfTarget.Space.Name, "--"+archiveOpt)
+		return "", Failure
+	}
+	ui.Ok()
+
+	ui.Say("Deploying the stored archive of multi-target app %s in org %s / space %s as %s...\n", terminal.EntityNameColor(mtaID),
+		terminal.EntityNameColor(cfTarget.Org.Name), terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+	wasAborted, err := c.CheckOngoingOperation(mtaID, namespace, dsHost, force, cfTarget)
+	if err != nil {
+		ui.Failed("Could not get MTA operations: %s", baseclient.NewClientError(err))
+		return "", Failure
+	}
+	if !wasAborted {
+		return "", Failure
+	}
+	conf := configuration.NewSnapshot()
+	fileUploader := NewFileUploader(mtaClient, namespace, conf.GetUploadChunkSizeInMB(), conf.GetUploadChunksSequentially(), conf.GetDisableUploadProgressBar())
+	extDescriptorIDs, status := c.uploadExtDescriptors(fileUploader, deployFlags)
+	if status == Failure {
+		return "", Failure
+	}
+	mtaElementsCalculator := mtaElementsToAddCalculator{shouldAddAllModules: true, shouldAddAllResources: true}
+	return c.startDeployOperation(mtaID, namespace, archivePartIDs, extDescriptorIDs, "", mtaElementsCalculator, mtaClient, deployFlags)
+}
+
+// findIdenticalFiles returns the IDs of the files with the same size and digests as the archive parts, in the order
+// of the parts, or nil if a part has no identical file
+func findIdenticalFiles(archiveParts, files []*models.FileMetadata) []string {
+	var fileIDs []string
+	for _, archivePart := range archiveParts {
+		var identicalFile *models.FileMetadata
+		for _, file := range files {
+			if file.Size == archivePart.Size && strings.EqualFold(file.DigestAlgorithm, archivePart.DigestAlgorithm) && strings.EqualFold(file.Digest, archivePart.Digest) {
+				identicalFile = file
+				break
+			}
+		}
+		if identicalFile == nil {
+			return nil
+		}
+		fileIDs = append(fileIDs, identicalFile.ID)
+	}
+	return fileIDs
+}
+
+// verifyArchive verifies that the archive contains the deployed version of the MTA and, unless skipped, that it is
+// identical to the archive uploaded by the operation which deployed this version
+func (c *MtaPromoteCommand) verifyArchive(archivePath, mtaID, deployedVersion, namespace string, sourceOperation *models.Operation,
+	sourceMtaClient mtaclient.MtaClientOperations, skipVerification bool) ExecutionStatus {
+	descriptor, err := util.GetMtaDescriptorFromArchive(archivePath)
+	if os.IsNotExist(err) {
+		ui.Failed("Could not find file %s", terminal.EntityNameColor(archivePath))
+		return Failure
+	} else if err != nil {
+		ui.Failed("Could not get MTA ID from deployment descriptor: %s", err)
+		return Failure
+	}
+	if descriptor.ID != mtaID || descriptor.Version != deployedVersion {
+		ui.Failed("The archive %s contains version %s of multi-target app %s, but version %s of multi-target app %s is deployed",
+			terminal.EntityNameColor(archivePath), descriptor.Version, descriptor.ID, deployedVersion, mtaID)
+		return Failure
+	}
+	if skipVerification {
+		ui.Warn("Skipping verification of the archive against the one deployed by operation %s", sourceOperation.ProcessID)
+		return Success
+	}
+
+	ui.Say("Verifying archive %s...", terminal.EntityNameColor(archivePath))
+	err = verifyArchiveDigests(archivePath, namespace, sourceOperation, sourceMtaClient)
+	if errors.Is(err, errArchiveNotStored) {
+		ui.Failed("The archive uploaded by operation %s is no longer stored, so the archive cannot be verified. Use the %q option to promote it without verification.",
+			sourceOperation.ProcessID, "--"+skipArchiveVerificationOpt)
+		return Failure
+	}
+	if err != nil {
+		ui.Failed("Could not verify archive %s: %s", terminal.EntityNameColor(archivePath), err.Error())
+		return Failure
+	}
+	ui.Ok()
+	return Success
+}
+
+// verifyArchiveDigests compares the digests of the consecutive parts of the archive with the digests of the stored
+// files, in which the archive was uploaded by the operation
+func verifyArchiveDigests(archivePath, namespace string, operation *models.Operation, mtaClient mtaclient.MtaClientOperations) error {
+	storedFiles, err := getStoredArchiveParts(namespace, operation, mtaClient)
+	if err != nil {
+		return err
+	}
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	for _, storedFile := range storedFiles {
+		digest, err := util.ComputeChecksum(io.LimitReader(archive, int64(storedFile.Size)), storedFile.DigestAlgorithm)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(digest, storedFile.Digest) {
+			return fmt.Errorf("The archive is not identical to the one uploaded by operation %s", operation.ProcessID)
+		}
+	}
+	if n, _ := archive.Read(make([]byte, 1)); n != 0 {
+		return fmt.Errorf("The archive is not identical to the one uploaded by operation %s", operation.ProcessID)
+	}
+	return nil
+}
+
+// getStoredArchiveParts returns the stored files in which the archive was uploaded by the operation, in the order of
+// the archive parts
+func getStoredArchiveParts(namespace string, operation *models.Operation, mtaClient mtaclient.MtaClientOperations) ([]*models.FileMetadata, error) {
+	archiveFileIDs, _ := operation.Parameters["appArchiveId"].(string)
+	if archiveFileIDs == "" {
+		return nil, errArchiveNotStored
+	}
+	storedFiles, err := mtaClient.GetMtaFiles(&namespace)
+	if err != nil {
+		return nil, baseclient.NewClientError(err)
+	}
+	var archiveParts []*models.FileMetadata
+	for _, fileID := range strings.Split(archiveFileIDs, ",") {
+		storedFile := findFileByID(storedFiles, fileID)
+		if storedFile == nil {
+			return nil, errArchiveNotStored
+		}
+		archiveParts = append(archiveParts, storedFile)
+	}
+	return archiveParts, nil
+}
+
+func findFileByID(files []*models.FileMetadata, fileID string) *models.FileMetadata {
+	for _, file := range files {
+		if file.ID == fileID {
+			return file
+		}
+	}
+	return nil
+}
+
+// createDeployFlags creates the flags of the deploy command for deploying the archive to the target space
+func (c *MtaPromoteCommand) createDeployFlags(namespace, extDescriptors string, retries uint) (*flag.FlagSet, error) {
+	args := []string{"--" + retriesOpt, fmt.Sprint(retries)}
+	if extDescriptors != "" {
+		args = append(args, "-"+extDescriptorsOpt, extDescriptors)
+	}
+	if namespace != "" {
+		args = append(args, "--"+namespaceOpt, namespace)
+	}
+	return c.parseDeployFlags(args)
+}
@@ -0,0 +1,218 @@
+package commands_test
+
+import (
+	"os"
+	"strings"
+
+	plugin_models "code.cloudfoundry.org/cli/v8/plugin/models"
+	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
+	cli_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	mtafake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
+	mtav2fake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient_v2/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	util_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/util/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MtaPromoteCommand", func() {
+	Describe("Execute", func() {
+		const mtaArchivePath = "../test_resources/commands/mtaArchive.mtar"
+
+		var name string
+		var cliConnection *plugin_fakes.FakeCliConnection
+		var mtaClientBuilder *mtafake.FakeMtaClientBuilder
+		var mtaClient *mtafake.FakeMtaClientOperations
+		var deployedMtas []*models.Mta
+		var storedArchive *models.FileMetadata
+		var sourceOperation *models.Operation
+		var laterOperations []*models.Operation
+		var targetSpaceFiles []*models.FileMetadata
+		var command *commands.MtaPromoteCommand
+		var oc = testutil.NewUIOutputCapturer()
+		var ex = testutil.NewUIExpector()
+
+		var orgModel = plugin_models.GetOrg_Model{Guid: "test-org-guid", Name: "test-org", Spaces: []plugin_models.GetOrg_Space{
+			{Guid: "dev-guid", Name: "dev"},
+			{Guid: "prod-guid", Name: "prod"},
+		}}
+
+		var execute = func(args []string) ([]string, int) {
+			mtaClient = mtaClientBuilder.
+				GetMtaFiles([]*models.FileMetadata{storedArchive}, nil).
+				GetMtaOperations(nil, nil, nil, append([]*models.Operation{sourceOperation}, laterOperations...), nil).Build()
+			if targetSpaceFiles != nil {
+				mtaClient.GetMtaFilesReturnsOnCall(1, targetSpaceFiles, nil)
+			}
+			mtaV2Client := mtav2fake.NewFakeMtaV2ClientBuilder().GetMtas("test", nil, "dev-guid", deployedMtas, nil).Build()
+			testClientFactory := commands.NewTestClientFactory(mtaClient, mtaV2Client, nil)
+			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
+			deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
+			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+			return oc.CaptureOutputAndStatus(func() int {
+				return command.Execute(args).ToInt()
+			})
+		}
+
+		BeforeEach(func() {
+			ui.DisableTerminalOutput(true)
+			command = commands.NewMtaPromoteCommand()
+			name = command.GetPluginCommand().Name
+			cliConnection = cli_fakes.NewFakeCliConnectionBuilder().
+				CurrentOrg("test-org-guid", "test-org", nil).
+				CurrentSpace("dev-guid", "dev", nil).
+				Username("test-user", nil).
+				AccessToken("bearer test-token", nil).
+				GetOrg("test-org", orgModel, nil).Build()
+
+			mtaArchiveFile, _ := os.Open(mtaArchivePath)
+			defer mtaArchiveFile.Close()
+			archiveInfo, _ := mtaArchiveFile.Stat()
+			digest, _ := util.ComputeFileChecksum(mtaArchivePath, "MD5")
+			storedArchive = testutil.GetFile(mtaArchiveFile, strings.ToUpper(digest), "")
+			storedArchive.Size = float64(archiveInfo.Size())
+			deployedMtas = []*models.Mta{testutil.GetMta("test", "0.0.1", "", nil, nil)}
+			sourceOperation = testutil.GetOperation("999", "dev-guid", "test", "", "DEPLOY", "FINISHED", false)
+			sourceOperation.Parameters = map[string]interface{}{"appArchiveId": storedArchive.ID, "mtaVersion": "0.0.1"}
+			laterOperations = nil
+			targetSpaceFiles = nil
+
+			mtaClientBuilder = mtafake.NewFakeMtaClientBuilder().
+				UploadMtaFile(mtaArchiveFile, storedArchive, nil).
+				StartMtaOperation(testutil.OperationResult, mtaclient.ResponseHeader{Location: "operations/1000?embed=messages"}, nil).
+				GetMtaOperation("1000", "messages", &testutil.OperationResult, nil)
+		})
+
+		Context("without a target space", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := execute([]string{"test", "--from", "dev"})
+				ex.ExpectFailure(status, output, "Incorrect usage. Missing required options '[to]'")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		Context("without an archive", func() {
+			It("should reuse the identical archive stored in the target space", func() {
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod"})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElement("Looking up the archive uploaded by operation 999 in space prod..."))
+				Expect(mtaClient.UploadMtaFileCallCount()).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				Expect(mtaClient.StartMtaOperationArgsForCall(0).Parameters).To(HaveKeyWithValue("appArchiveId", storedArchive.ID))
+			})
+
+			It("should print an error which suggests the archive option if the archive is not stored in the target space", func() {
+				targetSpaceFiles = []*models.FileMetadata{}
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod"})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("The archive uploaded by operation 999 is not stored in space prod and stored archives cannot be downloaded. Use the \"--archive\" option")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should print an error if the archive of the deploy operation is no longer stored", func() {
+				sourceOperation.Parameters["appArchiveId"] = "deleted-archive-id"
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod"})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("The archive uploaded by operation 999 is no longer stored. Use the \"--archive\" option to promote a copy of the archive.")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a later operation deployed a different version than the deployed one", func() {
+			BeforeEach(func() {
+				laterOperation := testutil.GetOperation("1001", "dev-guid", "test", "", "DEPLOY", "FINISHED", false)
+				laterOperation.StartedAt = "2016-03-05T14:23:24.521Z[Etc/UTC]"
+				laterOperation.Parameters = map[string]interface{}{"appArchiveId": storedArchive.ID, "mtaVersion": "0.0.2"}
+				laterOperations = []*models.Operation{laterOperation}
+			})
+
+			It("should print an error which suggests the archive option and not deploy anything", func() {
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod"})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("The latest deploy operation 1001 of multi-target app test deployed version 0.0.2, but version 0.0.1 is deployed. Use the \"--archive\" option")))
+				Expect(output).ToNot(ContainElement(ContainSubstring("was deployed by operation")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the deploy operation did not record the deployed version", func() {
+			It("should print an error which suggests the archive option and not deploy anything", func() {
+				delete(sourceOperation.Parameters, "mtaVersion")
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod"})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("The latest deploy operation 999 of multi-target app test did not record the deployed version, so its archive cannot be reused.")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with the same source and target space", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				output, status := execute([]string{"test", "--from", "dev", "--to", "test-org/dev", "--archive", mtaArchivePath})
+				ex.ExpectFailure(status, output, "The source and the target space must be different")
+			})
+		})
+
+		Context("with an unknown target space", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				output, status := execute([]string{"test", "--from", "dev", "--to", "qa", "--archive", mtaArchivePath})
+				ex.ExpectFailure(status, output, "Could not resolve target space: Space qa not found in org test-org")
+			})
+		})
+
+		Context("with an archive of a different version than the deployed one", func() {
+			It("should print an error and not deploy anything", func() {
+				deployedMtas = []*models.Mta{testutil.GetMta("test", "0.0.2", "", nil, nil)}
+				sourceOperation.Parameters["mtaVersion"] = "0.0.2"
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod", "--archive", mtaArchivePath})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("contains version 0.0.1 of multi-target app test, but version 0.0.2 of multi-target app test is deployed")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with an archive which is not identical to the deployed one", func() {
+			It("should print an error and not deploy anything", func() {
+				storedArchive.Digest = "0123456789ABCDEF0123456789ABCDEF"
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod", "--archive", mtaArchivePath})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("The archive is not identical to the one uploaded by operation 999")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the archive of the deploy operation is no longer stored", func() {
+			It("should print an error which suggests skipping the verification", func() {
+				sourceOperation.Parameters["appArchiveId"] = "deleted-archive-id"
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod", "--archive", mtaArchivePath})
+				Expect(status).To(Equal(1))
+				Expect(output).To(ContainElement(ContainSubstring("Use the \"--skip-archive-verification\" option to promote it without verification.")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should deploy the archive when the verification is skipped", func() {
+				sourceOperation.Parameters["appArchiveId"] = "deleted-archive-id"
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod", "--archive", mtaArchivePath, "--skip-archive-verification"})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElement(ContainSubstring("Skipping verification of the archive against the one deployed by operation 999")))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("with an archive identical to the deployed one", func() {
+			It("should verify the archive and deploy it to the target space", func() {
+				output, status := execute([]string{"test", "--from", "dev", "--to", "prod", "--archive", mtaArchivePath})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElements(
+					"Promoting multi-target app test from org test-org / space dev to org test-org / space prod as test-user...",
+					"Version 0.0.1 was deployed by operation 999 started at 2016-03-04T14:23:24.521Z[Etc/UTC]",
+				))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"code.cloudfoundry.org/cli/v8/plugin"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const releaseDeployAction = "deploy"
+
+// releaseMtaResult holds the outcome of the deployment of a single MTA of a release
+type releaseMtaResult struct {
+	name        string
+	operationID string
+	status      string
+	duration    time.Duration
+}
+
+// MtaReleaseCommand is a command for deploying the MTAs of a release in the order defined by their dependencies
+type MtaReleaseCommand struct {
+	*DeployCommand
+}
+
+// NewMtaReleaseCommand creates a new mta-release command
+func NewMtaReleaseCommand() *MtaReleaseCommand {
+	baseCmd := &BaseCommand{flagsParser: NewDefaultCommandFlagsParser([]string{"ACTION", "RELEASE_MANIFEST"}), flagsValidator: NewDefaultCommandFlagsValidator(nil)}
+	deployCmd := &DeployCommand{baseCmd, deployProcessParametersSetter(), &deployCommandProcessTypeProvider{}, os.Stdin, 30 * time.Second, nil, util.NewSimpleGetExecutor(), make(chan os.Signal, 1)}
+	releaseCmd := &MtaReleaseCommand{deployCmd}
+	baseCmd.Command = releaseCmd
+	return releaseCmd
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *MtaReleaseCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "mta-release",
+		HelpText: "Deploy the multi-target apps of a release manifest in the order defined by their dependencies",
+		UsageDetails: plugin.Usage{
+			Usage: `cf mta-release deploy RELEASE_MANIFEST [-u URL] [-f] [--retries RETRIES]
+
+   The release manifest lists the multi-target apps of the release:
+
+   mtas:
+   - name: backend
+     archive: backend.mtar
+     extension-descriptors: [backend.mtaext]
+     namespace: dev
+     strategy: blue-green
+   - name: ui
+     archive: ui
+     depends-on: [backend]
+
+   Multi-target apps without pending dependencies are deployed in parallel. If a deployment fails, the multi-target apps which depend on it are skipped.` + util.UploadEnvHelpText,
+			Options: map[string]string{
+				deployServiceURLOpt:             "Deploy service URL, by default 'deploy-service.<system-domain>'",
+				forceOpt:                        "Abort conflicting processes. Without it, multi-target apps with conflicting processes are not deployed, since the deployments run in parallel and cannot ask for confirmation",
+				util.GetShortOption(retriesOpt): "Retry the operations N times in case a non-content error occurs (default 3)",
+			},
+		},
+	}
+}
+
+func (c *MtaReleaseCommand) defineCommandOptions(flags *flag.FlagSet) {
+	flags.Bool(forceOpt, false, "")
+	flags.Uint(retriesOpt, 3, "")
+}
+
+func (c *MtaReleaseCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	action := positionalArgs[0]
+	if action != releaseDeployAction {
+		ui.Failed("Invalid action %s, supported actions: %s", terminal.EntityNameColor(action), releaseDeployAction)
+		return Failure
+	}
+	manifest, err := util.ParseReleaseManifest(positionalArgs[1])
+	if err != nil {
+		ui.Failed("Could not parse release manifest %s: %s", terminal.EntityNameColor(positionalArgs[1]), err.Error())
+		return Failure
+	}
+
+	deployFlags := make(map[string]*flag.FlagSet)
+	for _, mta := range manifest.MTAs {
+		deployFlags[mta.Name], err = c.createDeployFlags(mta, GetUintOpt(retriesOpt, flags))
+		if err != nil {
+			ui.Failed("Invalid deployment options for multi-target app %s: %s", terminal.EntityNameColor(mta.Name), err.Error())
+			return Failure
+		}
+	}
+
+	ui.Say("Deploying release %s with %d multi-target apps in org %s / space %s as %s...\n",
+		terminal.EntityNameColor(positionalArgs[1]), len(manifest.MTAs), terminal.EntityNameColor(cfTarget.Org.Name),
+		terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+
+	force := GetBoolOpt(forceOpt, flags)
+	c.promptsDisabled = true
+	results := make(map[string]*releaseMtaResult)
+	finished := make(chan *releaseMtaResult)
+	running, completed := 0, 0
+	for completed < len(manifest.MTAs) {
+		for _, mta := range getReadyMtas(manifest, results) {
+			if failedDependency := getFailedDependency(mta, results); failedDependency != "" {
+				ui.Warn("Skipping multi-target app %s, because %s was not deployed successfully", mta.Name, failedDependency)
+				results[mta.Name] = &releaseMtaResult{name: mta.Name, status: deploymentSkipped}
+				completed++
+				continue
+			}
+			results[mta.Name] = nil
+			running++
+			go func(mta util.ReleaseMta) {
+				finished <- c.deployReleaseMta(mta, deployFlags[mta.Name], force, dsHost, cfTarget)
+			}(mta)
+		}
+		if running == 0 {
+			continue
+		}
+		result := <-finished
+		results[result.name] = result
+		running--
+		completed++
+	}
+
+	return reportReleaseSummary(manifest, results)
+}
+
+// createDeployFlags creates the flags of the deploy command which correspond to the deployment options of the MTA
+func (c *MtaReleaseCommand) createDeployFlags(mta util.ReleaseMta, retries uint) (*flag.FlagSet, error) {
+	args := []string{"--" + retriesOpt, fmt.Sprint(retries)}
+	if len(mta.ExtensionDescriptors) != 0 {
+		args = append(args, "-"+extDescriptorsOpt, strings.Join(mta.ExtensionDescriptors, ","))
+	}
+	if mta.Namespace != "" {
+		args = append(args, "--"+namespaceOpt, mta.Namespace)
+	}
+	if mta.Strategy != "" {
+		args = append(args, "--"+strategyOpt, mta.Strategy)
+	}
+	return c.parseDeployFlags(args)
+}
+
+// getReadyMtas returns the MTAs which have not been started yet and whose dependencies have all completed
+func getReadyMtas(manifest util.ReleaseManifest, results map[string]*releaseMtaResult) []util.ReleaseMta {
+	var readyMtas []util.ReleaseMta
+	for _, mta := range manifest.MTAs {
+		if _, started := results[mta.Name]; started {
+			continue
+		}
+		isReady := true
+		for _, dependency := range mta.DependsOn {
+			if results[dependency] == nil {
+				isReady = false
+				break
+			}
+		}
+		if isReady {
+			readyMtas = append(readyMtas, mta)
+		}
+	}
+	return readyMtas
+}
+
+func getFailedDependency(mta util.ReleaseMta, results map[string]*releaseMtaResult) string {
+	for _, dependency := range mta.DependsOn {
+		if results[dependency].status != deploymentSucceeded {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// deployReleaseMta deploys a single MTA of the release through the pipeline of the deploy command and waits for the
+// deploy operation to complete
+func (c *MtaReleaseCommand) deployReleaseMta(mta util.ReleaseMta, deployFlags *flag.FlagSet, force bool, dsHost string, cfTarget util.CloudFoundryTarget) *releaseMtaResult {
+	result := &releaseMtaResult{name: mta.Name, status: deploymentFailed}
+	startTime := time.Now()
+	defer func() {
+		result.duration = time.Since(startTime)
+	}()
+
+	ui.Say("Starting deployment of multi-target app %s...", terminal.EntityNameColor(mta.Name))
+	mtaElementsCalculator := mtaElementsToAddCalculator{shouldAddAllModules: true, shouldAddAllResources: true}
+	rawMtaArchive, err := c.getMtaArchive([]string{mta.Archive}, mtaElementsCalculator)
+	if err != nil {
+		ui.Failed("Error retrieving MTA %s: %s", terminal.EntityNameColor(mta.Name), err.Error())
+		return result
+	}
+
+	mtaClient := c.NewMtaClient(dsHost, cfTarget)
+	_, operationLocation, status := c.deployMtaArchive(rawMtaArchive, mtaElementsCalculator, force, mtaClient, dsHost, deployFlags, cfTarget)
+	if status == Failure {
+		return result
+	}
+	result.operationID, _ = getMonitoringInformation(operationLocation)
+
+	deployCommandName := NewDeployCommand().GetPluginCommand().Name
+	historyContext := c.getDeploymentHistoryContext(rawMtaArchive, deployFlags, cfTarget)
+	result.status = monitorDeployment(deployCommandName, operationLocation, GetUintOpt(retriesOpt, deployFlags), mtaClient, historyContext, c.span)
+	return result
+}
+
+func reportReleaseSummary(manifest util.ReleaseManifest, results map[string]*releaseMtaResult) ExecutionStatus {
+	status := Success
+	ui.Say("\nRelease summary:")
+	table := ui.Table([]string{"mta", "status", "operation id", "duration"})
+	for _, mta := range manifest.MTAs {
+		result := results[mta.Name]
+		if result.status != deploymentSucceeded {
+			status = Failure
+		}
+		operationID, duration := "-", "-"
+		if result.operationID != "" {
+			operationID = result.operationID
+		}
+		if result.status != deploymentSkipped {
+			duration = result.duration.Round(time.Second).String()
+		}
+		table.Add(mta.Name, result.status, operationID, duration)
+	}
+	table.Print()
+
+	downloadProcessLogsCommand := DownloadMtaOperationLogsCommand{}
+	for _, mta := range manifest.MTAs {
+		if operationID := results[mta.Name].operationID; operationID != "" {
+			commandBuilder := util.NewCfCommandStringBuilder()
+			commandBuilder.SetName(downloadProcessLogsCommand.GetPluginCommand().Alias)
+			commandBuilder.AddOption(operationIDOpt, operationID)
+			ui.Say("Use \"%s\" to download the logs of the process of %s.", commandBuilder.Build(), mta.Name)
+		}
+	}
+	return status
+}
@@ -0,0 +1,170 @@
+package commands_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
+	cli_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
+	mtafake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/testutil"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	util_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/util/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MtaReleaseCommand", func() {
+	Describe("Execute", func() {
+		const mtaArchivePath = "../test_resources/commands/mtaArchive.mtar"
+
+		var name string
+		var cliConnection *plugin_fakes.FakeCliConnection
+		var mtaClient *mtafake.FakeMtaClientOperations
+		var command *commands.MtaReleaseCommand
+		var manifestDirectory string
+		var manifestLocation string
+		var oc = testutil.NewUIOutputCapturer()
+		var ex = testutil.NewUIExpector()
+
+		var fullMtaArchivePath, _ = filepath.Abs(mtaArchivePath)
+
+		var writeManifest = func(content string) {
+			os.WriteFile(manifestLocation, []byte(strings.ReplaceAll(content, "ARCHIVE", fullMtaArchivePath)), os.ModePerm)
+		}
+
+		var trimOutput = func(output []string) []string {
+			result := make([]string, 0, len(output))
+			for _, line := range output {
+				result = append(result, strings.TrimSpace(line))
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			ui.DisableTerminalOutput(true)
+			command = commands.NewMtaReleaseCommand()
+			name = command.GetPluginCommand().Name
+			cliConnection = cli_fakes.NewFakeCliConnectionBuilder().
+				CurrentOrg("test-org-guid", "test-org", nil).
+				CurrentSpace("test-space-guid", "test-space", nil).
+				Username("test-user", nil).
+				AccessToken("bearer test-token", nil).Build()
+			mtaArchiveFile, _ := os.Open(mtaArchivePath)
+			defer mtaArchiveFile.Close()
+			digest, _ := util.ComputeFileChecksum(mtaArchivePath, "MD5")
+			mtaArchive := testutil.GetFile(mtaArchiveFile, strings.ToUpper(digest), "")
+			mtaClient = mtafake.NewFakeMtaClientBuilder().
+				GetMtaFiles([]*models.FileMetadata{&testutil.SimpleFile}, nil).
+				UploadMtaFile(mtaArchiveFile, mtaArchive, nil).
+				StartMtaOperation(testutil.OperationResult, mtaclient.ResponseHeader{Location: "operations/1000?embed=messages"}, nil).
+				GetMtaOperation("1000", "messages", &testutil.OperationResult, nil).
+				GetMtaOperations(nil, nil, nil, []*models.Operation{&testutil.OperationResult}, nil).Build()
+			testClientFactory := commands.NewTestClientFactory(mtaClient, nil, nil)
+			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
+			deployServiceURLCalculator := util_fakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
+			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+
+			manifestDirectory, _ = os.MkdirTemp("", "mta-release")
+			manifestLocation = filepath.Join(manifestDirectory, "release.yaml")
+		})
+
+		Context("with a missing release manifest argument", func() {
+			It("should print incorrect usage, call cf help, and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"deploy"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Incorrect usage. Missing positional argument \"RELEASE_MANIFEST\"")
+				Expect(cliConnection.CliCommandArgsForCall(0)).To(Equal([]string{"help", name}))
+			})
+		})
+
+		Context("with an unsupported action", func() {
+			It("should print an error and exit with a non-zero status", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"publish", manifestLocation}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Invalid action publish, supported actions: deploy")
+			})
+		})
+
+		Context("with an invalid deployment strategy", func() {
+			It("should print an error and not deploy anything", func() {
+				writeManifest("mtas:\n- name: backend\n  archive: ARCHIVE\n  strategy: canary\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"deploy", manifestLocation}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Invalid deployment options for multi-target app backend: canary is not a valid deployment strategy")
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with dependent MTAs which are deployed successfully", func() {
+			It("should deploy all MTAs and print a summary", func() {
+				writeManifest("mtas:\n- name: backend\n  archive: ARCHIVE\n- name: ui\n  archive: ARCHIVE\n  depends-on: [backend]\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"deploy", manifestLocation}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(2))
+				Expect(trimOutput(output)).To(ContainElements(
+					"Release summary:",
+					"Use \"cf dmol -i 1000\" to download the logs of the process of backend.",
+					"Use \"cf dmol -i 1000\" to download the logs of the process of ui.",
+				))
+			})
+		})
+
+		Context("with an MTA which fails to deploy", func() {
+			It("should skip the MTAs which depend on it and exit with a non-zero status", func() {
+				writeManifest("mtas:\n- name: backend\n  archive: missing.mtar\n- name: ui\n  archive: ARCHIVE\n  depends-on: [backend]\n- name: db\n  archive: ARCHIVE\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"deploy", manifestLocation}).ToInt()
+				})
+				Expect(status).To(Equal(1))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+				Expect(trimOutput(output)).To(ContainElements(
+					"Skipping multi-target app ui, because backend was not deployed successfully",
+					"Use \"cf dmol -i 1000\" to download the logs of the process of db.",
+				))
+				Expect(trimOutput(output)).NotTo(ContainElement(ContainSubstring("logs of the process of ui")))
+			})
+		})
+
+		Context("with an ongoing operation of a deployed MTA", func() {
+			It("should not ask for confirmation and not deploy the MTA without the force option", func() {
+				ongoingOperation := models.Operation{ProcessID: "999", ProcessType: "DEPLOY", MtaID: "test", SpaceID: "test-space-guid", AcquiredLock: true, State: models.StateRUNNING}
+				mtaClient.GetMtaOperationsReturns([]*models.Operation{&ongoingOperation}, nil)
+				writeManifest("mtas:\n- name: backend\n  archive: ARCHIVE\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"deploy", manifestLocation}).ToInt()
+				})
+				Expect(status).To(Equal(1))
+				Expect(mtaClient.ExecuteActionCallCount()).To(Equal(0))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+				Expect(trimOutput(output)).To(ContainElement("There is an ongoing operation for multi-target app test. Use the -f option to abort it."))
+			})
+
+			It("should abort the ongoing operation with the force option", func() {
+				ongoingOperation := models.Operation{ProcessID: "999", ProcessType: "DEPLOY", MtaID: "test", SpaceID: "test-space-guid", AcquiredLock: true, State: models.StateRUNNING}
+				mtaClient.GetMtaOperationsReturns([]*models.Operation{&ongoingOperation}, nil)
+				writeManifest("mtas:\n- name: backend\n  archive: ARCHIVE\n")
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"deploy", manifestLocation, "-f"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(mtaClient.ExecuteActionCallCount()).To(Equal(1))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(1))
+			})
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(manifestDirectory)
+		})
+	})
+})
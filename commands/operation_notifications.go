@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"flag"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const notifyWebhookOpt = "notify-webhook"
+
+// operationObservers are notified about the operations which a command monitors, in addition to the output of the
+// command. Observers which are not configured are nil.
+type operationObservers struct {
+	report   *operationsReport
+	notifier *util.WebhookNotifier
+}
+
+// newOperationObservers creates the observers configured through the --report and --notify-webhook options
+func newOperationObservers(commandName string, flags *flag.FlagSet) (operationObservers, error) {
+	var observers operationObservers
+	var err error
+	if observers.report, err = newOperationsReport(commandName, GetStringOpt(reportOpt, flags)); err != nil {
+		return operationObservers{}, err
+	}
+	if webhookURL := GetStringOpt(notifyWebhookOpt, flags); webhookURL != "" {
+		if observers.notifier, err = util.NewWebhookNotifier(webhookURL); err != nil {
+			return operationObservers{}, err
+		}
+	}
+	return observers, nil
+}
+
+// notifyStateChange sends the event which corresponds to the state of the operation to the webhook, unless the event
+// was already sent. Failures are only reported as warnings, as they do not affect the operation.
+func (m *ExecutionMonitor) notifyStateChange(operation *models.Operation) {
+	if m.observers.notifier == nil {
+		return
+	}
+	var event string
+	switch operation.State {
+	case models.StateRUNNING:
+		event = util.WebhookEventStarted
+	case models.StateACTIONREQUIRED:
+		event = util.WebhookEventActionRequired
+	case models.StateFINISHED:
+		event = util.WebhookEventFinished
+	case models.StateERROR, models.StateABORTED:
+		event = util.WebhookEventFailed
+	default:
+		return
+	}
+	// An operation which completes before it is first polled has still started
+	if m.notifiedEvent == "" && event != util.WebhookEventStarted {
+		m.notify(util.WebhookEventStarted, operation)
+	}
+	if event != m.notifiedEvent {
+		m.notify(event, operation)
+	}
+}
+
+func (m *ExecutionMonitor) notify(event string, operation *models.Operation) {
+	m.notifiedEvent = event
+	payload := util.WebhookPayload{
+		Event:       event,
+		Timestamp:   time.Now().UTC(),
+		Org:         m.historyContext.org,
+		Space:       m.historyContext.space,
+		MtaID:       operation.MtaID,
+		MtaVersion:  m.historyContext.mtaVersion,
+		Namespace:   operation.Namespace,
+		ProcessID:   m.operationID,
+		ProcessType: operation.ProcessType,
+		State:       string(operation.State),
+		LogsHint:    getDownloadLogsCommand(m.operationID),
+	}
+	if event == util.WebhookEventStarted {
+		payload.State = string(models.StateRUNNING)
+	}
+	if failure := m.getOperationFailure(operation); event == util.WebhookEventFailed && failure != nil {
+		payload.Message = failure.Message
+	}
+	if err := m.observers.notifier.Notify(payload); err != nil {
+		ui.Warn("Could not send the %s notification of operation %s to the webhook: %s", event, m.operationID, err)
+	}
+}
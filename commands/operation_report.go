@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	reportOpt = "report"
+
+	junitReportFormat = "junit"
+	tapReportFormat   = "tap"
+)
+
+// The name of the app to which an operation message refers. The apps are named after the modules from which they are
+// deployed.
+var messageAppNamePattern = regexp.MustCompile(`(?i)\bapplications? "([^"]+)"`)
+
+// operationsReport collects the results of the operations which a command monitors, to write them as a JUnit XML or a
+// TAP report for CI servers. A nil report collects nothing.
+type operationsReport struct {
+	commandName string
+	format      string
+	location    string
+	suites      []util.JUnitTestSuite
+}
+
+// newOperationsReport parses the value of the --report option, which is junit=<path> or tap=<path>. It returns nil if
+// the option is not set.
+func newOperationsReport(commandName, value string) (*operationsReport, error) {
+	if value == "" {
+		return nil, nil
+	}
+	format, location, found := strings.Cut(value, "=")
+	if !found || location == "" || (format != junitReportFormat && format != tapReportFormat) {
+		return nil, fmt.Errorf("Invalid report %q, expected junit=<path> or tap=<path>", value)
+	}
+	return &operationsReport{commandName: commandName, format: format, location: location}, nil
+}
+
+// write writes the collected results to the report file. If the command failed without a failed operation, e.g.
+// because the archive could not be uploaded, the failure of the command is reported instead.
+func (r *operationsReport) write(status ExecutionStatus) {
+	if r == nil {
+		return
+	}
+	suites := r.suites
+	if status == Failure && !hasFailures(suites) {
+		failure := &util.JUnitFailure{Message: fmt.Sprintf("The %s command failed, see its output for details", r.commandName)}
+		suites = append(suites, util.NewJUnitTestSuite(r.commandName, time.Time{}, []util.JUnitTestCase{{Name: r.commandName, ClassName: r.commandName, Failure: failure}}))
+	}
+	var err error
+	if r.format == tapReportFormat {
+		err = util.WriteTAPReport(r.location, suites)
+	} else {
+		err = util.WriteJUnitReport(r.location, suites)
+	}
+	if err != nil {
+		ui.Warn("Could not write the report to %s: %s", terminal.EntityNameColor(r.location), err)
+	}
+}
+
+// addOperation adds a test suite with the upload, the phases and the modules of the operation. The failure
+// of a failed or aborted operation is reported in the phase in which it failed and in the modules which it names.
+func (r *operationsReport) addOperation(m *ExecutionMonitor, operation *models.Operation) {
+	if r == nil {
+		return
+	}
+	endTime := time.Now()
+	messages := m.timedMessages
+	var cases []util.JUnitTestCase
+	if len(messages) != 0 && messages[0].text == "" {
+		uploadEndTime := endTime
+		if len(messages) > 1 {
+			uploadEndTime = messages[1].reportedAt
+		}
+		cases = append(cases, util.JUnitTestCase{Name: phaseUpload, ClassName: operation.MtaID, Time: util.JUnitSeconds(uploadEndTime.Sub(messages[0].reportedAt))})
+		messages = messages[1:]
+	}
+
+	failure := m.getOperationFailure(operation)
+	failureReported := false
+	for _, timing := range getPhaseTimings(messages, endTime) {
+		testCase := util.JUnitTestCase{Name: "phase " + timing.name, ClassName: operation.MtaID, Time: util.JUnitSeconds(timing.duration)}
+		if failure != nil && timing.name == messages[len(messages)-1].phase {
+			testCase.Failure = failure
+			failureReported = true
+		}
+		cases = append(cases, testCase)
+	}
+	for _, timing := range getModuleTimings(messages, endTime) {
+		testCase := util.JUnitTestCase{Name: "module " + timing.name, ClassName: operation.MtaID, Time: util.JUnitSeconds(timing.duration)}
+		if failure != nil && strings.Contains(failure.Message, `"`+timing.name+`"`) {
+			testCase.Failure = failure
+			failureReported = true
+		}
+		cases = append(cases, testCase)
+	}
+	if failure != nil && !failureReported {
+		cases = append(cases, util.JUnitTestCase{Name: "operation", ClassName: operation.MtaID, Failure: failure})
+	}
+	suite := util.NewJUnitTestSuite(m.getReportSuiteName(), m.startTime, cases)
+	// A resumed operation replaces the suite which was added when it required an action
+	for i := range r.suites {
+		if r.suites[i].Name == suite.Name {
+			r.suites[i] = suite
+			return
+		}
+	}
+	r.suites = append(r.suites, suite)
+}
+
+// getOperationFailure returns the failure of a failed or aborted operation, or nil if the operation did not fail
+func (m *ExecutionMonitor) getOperationFailure(operation *models.Operation) *util.JUnitFailure {
+	switch operation.State {
+	case models.StateERROR:
+		failure := &util.JUnitFailure{Message: "Process failed", Type: string(operation.ErrorType)}
+		if message := findErrorMessage(operation.Messages); message != nil {
+			failure.Message = m.redactor.Redact(message.Text)
+			failure.Text = failure.Message
+		}
+		return failure
+	case models.StateABORTED:
+		return &util.JUnitFailure{Message: "Process was aborted"}
+	}
+	return nil
+}
+
+func (m *ExecutionMonitor) getReportSuiteName() string {
+	return fmt.Sprintf("%s %s", m.commandName, m.operationID)
+}
+
+// getModuleTimings returns the time from the first to the last message about each module, in the order in which the
+// modules are first mentioned
+func getModuleTimings(messages []timedMessage, endTime time.Time) []stepTiming {
+	var timings []stepTiming
+	firstTimes := make(map[string]time.Time)
+	positions := make(map[string]int)
+	for i, message := range messages {
+		match := messageAppNamePattern.FindStringSubmatch(message.text)
+		if match == nil {
+			continue
+		}
+		nextTime := endTime
+		if i+1 < len(messages) {
+			nextTime = messages[i+1].reportedAt
+		}
+		name := match[1]
+		position, exists := positions[name]
+		if !exists {
+			position = len(timings)
+			positions[name] = position
+			firstTimes[name] = message.reportedAt
+			timings = append(timings, stepTiming{name: name})
+		}
+		timings[position].duration = nextTime.Sub(firstTimes[name])
+	}
+	return timings
+}
+
+func hasFailures(suites []util.JUnitTestSuite) bool {
+	for _, suite := range suites {
+		if suite.Failures != 0 {
+			return true
+		}
+	}
+	return false
+}
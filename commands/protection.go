@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	overrideProtectionOpt = "override-protection"
+
+	// An MTA is protected when any of its apps has this annotation set to "true"
+	protectedAnnotation = "mta_protected"
+)
+
+// ensureProtectionOverridden checks whether the MTA is protected. A destructive operation on a protected MTA is only
+// allowed with the override-protection option and after the user has typed the ID of the MTA into the prompt. Commands
+// which run several operations in parallel pass no prompt, so that the option alone overrides the protection.
+func ensureProtectionOverridden(mtaID, namespace, operation string, cfClient cfrestclient.CloudFoundryOperationsExtended,
+	prompt *ui.Prompt, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) bool {
+	protected, err := isMtaProtected(mtaID, namespace, cfClient, cfTarget)
+	if err != nil {
+		ui.Failed(err.Error())
+		return false
+	}
+	if !protected {
+		return true
+	}
+
+	if !GetBoolOpt(overrideProtectionOpt, flags) {
+		ui.Failed("Multi-target app %s is protected and cannot be the target of %s. Use the %q option to override the protection.",
+			terminal.EntityNameColor(mtaID), operation, "--"+overrideProtectionOpt)
+		return false
+	}
+	if prompt == nil {
+		return true
+	}
+	answer := prompt.Ask("Multi-target app %s is protected. Type its ID to confirm %s", terminal.EntityNameColor(mtaID), operation)
+	if strings.TrimSpace(answer) != mtaID {
+		ui.Failed("The typed ID does not match multi-target app %s, the operation was cancelled", terminal.EntityNameColor(mtaID))
+		return false
+	}
+	return true
+}
+
+// isMtaProtected checks whether the MTA is protected through the local protection policy or through the annotations
+// of its apps
+func isMtaProtected(mtaID, namespace string, cfClient cfrestclient.CloudFoundryOperationsExtended, cfTarget util.CloudFoundryTarget) (bool, error) {
+	policy, err := util.GetProtectionPolicy()
+	if err != nil {
+		return false, err
+	}
+	if policy.IsProtected(mtaID, namespace) {
+		return true, nil
+	}
+	apps, err := cfClient.GetApplications(mtaID, namespace, cfTarget.Space.Guid)
+	if err != nil {
+		return false, fmt.Errorf("Could not check whether multi-target app %s is protected: %s", terminal.EntityNameColor(mtaID), err)
+	}
+	for _, app := range apps {
+		if strings.EqualFold(app.Annotations[protectedAnnotation], "true") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/secure_parameters"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+)
+
+const redactionWarning = "Some secrets might not be redacted: %s"
+
+// newRedactor creates the redactor of the printed operation messages and the downloaded logs. The redaction rules
+// which cannot be loaded are reported and the rest are still applied.
+func newRedactor() *secure_parameters.Redactor {
+	redactor, err := secure_parameters.NewRedactorFromEnv()
+	if err != nil {
+		ui.Warn(redactionWarning, err)
+	}
+	return redactor
+}
+
+// newJsonOutputRedactor creates the redactor of commands which print JSON. The redaction rules which cannot be loaded
+// are reported on the standard error, so that the standard output remains valid JSON.
+func newJsonOutputRedactor() *secure_parameters.Redactor {
+	redactor, err := secure_parameters.NewRedactorFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, redactionWarning+"\n", err)
+	}
+	return redactor
+}
@@ -2,10 +2,12 @@ package commands_test
 
 import (
 	"fmt"
+	"strings"
 
 	pluginFakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
 	cliFakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	cf_client_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
 	mtaFake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
@@ -19,6 +21,16 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// namespacedAppsClient returns the apps of the requested namespace only
+type namespacedAppsClient struct {
+	cf_client_fakes.FakeCloudFoundryClient
+	appsByNamespace map[string][]models.CloudFoundryApplication
+}
+
+func (c namespacedAppsClient) GetApplications(mtaId, mtaNamespace, spaceGuid string) ([]models.CloudFoundryApplication, error) {
+	return c.appsByNamespace[mtaNamespace], nil
+}
+
 var _ = Describe("RollbackMtaCommand", func() {
 	const org = "test-org"
 	const space = "test-space"
@@ -55,6 +67,17 @@ var _ = Describe("RollbackMtaCommand", func() {
 
 	var ongoingOperations = []*models.Operation{&ongoingOperation}
 
+	var deployedMta = &models.Mta{
+		Metadata: &models.Metadata{ID: mtaID, Version: "2.0.0"},
+		Services: []string{"db"},
+	}
+	var liveApps = []models.CloudFoundryApplication{{Name: "backend", Guid: "backend-guid", State: "STARTED"}}
+	var backupClient = namespacedAppsClient{appsByNamespace: map[string][]models.CloudFoundryApplication{
+		"": liveApps,
+		"mta-backup": {{Name: "mta-backup-backend", Guid: "backup-guid", State: "STOPPED",
+			Annotations: map[string]string{"mta_version": "1.0.0"}}},
+	}}
+
 	var getOutputLines = func(processID string, abortedProcessId string) []string {
 		lines := []string{}
 		lines = append(lines,
@@ -91,6 +114,7 @@ var _ = Describe("RollbackMtaCommand", func() {
 		testTokenFactory := commands.NewTestTokenFactory(cliConnection)
 		deployServiceURLCalculator := utilFakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
 		command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+		command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{}
 	})
 
 	Describe("GetPluginCommand", func() {
@@ -217,6 +241,96 @@ var _ = Describe("RollbackMtaCommand", func() {
 			})
 		})
 
+		Context("with the list option", func() {
+			BeforeEach(func() {
+				testClientFactory.MtaV2Client = mtaV2Fake.NewFakeMtaV2ClientBuilder().
+					GetMtasForThisSpace(mtaID, nil, []*models.Mta{deployedMta}, nil).Build()
+			})
+
+			It("should list the backup apps and their versions", func() {
+				command.CfClient = backupClient
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--list"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElements(
+					"Live version: 2.0.0",
+					"Backup version: 1.0.0",
+				))
+				Expect(strings.Join(output, "\n")).To(MatchRegexp(`mta-backup-backend\s+1\.0\.0\s+STOPPED\s+backend`))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should report that there is no backup", func() {
+				command.CfClient = namespacedAppsClient{appsByNamespace: map[string][]models.CloudFoundryApplication{"": liveApps}}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--list"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElement("No backup of multi-target app test found. A backup is created by a blue-green deployment with the \"--backup-previous-version\" option."))
+			})
+		})
+
+		Context("with the dry-run option", func() {
+			BeforeEach(func() {
+				testClientFactory.MtaV2Client = mtaV2Fake.NewFakeMtaV2ClientBuilder().
+					GetMtasForThisSpace(mtaID, nil, []*models.Mta{deployedMta}, nil).Build()
+			})
+
+			It("should print the apps which would be swapped without rolling back", func() {
+				command.CfClient = backupClient
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--dry-run", "--delete-services"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(output).To(ContainElements(
+					"Rolling back multi-target app test from version 2.0.0 to version 1.0.0 would swap the following apps:",
+					"The following services would be bound to the backup apps:",
+					"  db",
+					"Services which are not part of the backup version would be deleted.",
+					"This is a dry run, the multi-target app was not rolled back.",
+				))
+				Expect(strings.Join(output, "\n")).To(MatchRegexp(`backend\s+mta-backup-backend`))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should fail when there is no backup", func() {
+				command.CfClient = namespacedAppsClient{appsByNamespace: map[string][]models.CloudFoundryApplication{"": liveApps}}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--dry-run"}).ToInt()
+				})
+				ex.ExpectFailureOnLine(status, output, "No backup of multi-target app test found, so it cannot be rolled back", 3)
+			})
+		})
+
+		Context("without the force option", func() {
+			It("should cancel the rollback when it is not confirmed", func() {
+				testClientFactory.MtaV2Client = mtaV2Fake.NewFakeMtaV2ClientBuilder().
+					GetMtasForThisSpace(mtaID, nil, []*models.Mta{deployedMta}, nil).Build()
+				command.CfClient = backupClient
+				command.Input = strings.NewReader("n\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID}).ToInt()
+				})
+				ex.ExpectNonZeroStatus(status)
+				Expect(output).To(ContainElement("Rollback mta cancelled"))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with an mta protected through an annotation", func() {
+			It("should refuse to roll back without the override-protection option", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", Annotations: map[string]string{"mta_protected": "true"}}},
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "-f"}).ToInt()
+				})
+				ex.ExpectFailureOnLine(status, output, "Multi-target app test is protected and cannot be the target of a rollback. Use the \"--override-protection\" option to override the protection.", 2)
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("with a correct mta id provided but fail to start operation", func() {
 			It("should exit with non-zero status", func() {
 				testClientFactory.MtaClient = mtaFake.NewFakeMtaClientBuilder().
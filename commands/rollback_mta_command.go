@@ -9,6 +9,8 @@ import (
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
 	"code.cloudfoundry.org/cli/v8/plugin"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/resilient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
@@ -20,15 +22,23 @@ const processUserProvidedServicesOpt = "process-user-provided-services"
 type RollbackMtaCommand struct {
 	*BaseCommand
 	processTypeProvider ProcessTypeProvider
+
+	CfClient cfrestclient.CloudFoundryOperationsExtended
 }
 
 func NewRollbackMtaCommand() *RollbackMtaCommand {
 	baseCmd := &BaseCommand{flagsParser: NewProcessActionExecutorCommandArgumentsParser([]string{"MTA_ID"}), flagsValidator: NewDefaultCommandFlagsValidator(nil)}
-	rollbackMtaCmd := &RollbackMtaCommand{baseCmd, &rollbackMtaCommandProcessTypeProvider{}}
+	rollbackMtaCmd := &RollbackMtaCommand{baseCmd, &rollbackMtaCommandProcessTypeProvider{}, nil}
 	baseCmd.Command = rollbackMtaCmd
 	return rollbackMtaCmd
 }
 
+func (c *RollbackMtaCommand) Initialize(name string, cliConnection plugin.CliConnection) {
+	c.BaseCommand.Initialize(name, cliConnection)
+	delegate := cfrestclient.NewCloudFoundryRestClient(cliConnection)
+	c.CfClient = resilient.NewResilientCloudFoundryClient(delegate, maxRetriesCount, retryIntervalInSeconds)
+}
+
 // GetPluginCommand returns more information for the blue green deploy command.
 func (c *RollbackMtaCommand) GetPluginCommand() plugin.Command {
 	return plugin.Command{
@@ -36,7 +46,11 @@ func (c *RollbackMtaCommand) GetPluginCommand() plugin.Command {
 		HelpText: "(EXPERIMENTAL) Rollback of a multi-target app works only if [--backup-previous-version] flag was used during blue-green deployment and backup applications exists in the space",
 		UsageDetails: plugin.Usage{
 			Usage: `Rollback of a multi-target app
-   cf rollback-mta MTA_ID [-t TIMEOUT] [-f] [--retries RETRIES] [--namespace NAMESPACE] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT]
+   cf rollback-mta MTA_ID [-t TIMEOUT] [-f] [--retries RETRIES] [--namespace NAMESPACE] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--apps-start-timeout TIMEOUT] [--apps-stage-timeout TIMEOUT] [--apps-upload-timeout TIMEOUT] [--apps-task-execution-timeout TIMEOUT] [--override-protection] [--report junit=PATH|tap=PATH] [--notify-webhook URL]
+
+   List the backup apps which become live on rollback, or preview the rollback
+   cf rollback-mta MTA_ID --list [--namespace NAMESPACE]
+   cf rollback-mta MTA_ID --dry-run [--namespace NAMESPACE] [--delete-services]
 
    Perform action on an active deploy operation
    cf rollback-mta -i OPERATION_ID -a ACTION [-u URL]` + util.BaseEnvHelpText,
@@ -51,6 +65,11 @@ func (c *RollbackMtaCommand) GetPluginCommand() plugin.Command {
 				util.GetShortOption(abortOnErrorOpt):                            "Auto-abort the process on any errors",
 				util.GetShortOption(processUserProvidedServicesOpt):             "Enable processing of user provided services during rollback",
 				util.GetShortOption(retriesOpt):                                 "Retry the operation N times in case a non-content error occurs (default 3)",
+				util.GetShortOption(reportOpt):                                  "Write the results of the operations to a JUnit XML or TAP report, e.g. junit=report.xml or tap=report.tap",
+				util.GetShortOption(notifyWebhookOpt):                           "Notify the webhook at URL when the operation starts, requires an action, finishes or fails",
+				util.GetShortOption(listOpt):                                    "List the backup apps and their versions which become live on rollback",
+				util.GetShortOption(overrideProtectionOpt):                      "Allow rolling back a protected multi-target app after typing its ID",
+				util.GetShortOption(dryRunOpt):                                  "Print the apps which would be swapped and the services which would be touched, without rolling back",
 				util.GetShortOption(stageTimeoutOpt):                            "Stage app timeout in seconds",
 				util.GetShortOption(uploadTimeoutOpt):                           "Upload app timeout in seconds",
 				util.GetShortOption(taskExecutionTimeoutOpt):                    "Task execution timeout in seconds",
@@ -70,26 +89,46 @@ func (c *RollbackMtaCommand) defineCommandOptions(flags *flag.FlagSet) {
 	flags.Bool(abortOnErrorOpt, false, "")
 	flags.Bool(processUserProvidedServicesOpt, false, "")
 	flags.Uint(retriesOpt, 3, "")
+	flags.String(reportOpt, "", "")
+	flags.String(notifyWebhookOpt, "", "")
 	flags.String(startTimeoutOpt, "", "")
 	flags.String(stageTimeoutOpt, "", "")
 	flags.String(uploadTimeoutOpt, "", "")
 	flags.String(taskExecutionTimeoutOpt, "", "")
+	flags.Bool(listOpt, false, "")
+	flags.Bool(dryRunOpt, false, "")
+	flags.Bool(overrideProtectionOpt, false, "")
 }
 
 func (c *RollbackMtaCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	observers, err := newOperationObservers(c.name, flags)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	status := c.executeRollback(positionalArgs, dsHost, flags, cfTarget, observers)
+	observers.report.write(status)
+	return status
+}
+
+func (c *RollbackMtaCommand) executeRollback(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget, observers operationObservers) ExecutionStatus {
 	operationID := GetStringOpt(operationIDOpt, flags)
 	actionID := GetStringOpt(actionOpt, flags)
 	retries := GetUintOpt(retriesOpt, flags)
 
 	if operationID != "" || actionID != "" {
-		return c.ExecuteAction(operationID, actionID, retries, dsHost, cfTarget)
+		return c.executeAction(operationID, actionID, retries, dsHost, cfTarget, newDeploymentHistoryContext(flags, cfTarget), observers)
 	}
 
 	force := GetBoolOpt(forceOpt, flags)
 	mtaID := positionalArgs[0]
-	if !force && !ui.Confirm("Really rollback multi-target app %s in org %s / space %s? (y/n)", terminal.EntityNameColor(mtaID),
-		terminal.EntityNameColor(cfTarget.Org.Name),
-		terminal.EntityNameColor(cfTarget.Space.Name)) {
+	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
+
+	if GetBoolOpt(listOpt, flags) || GetBoolOpt(dryRunOpt, flags) {
+		return c.executePreview(mtaID, namespace, flags, dsHost, cfTarget)
+	}
+
+	if !force && !c.confirmRollback(mtaID, namespace, dsHost, cfTarget) {
 		ui.Warn("Rollback mta cancelled")
 		return Failure
 	}
@@ -101,24 +140,13 @@ func (c *RollbackMtaCommand) executeInternal(positionalArgs []string, dsHost str
 
 	// Create rest client
 	mtaClient := c.NewMtaClient(dsHost, cfTarget)
-	// Create new REST client for mtas V2 api
-	mtaV2Client := c.NewMtaV2Client(dsHost, cfTarget)
-
-	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
 
 	// Check if a deployed MTA with the specified ID exists
-	_, err := mtaV2Client.GetMtasForThisSpace(&mtaID, &namespace)
-	if err != nil {
-		ce, ok := err.(*baseclient.ClientError)
-		if ok && ce.Code == 404 && strings.Contains(fmt.Sprint(ce.Description), mtaID) {
-			if util.DiscardIfEmpty(namespace) != nil {
-				ui.Failed("Multi-target app %s with namespace %s not found", terminal.EntityNameColor(mtaID), terminal.EntityNameColor(namespace))
-			} else {
-				ui.Failed("Multi-target app %s not found", terminal.EntityNameColor(mtaID))
-			}
-			return Failure
-		}
-		ui.Failed("Could not get multi-target app %s: %s", terminal.EntityNameColor(mtaID), baseclient.NewClientError(err))
+	if _, status := c.getDeployedMta(mtaID, namespace, dsHost, cfTarget); status == Failure {
+		return Failure
+	}
+
+	if !ensureProtectionOverridden(mtaID, namespace, "a rollback", c.CfClient, c.getPrompt(), flags, cfTarget) {
 		return Failure
 	}
 
@@ -152,10 +180,83 @@ func (c *RollbackMtaCommand) executeInternal(positionalArgs []string, dsHost str
 		return Failure
 	}
 
-	executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, responseHeader.Location.String(), retries, []*models.Message{}, mtaClient)
+	executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, responseHeader.Location.String(), retries, []*models.Message{}, mtaClient).
+		withHistoryContext(newDeploymentHistoryContext(flags, cfTarget)).
+		withObservers(observers).
+		WithParentSpan(c.span)
 	return executionMonitor.Monitor()
 }
 
+// getDeployedMta returns the deployed MTA with the specified ID, or nil if the deploy service returned no MTA
+func (c *RollbackMtaCommand) getDeployedMta(mtaID, namespace, dsHost string, cfTarget util.CloudFoundryTarget) (*models.Mta, ExecutionStatus) {
+	// Create new REST client for mtas V2 api
+	mtaV2Client := c.NewMtaV2Client(dsHost, cfTarget)
+	mtas, err := mtaV2Client.GetMtasForThisSpace(&mtaID, &namespace)
+	if err != nil {
+		ce, ok := err.(*baseclient.ClientError)
+		if ok && ce.Code == 404 && strings.Contains(fmt.Sprint(ce.Description), mtaID) {
+			if util.DiscardIfEmpty(namespace) != nil {
+				ui.Failed("Multi-target app %s with namespace %s not found", terminal.EntityNameColor(mtaID), terminal.EntityNameColor(namespace))
+			} else {
+				ui.Failed("Multi-target app %s not found", terminal.EntityNameColor(mtaID))
+			}
+			return nil, Failure
+		}
+		ui.Failed("Could not get multi-target app %s: %s", terminal.EntityNameColor(mtaID), baseclient.NewClientError(err))
+		return nil, Failure
+	}
+	if len(mtas) == 0 {
+		return nil, Success
+	}
+	return mtas[0], Success
+}
+
+// executePreview lists the backup of the MTA or prints what a rollback to it would change
+func (c *RollbackMtaCommand) executePreview(mtaID, namespace string, flags *flag.FlagSet, dsHost string, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	ui.Say("Getting backup of multi-target app %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(mtaID), terminal.EntityNameColor(cfTarget.Org.Name),
+		terminal.EntityNameColor(cfTarget.Space.Name), terminal.EntityNameColor(cfTarget.Username))
+	mta, status := c.getDeployedMta(mtaID, namespace, dsHost, cfTarget)
+	if status == Failure {
+		return Failure
+	}
+	if mta == nil {
+		ui.Failed("Multi-target app %s not found", terminal.EntityNameColor(mtaID))
+		return Failure
+	}
+	backup, err := c.getMtaBackup(mta, cfTarget)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	ui.Ok()
+
+	if GetBoolOpt(listOpt, flags) {
+		return listBackups(backup)
+	}
+	return previewRollback(backup, GetBoolOpt(deleteServicesOpt, flags))
+}
+
+// confirmRollback asks the user to confirm the rollback. The live and the backup version are included in the question
+// when they can be determined.
+func (c *RollbackMtaCommand) confirmRollback(mtaID, namespace, dsHost string, cfTarget util.CloudFoundryTarget) bool {
+	mtas, err := c.NewMtaV2Client(dsHost, cfTarget).GetMtasForThisSpace(&mtaID, &namespace)
+	if err == nil && len(mtas) == 1 {
+		backup, err := c.getMtaBackup(mtas[0], cfTarget)
+		if err == nil && len(backup.swaps) != 0 {
+			return c.getPrompt().Confirm("Really rollback multi-target app %s from version %s to version %s in org %s / space %s? (y/n)",
+				terminal.EntityNameColor(mtaID), util.GetMtaVersionAsString(mtas[0]), backup.backupVersion,
+				terminal.EntityNameColor(cfTarget.Org.Name), terminal.EntityNameColor(cfTarget.Space.Name))
+		}
+		if err == nil {
+			ui.Warn("No backup of multi-target app %s found, the rollback will fail.", mtaID)
+		}
+	}
+	return c.getPrompt().Confirm("Really rollback multi-target app %s in org %s / space %s? (y/n)", terminal.EntityNameColor(mtaID),
+		terminal.EntityNameColor(cfTarget.Org.Name),
+		terminal.EntityNameColor(cfTarget.Space.Name))
+}
+
 type rollbackMtaCommandProcessTypeProvider struct{}
 
 func (rollbackMta rollbackMtaCommandProcessTypeProvider) GetProcessType() string {
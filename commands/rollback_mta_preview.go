@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+const (
+	listOpt   = "list"
+	dryRunOpt = "dry-run"
+
+	// The previous version of an MTA which is deployed with the "backup-previous-version" option is kept in this namespace
+	backupNamespace        = "mta-backup"
+	mtaVersionAnnotation   = "mta_version"
+	unknownMtaVersionLabel = "unknown"
+)
+
+// appSwap describes a backup app which becomes live on rollback and the live app which it replaces
+type appSwap struct {
+	liveApp   string
+	backupApp models.CloudFoundryApplication
+}
+
+// mtaBackup describes the live and the backup version of an MTA
+type mtaBackup struct {
+	mta           *models.Mta
+	backupVersion string
+	swaps         []appSwap
+}
+
+// getMtaBackup finds the backup apps of the MTA in the backup namespace and pairs them with the live apps they replace
+func (c *RollbackMtaCommand) getMtaBackup(mta *models.Mta, cfTarget util.CloudFoundryTarget) (mtaBackup, error) {
+	mtaID, namespace := mta.Metadata.ID, mta.Metadata.Namespace
+	liveApps, err := c.CfClient.GetApplications(mtaID, namespace, cfTarget.Space.Guid)
+	if err != nil {
+		return mtaBackup{}, fmt.Errorf("Could not get apps: %s", err)
+	}
+	backupAppsNamespace := getBackupNamespace(namespace)
+	backupApps, err := c.CfClient.GetApplications(mtaID, backupAppsNamespace, cfTarget.Space.Guid)
+	if err != nil {
+		return mtaBackup{}, fmt.Errorf("Could not get backup apps: %s", err)
+	}
+
+	backup := mtaBackup{mta: mta, backupVersion: unknownMtaVersionLabel}
+	for _, backupApp := range backupApps {
+		liveApp := "-"
+		liveAppName := strings.TrimPrefix(backupApp.Name, backupAppsNamespace+"-")
+		for _, app := range liveApps {
+			if app.Name == liveAppName {
+				liveApp = app.Name
+				break
+			}
+		}
+		if version := backupApp.Annotations[mtaVersionAnnotation]; version != "" {
+			backup.backupVersion = version
+		}
+		backup.swaps = append(backup.swaps, appSwap{liveApp: liveApp, backupApp: backupApp})
+	}
+	return backup, nil
+}
+
+func getBackupNamespace(namespace string) string {
+	if namespace == "" {
+		return backupNamespace
+	}
+	return backupNamespace + "-" + namespace
+}
+
+// listBackups prints the backup apps which would become live on rollback
+func listBackups(backup mtaBackup) ExecutionStatus {
+	mtaID := backup.mta.Metadata.ID
+	if len(backup.swaps) == 0 {
+		ui.Say("No backup of multi-target app %s found. A backup is created by a blue-green deployment with the %q option.",
+			terminal.EntityNameColor(mtaID), "--"+shouldBackupPreviousVersionOpt)
+		return Success
+	}
+	ui.Say("Live version: %s", util.GetMtaVersionAsString(backup.mta))
+	ui.Say("Backup version: %s\n", backup.backupVersion)
+	table := ui.Table([]string{"backup app", "version", "state", "replaces app"})
+	for _, swap := range backup.swaps {
+		version := swap.backupApp.Annotations[mtaVersionAnnotation]
+		if version == "" {
+			version = unknownMtaVersionLabel
+		}
+		table.Add(swap.backupApp.Name, version, swap.backupApp.State, swap.liveApp)
+	}
+	table.Print()
+	return Success
+}
+
+// previewRollback prints the apps which would be swapped and the services which would be touched by the rollback
+func previewRollback(backup mtaBackup, deleteServices bool) ExecutionStatus {
+	mtaID := backup.mta.Metadata.ID
+	if len(backup.swaps) == 0 {
+		ui.Failed("No backup of multi-target app %s found, so it cannot be rolled back", terminal.EntityNameColor(mtaID))
+		return Failure
+	}
+	ui.Say("Rolling back multi-target app %s from version %s to version %s would swap the following apps:\n",
+		terminal.EntityNameColor(mtaID), util.GetMtaVersionAsString(backup.mta), backup.backupVersion)
+	table := ui.Table([]string{"live app", "backup app"})
+	for _, swap := range backup.swaps {
+		table.Add(swap.liveApp, swap.backupApp.Name)
+	}
+	table.Print()
+
+	if len(backup.mta.Services) != 0 {
+		ui.Say("\nThe following services would be bound to the backup apps:")
+		for _, service := range backup.mta.Services {
+			ui.Say("  %s", service)
+		}
+		if deleteServices {
+			ui.Say("Services which are not part of the backup version would be deleted.")
+		}
+	}
+	ui.Say("\nThis is a dry run, the multi-target app was not rolled back.")
+	return Success
+}
@@ -10,6 +10,8 @@ import (
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
 	"code.cloudfoundry.org/cli/v8/plugin"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/resilient"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
@@ -19,15 +21,23 @@ import (
 type UndeployCommand struct {
 	*BaseCommand
 	processTypeProvider ProcessTypeProvider
+
+	CfClient cfrestclient.CloudFoundryOperationsExtended
 }
 
 func NewUndeployCommand() *UndeployCommand {
 	baseCmd := &BaseCommand{flagsParser: NewProcessActionExecutorCommandArgumentsParser([]string{"MTA_ID"}), flagsValidator: NewDefaultCommandFlagsValidator(nil)}
-	undeployCmd := &UndeployCommand{baseCmd, &undeployCommandProcessTypeProvider{}}
+	undeployCmd := &UndeployCommand{baseCmd, &undeployCommandProcessTypeProvider{}, nil}
 	baseCmd.Command = undeployCmd
 	return undeployCmd
 }
 
+func (c *UndeployCommand) Initialize(name string, cliConnection plugin.CliConnection) {
+	c.BaseCommand.Initialize(name, cliConnection)
+	delegate := cfrestclient.NewCloudFoundryRestClient(cliConnection)
+	c.CfClient = resilient.NewResilientCloudFoundryClient(delegate, maxRetriesCount, retryIntervalInSeconds)
+}
+
 // GetPluginCommand returns the plugin command details
 func (c *UndeployCommand) GetPluginCommand() plugin.Command {
 	return plugin.Command{
@@ -35,7 +45,7 @@ func (c *UndeployCommand) GetPluginCommand() plugin.Command {
 		HelpText: "Undeploy a multi-target app",
 		UsageDetails: plugin.Usage{
 			Usage: `Undeploy a multi-target app
-   cf undeploy MTA_ID [-u URL] [-f] [--retries RETRIES] [--namespace NAMESPACE] [--delete-services] [--delete-service-keys] [--delete-service-brokers] [--no-restart-subscribed-apps] [--do-not-fail-on-missing-permissions] [--abort-on-error]
+   cf undeploy MTA_ID [-u URL] [-f] [--retries RETRIES] [--namespace NAMESPACE] [--delete-services] [--delete-service-keys] [--delete-service-brokers] [--no-restart-subscribed-apps] [--do-not-fail-on-missing-permissions] [--abort-on-error] [--dry-run] [--override-protection] [--report junit=PATH|tap=PATH] [--notify-webhook URL]
 
    Perform action on an active undeploy operation
    cf undeploy -i OPERATION_ID -a ACTION [-u URL]` + util.BaseEnvHelpText,
@@ -44,13 +54,17 @@ func (c *UndeployCommand) GetPluginCommand() plugin.Command {
 				operationIDOpt:                         "Active undeploy operation ID",
 				actionOpt:                              "Action to perform on the active undeploy operation (abort, retry, monitor)",
 				forceOpt:                               "Force undeploy without confirmation",
+				util.GetShortOption(dryRunOpt):         "List the apps, routes, service instances and service keys which would be deleted, without undeploying",
 				util.GetShortOption(deleteServicesOpt): "Delete services",
+				util.GetShortOption(overrideProtectionOpt):         "Allow undeploying a protected multi-target app after typing its ID",
 				util.GetShortOption(deleteServiceKeysOpt):          "Delete existing service keys",
 				util.GetShortOption(deleteServiceBrokersOpt):       "Delete service brokers",
 				util.GetShortOption(noRestartSubscribedAppsOpt):    "Do not restart subscribed apps, updated during the undeployment",
 				util.GetShortOption(noFailOnMissingPermissionsOpt): "Do not fail on missing permissions for admin operations",
 				util.GetShortOption(abortOnErrorOpt):               "Auto-abort the process on any errors",
 				util.GetShortOption(retriesOpt):                    "Retry the operation N times in case a non-content error occurs (default 3)",
+				util.GetShortOption(reportOpt):                     "Write the results of the operations to a JUnit XML or TAP report, e.g. junit=report.xml or tap=report.tap",
+				util.GetShortOption(notifyWebhookOpt):              "Notify the webhook at URL when the operation starts, requires an action, finishes or fails",
 				util.GetShortOption(namespaceOpt):                  "Specify the (optional) namespace the target mta is in",
 			},
 		},
@@ -69,20 +83,53 @@ func (c *UndeployCommand) defineCommandOptions(flags *flag.FlagSet) {
 	flags.Bool(noFailOnMissingPermissionsOpt, false, "")
 	flags.Bool(abortOnErrorOpt, false, "")
 	flags.Uint(retriesOpt, 3, "")
+	flags.String(reportOpt, "", "")
+	flags.String(notifyWebhookOpt, "", "")
+	flags.Bool(dryRunOpt, false, "")
+	flags.Bool(overrideProtectionOpt, false, "")
 }
 
 func (c *UndeployCommand) executeInternal(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget) ExecutionStatus {
+	observers, err := newOperationObservers(c.name, flags)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	status := c.executeUndeployment(positionalArgs, dsHost, flags, cfTarget, observers)
+	observers.report.write(status)
+	return status
+}
+
+func (c *UndeployCommand) executeUndeployment(positionalArgs []string, dsHost string, flags *flag.FlagSet, cfTarget util.CloudFoundryTarget, observers operationObservers) ExecutionStatus {
 	operationID := GetStringOpt(operationIDOpt, flags)
 	actionID := GetStringOpt(actionOpt, flags)
 	retries := GetUintOpt(retriesOpt, flags)
 
 	if operationID != "" || actionID != "" {
-		return c.ExecuteAction(operationID, actionID, retries, dsHost, cfTarget)
+		return c.executeAction(operationID, actionID, retries, dsHost, cfTarget, newDeploymentHistoryContext(flags, cfTarget), observers)
 	}
 
 	force := GetBoolOpt(forceOpt, flags)
 	mtaID := positionalArgs[0]
-	if !force && !ui.Confirm("Really undeploy multi-target app %s in org %s / space %s? (y/n)", terminal.EntityNameColor(mtaID),
+	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
+	dryRun := GetBoolOpt(dryRunOpt, flags)
+	if dryRun || !force {
+		preview, err := c.getUndeployPreview(mtaID, namespace, cfTarget)
+		if err != nil && dryRun {
+			ui.Failed("Could not preview the undeployment of multi-target app %s: %s", terminal.EntityNameColor(mtaID), err)
+			return Failure
+		}
+		if err != nil {
+			ui.Warn("Could not preview the undeployment of multi-target app %s: %s", mtaID, err)
+		} else {
+			printUndeployPreview(mtaID, preview, flags)
+		}
+		if dryRun {
+			ui.Say("\nThis is a dry run, the multi-target app was not undeployed.")
+			return Success
+		}
+	}
+	if !force && !c.getPrompt().Confirm("Really undeploy multi-target app %s in org %s / space %s? (y/n)", terminal.EntityNameColor(mtaID),
 		terminal.EntityNameColor(cfTarget.Org.Name),
 		terminal.EntityNameColor(cfTarget.Space.Name)) {
 		ui.Warn("Undeploy cancelled")
@@ -99,7 +146,6 @@ func (c *UndeployCommand) executeInternal(positionalArgs []string, dsHost string
 	// Create new REST client for mtas V2 api
 	mtaV2Client := c.NewMtaV2Client(dsHost, cfTarget)
 
-	namespace := strings.TrimSpace(GetStringOpt(namespaceOpt, flags))
 	// Check if a deployed MTA with the specified ID exists
 	_, err := mtaV2Client.GetMtasForThisSpace(&mtaID, &namespace)
 	if err != nil {
@@ -116,6 +162,10 @@ func (c *UndeployCommand) executeInternal(positionalArgs []string, dsHost string
 		return Failure
 	}
 
+	if !ensureProtectionOverridden(mtaID, namespace, "an undeployment", c.CfClient, c.getPrompt(), flags, cfTarget) {
+		return Failure
+	}
+
 	// Check for an ongoing operation for this MTA ID and abort it
 	wasAborted, err := c.CheckOngoingOperation(mtaID, namespace, dsHost, force, cfTarget)
 	if err != nil {
@@ -145,7 +195,10 @@ func (c *UndeployCommand) executeInternal(positionalArgs []string, dsHost string
 		return Failure
 	}
 
-	executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, responseHeader.Location.String(), retries, []*models.Message{}, mtaClient)
+	executionMonitor := NewExecutionMonitorFromLocationHeader(c.name, responseHeader.Location.String(), retries, []*models.Message{}, mtaClient).
+		withHistoryContext(newDeploymentHistoryContext(flags, cfTarget)).
+		withObservers(observers).
+		WithParentSpan(c.span)
 	return executionMonitor.Monitor()
 }
 
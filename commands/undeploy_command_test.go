@@ -1,16 +1,25 @@
 package commands_test
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 
 	cliFakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/baseclient"
+	cf_client_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/cfrestclient/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient"
 	mtaFake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient/fakes"
 	mtaV2Fake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient_v2/fakes"
 	mtaV2fake "github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/mtaclient_v2/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 
 	pluginFakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
@@ -93,6 +102,7 @@ var _ = Describe("UndeployCommand", func() {
 			testTokenFactory := commands.NewTestTokenFactory(cliConnection)
 			deployServiceURLCalculator := utilFakes.NewDeployServiceURLFakeCalculator("deploy-service.test.ondemand.com")
 			command.InitializeAll(name, cliConnection, testutil.NewCustomTransport(200), testClientFactory, testTokenFactory, deployServiceURLCalculator)
+			command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{}
 		})
 
 		// unknown flag - error
@@ -216,6 +226,248 @@ var _ = Describe("UndeployCommand", func() {
 			})
 		})
 
+		Context("with the dry-run option", func() {
+			BeforeEach(func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps:            []models.CloudFoundryApplication{{Name: "backend", Guid: "backend-guid"}},
+					AppRoutes:       []models.ApplicationRoute{{Url: "backend.example.com"}},
+					Services:        []models.CloudFoundryServiceInstance{{Name: "db"}},
+					ServiceBindings: []models.ServiceBinding{{AppName: "backend"}},
+					ServiceKeys:     []models.ServiceKey{{Name: "db-key"}},
+				}
+			})
+
+			It("should list the entities which would be deleted without undeploying", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--dry-run"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				joinedOutput := strings.Join(output, "\n")
+				Expect(joinedOutput).To(MatchRegexp(`app\s+backend\s+routes: backend.example.com\s+delete`))
+				Expect(joinedOutput).To(MatchRegexp(`service instance\s+db\s+bound to: backend\s+keep`))
+				Expect(joinedOutput).To(MatchRegexp(`service key\s+db-key\s+service: db\s+keep`))
+				Expect(joinedOutput).ToNot(ContainSubstring("cannot be restored"))
+				Expect(output).To(ContainElement("This is a dry run, the multi-target app was not undeployed."))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should highlight the deletion of services and service keys", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--dry-run", "--delete-services", "--delete-service-brokers"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				joinedOutput := strings.Join(output, "\n")
+				Expect(joinedOutput).To(MatchRegexp(`service instance\s+db\s+bound to: backend\s+delete`))
+				Expect(joinedOutput).To(MatchRegexp(`service key\s+db-key\s+service: db\s+delete`))
+				Expect(joinedOutput).To(ContainSubstring("Deleted service instances and service keys cannot be restored and the data they hold is lost."))
+				Expect(joinedOutput).To(ContainSubstring("The service brokers registered by the apps of the multi-target app would be deleted as well."))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should list only the bindings of the service instance of the mta when another space has an instance with the same name", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Services: []models.CloudFoundryServiceInstance{{Name: "db", Guid: "db-guid"}},
+					ServiceBindingsByServiceGuid: map[string][]models.ServiceBinding{
+						"db-guid":             {{AppName: "backend"}},
+						"other-space-db-guid": {{AppName: "other-backend"}},
+					},
+				}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--dry-run"}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				joinedOutput := strings.Join(output, "\n")
+				Expect(joinedOutput).To(MatchRegexp(`service instance\s+db\s+bound to: backend\s+keep`))
+				Expect(joinedOutput).ToNot(ContainSubstring("other-backend"))
+			})
+
+			It("should fail when the entities cannot be listed", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{AppsErr: fmt.Errorf("test-error")}
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "--dry-run"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Could not preview the undeployment of multi-target app test: Could not get apps: test-error")
+			})
+		})
+
+		Context("without the force option", func() {
+			It("should list the entities which would be deleted before asking for confirmation", func() {
+				command.CfClient = &cf_client_fakes.FakeCloudFoundryClient{
+					Apps: []models.CloudFoundryApplication{{Name: "backend", Guid: "backend-guid"}},
+				}
+				command.Input = strings.NewReader("n\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID}).ToInt()
+				})
+				ex.ExpectNonZeroStatus(status)
+				Expect(strings.Join(output, "\n")).To(MatchRegexp(`app\s+backend\s+routes: none\s+delete`))
+				Expect(output).To(ContainElement("Undeploy cancelled"))
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with a protected mta", func() {
+			var policyDirectory string
+
+			BeforeEach(func() {
+				policyDirectory, _ = os.MkdirTemp("", "protection-policy")
+				policyLocation := filepath.Join(policyDirectory, "protection-policy.yaml")
+				os.WriteFile(policyLocation, []byte("protected:\n- mta-id: "+mtaID+"\n"), os.ModePerm)
+				os.Setenv(util.ProtectionPolicyEnv, policyLocation)
+			})
+
+			AfterEach(func() {
+				os.Unsetenv(util.ProtectionPolicyEnv)
+				os.RemoveAll(policyDirectory)
+			})
+
+			It("should refuse to undeploy without the override-protection option", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "-f"}).ToInt()
+				})
+				ex.ExpectFailureOnLine(status, output, "Multi-target app test is protected and cannot be the target of an undeployment. Use the \"--override-protection\" option to override the protection.", 2)
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should refuse to undeploy when the typed ID does not match", func() {
+				command.Input = strings.NewReader("other\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "-f", "--override-protection"}).ToInt()
+				})
+				ex.ExpectFailureOnLine(status, output, "The typed ID does not match multi-target app test, the operation was cancelled", 2)
+				Expect(mtaClient.StartMtaOperationCallCount()).To(Equal(0))
+			})
+
+			It("should undeploy when the protection is overridden and the ID is typed", func() {
+				command.Input = strings.NewReader(mtaID + "\n")
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{mtaID, "-f", "--override-protection"}).ToInt()
+				})
+				ex.ExpectSuccessWithOutput(status, output, getOutputLines(testutil.ProcessID, ""))
+			})
+		})
+
+		Context("with the report option", func() {
+			var reportDirectory string
+
+			var readJUnitReport = func() util.JUnitTestSuites {
+				content, err := os.ReadFile(filepath.Join(reportDirectory, "report.xml"))
+				Expect(err).ToNot(HaveOccurred())
+				var report util.JUnitTestSuites
+				Expect(xml.Unmarshal(content, &report)).To(Succeed())
+				return report
+			}
+
+			BeforeEach(func() {
+				reportDirectory, _ = os.MkdirTemp("", "undeploy-report")
+			})
+
+			It("should write a test suite for the undeploy operation", func() {
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"test", "-f", "--report", "junit=" + filepath.Join(reportDirectory, "report.xml")}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				report := readJUnitReport()
+				Expect(report.Suites).To(HaveLen(1))
+				Expect(report.Suites[0].Name).To(Equal("undeploy " + testutil.ProcessID))
+				Expect(report.Suites[0].Failures).To(Equal(0))
+			})
+
+			It("should report the error message of a failed operation in its phase and module", func() {
+				testClientFactory.MtaClient = mtaFake.NewFakeMtaClientBuilder().
+					GetMtaOperations(&[]string{mtaID}[0], nil, nil, nil, nil).
+					StartMtaOperation(testutil.OperationResult, mtaclient.ResponseHeader{Location: "operations/1000?embed=messages"}, nil).
+					GetMtaOperation(testutil.ProcessID, "messages", &models.Operation{
+						State:     models.StateERROR,
+						ErrorType: models.ErrorTypeCONTENT,
+						MtaID:     mtaID,
+						Messages: []*models.Message{
+							{ID: 1, Text: "Stopping application \"test-web\"...", Type: models.MessageTypeINFO},
+							{ID: 2, Text: "Could not delete application \"test-web\": 502 Bad Gateway", Type: models.MessageTypeERROR},
+						},
+					}, nil).Build()
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"test", "-f", "--report", "junit=" + filepath.Join(reportDirectory, "report.xml")}).ToInt()
+				})
+				Expect(status).To(Equal(1))
+				report := readJUnitReport()
+				Expect(report.Suites).To(HaveLen(1))
+				Expect(report.Suites[0].Failures).To(Equal(2))
+				var failedCases []string
+				for _, testCase := range report.Suites[0].Cases {
+					if testCase.Failure != nil {
+						Expect(testCase.Failure.Message).To(Equal("Could not delete application \"test-web\": 502 Bad Gateway"))
+						failedCases = append(failedCases, testCase.Name)
+					}
+				}
+				Expect(failedCases).To(Equal([]string{"phase cleanup", "module test-web"}))
+			})
+
+			It("should write the report when the operation cannot be started", func() {
+				testClientFactory.MtaClient = mtaFake.NewFakeMtaClientBuilder().
+					GetMtaOperations(&[]string{mtaID}[0], nil, nil, nil, nil).
+					StartMtaOperation(testutil.OperationResult, mtaclient.ResponseHeader{}, fmt.Errorf("test-error")).Build()
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"test", "-f", "--report", "tap=" + filepath.Join(reportDirectory, "report.tap")}).ToInt()
+				})
+				Expect(status).To(Equal(1))
+				content, err := os.ReadFile(filepath.Join(reportDirectory, "report.tap"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(content)).To(Equal("TAP version 13\n1..1\n# undeploy\nnot ok 1 - undeploy undeploy # time=0.000s\n" +
+					"  ---\n  message: \"The undeploy command failed, see its output for details\"\n  ...\n"))
+			})
+
+			It("should fail with an invalid report", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"test", "-f", "--report", "html=report.html"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, `Invalid report "html=report.html", expected junit=<path> or tap=<path>`)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(reportDirectory)
+			})
+		})
+
+		Context("with the notify-webhook option", func() {
+			var server *httptest.Server
+			var payloads []util.WebhookPayload
+
+			BeforeEach(func() {
+				payloads = nil
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var payload util.WebhookPayload
+					json.NewDecoder(r.Body).Decode(&payload)
+					payloads = append(payloads, payload)
+				}))
+			})
+
+			It("should notify the webhook that the operation started and finished", func() {
+				_, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"test", "-f", "--notify-webhook", server.URL}).ToInt()
+				})
+				Expect(status).To(Equal(0))
+				Expect(payloads).To(HaveLen(2))
+				Expect(payloads[0].Event).To(Equal(util.WebhookEventStarted))
+				Expect(payloads[1].Event).To(Equal(util.WebhookEventFinished))
+				Expect(payloads[1].Org).To(Equal(org))
+				Expect(payloads[1].Space).To(Equal(space))
+				Expect(payloads[1].ProcessID).To(Equal(testutil.ProcessID))
+				Expect(payloads[1].LogsHint).To(Equal("cf dmol -i " + testutil.ProcessID))
+			})
+
+			It("should fail with an invalid webhook URL", func() {
+				output, status := oc.CaptureOutputAndStatus(func() int {
+					return command.Execute([]string{"test", "-f", "--notify-webhook", "hooks.example.com"}).ToInt()
+				})
+				ex.ExpectFailure(status, output, "Invalid webhook URL, expected an http or https URL")
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+		})
+
 		// unable to start operation - failure
 		Context("with a correct mta id provided and failing start of operation", func() {
 			It("should display error and exit with non-zero status", func() {
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/v8/cf/terminal"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/clients/models"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+)
+
+// undeployPreview describes the Cloud Foundry entities of an MTA which are affected by its undeployment
+type undeployPreview struct {
+	apps     []undeployPreviewApp
+	services []undeployPreviewService
+}
+
+type undeployPreviewApp struct {
+	app    models.CloudFoundryApplication
+	routes []models.ApplicationRoute
+}
+
+type undeployPreviewService struct {
+	service  models.CloudFoundryServiceInstance
+	bindings []models.ServiceBinding
+	keys     []models.ServiceKey
+}
+
+// getUndeployPreview collects the apps, routes, service instances, service bindings and service keys of the MTA
+func (c *UndeployCommand) getUndeployPreview(mtaID, namespace string, cfTarget util.CloudFoundryTarget) (undeployPreview, error) {
+	var preview undeployPreview
+	apps, err := c.CfClient.GetApplications(mtaID, namespace, cfTarget.Space.Guid)
+	if err != nil {
+		return undeployPreview{}, fmt.Errorf("Could not get apps: %s", err)
+	}
+	for _, app := range apps {
+		routes, err := c.CfClient.GetApplicationRoutes(app.Guid)
+		if err != nil {
+			return undeployPreview{}, fmt.Errorf("Could not get app %q routes: %s", app.Name, err)
+		}
+		preview.apps = append(preview.apps, undeployPreviewApp{app: app, routes: routes})
+	}
+
+	services, err := c.CfClient.GetServiceInstances(mtaID, namespace, cfTarget.Space.Guid)
+	if err != nil {
+		return undeployPreview{}, fmt.Errorf("Could not get service instances: %s", err)
+	}
+	for _, service := range services {
+		bindings, err := c.CfClient.GetServiceBindings(service.Guid)
+		if err != nil {
+			return undeployPreview{}, fmt.Errorf("Could not get service %q bindings: %s", service.Name, err)
+		}
+		keys, err := c.CfClient.GetServiceKeys(service.Guid)
+		if err != nil {
+			return undeployPreview{}, fmt.Errorf("Could not get service %q keys: %s", service.Name, err)
+		}
+		preview.services = append(preview.services, undeployPreviewService{service: service, bindings: bindings, keys: keys})
+	}
+	return preview, nil
+}
+
+// printUndeployPreview prints every entity of the MTA and whether it would be deleted. The deletion of service
+// instances and service keys cannot be undone, so it is highlighted.
+func printUndeployPreview(mtaID string, preview undeployPreview, flags *flag.FlagSet) {
+	deleteServices := GetBoolOpt(deleteServicesOpt, flags)
+	deleteServiceKeys := deleteServices || GetBoolOpt(deleteServiceKeysOpt, flags)
+
+	ui.Say("Undeploying multi-target app %s would affect the following entities:\n", terminal.EntityNameColor(mtaID))
+	table := ui.Table([]string{"entity", "name", "details", "action"})
+	for _, app := range preview.apps {
+		table.Add("app", app.app.Name, "routes: "+joinOrNone(getRouteUrls(app.routes)), "delete")
+	}
+	destructive := false
+	for _, service := range preview.services {
+		table.Add("service instance", service.service.Name, "bound to: "+joinOrNone(getBoundAppNames(service.bindings)),
+			getUndeployAction(deleteServices))
+		for _, key := range service.keys {
+			table.Add("service key", key.Name, "service: "+service.service.Name, getUndeployAction(deleteServiceKeys))
+		}
+		destructive = destructive || deleteServices || (deleteServiceKeys && len(service.keys) != 0)
+	}
+	table.Print()
+
+	if len(preview.apps) == 0 && len(preview.services) == 0 {
+		ui.Say("No apps or service instances of multi-target app %s found", terminal.EntityNameColor(mtaID))
+	}
+	if destructive {
+		ui.Warn("Deleted service instances and service keys cannot be restored and the data they hold is lost.")
+	}
+	if GetBoolOpt(deleteServiceBrokersOpt, flags) {
+		ui.Warn("The service brokers registered by the apps of the multi-target app would be deleted as well.")
+	}
+}
+
+func getUndeployAction(deleted bool) string {
+	if deleted {
+		return terminal.FailureColor("delete")
+	}
+	return "keep"
+}
+
+func getRouteUrls(routes []models.ApplicationRoute) []string {
+	urls := make([]string, 0, len(routes))
+	for _, route := range routes {
+		urls = append(urls, route.Url)
+	}
+	return urls
+}
+
+func getBoundAppNames(bindings []models.ServiceBinding) []string {
+	appNames := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		appNames = append(appNames, binding.AppName)
+	}
+	return appNames
+}
+
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
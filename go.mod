@@ -5,6 +5,7 @@ go 1.25.4
 require (
 	code.cloudfoundry.org/cli/v8 v8.17.0
 	code.cloudfoundry.org/jsonry v1.1.4
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/go-openapi/errors v0.22.8
 	github.com/go-openapi/runtime v0.19.31
 	github.com/go-openapi/strfmt v0.26.4
@@ -15,6 +16,7 @@ require (
 	github.com/onsi/gomega v1.38.2
 	github.com/pborman/uuid v1.2.0
 	golang.org/x/net v0.56.0
+	golang.org/x/term v0.44.0
 	gopkg.in/cheggaaa/pb.v1 v1.0.28
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -35,7 +37,6 @@ require (
 	github.com/cloudfoundry/bosh-utils v0.0.385 // indirect
 	github.com/cppforlife/go-patch v0.2.0 // indirect
 	github.com/fatih/color v1.18.0 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/go-openapi/analysis v0.20.0 // indirect
 	github.com/go-openapi/jsonpointer v0.19.5 // indirect
 	github.com/go-openapi/jsonreference v0.19.5 // indirect
@@ -61,7 +62,6 @@ require (
 	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/crypto v0.53.0 // indirect
 	golang.org/x/sys v0.46.0 // indirect
-	golang.org/x/term v0.44.0 // indirect
 	golang.org/x/text v0.38.0 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
@@ -11,6 +11,7 @@ import (
 	"code.cloudfoundry.org/cli/v8/plugin"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/commands"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/log"
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
 )
 
@@ -29,8 +30,13 @@ var Commands = []commands.Command{
 	commands.NewUndeployCommand(),
 	commands.NewMtaCommand(),
 	commands.NewMtaOperationsCommand(),
+	commands.NewMtaOperationCommand(),
+	commands.NewMtaHistoryCommand(),
 	commands.NewPurgeConfigCommand(),
 	commands.NewRollbackMtaCommand(),
+	commands.NewMtaReleaseCommand(),
+	commands.NewMtaPromoteCommand(),
+	commands.NewMtaCleanupCommand(),
 }
 
 // Run runs this plugin
@@ -50,6 +56,7 @@ func (p *MultiappsPlugin) Run(cliConnection plugin.CliConnection, args []string)
 	}
 	util.SetCfCliVersion(strings.Join(versionOutput, " "))
 	util.SetPluginVersion(Version)
+	tracing.Configure(tracing.ConfigFromEnv(Version))
 	command.Initialize(command.GetPluginCommand().Name, cliConnection)
 	status := command.Execute(args[1:])
 	if status == commands.Failure {
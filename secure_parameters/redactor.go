@@ -0,0 +1,173 @@
+package secure_parameters
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// RedactionPatternsEnv is the environment variable with the location of a file with additional redaction patterns,
+	// one regular expression per line
+	RedactionPatternsEnv = "MULTIAPPS_REDACTION_PATTERNS"
+
+	redactedValue = "********"
+	// Shorter secure parameter values are not redacted, as they would match unrelated text
+	minRedactedValueLength = 4
+	// If a pattern has a group with this name, only the group is redacted instead of the whole match
+	redactedValueGroup = "value"
+)
+
+var defaultRedactionPatterns = []string{
+	`(?i)\b[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key)"?\s*[:=]\s*"?(?P<value>[^\s"',;}&]+)`,
+	`(?i)\bbearer\s+(?P<value>[A-Za-z0-9\-._~+/]+=*)`,
+	`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----(?P<value>.*?)-----END [A-Z ]*PRIVATE KEY-----`,
+	`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+}
+
+// Redactor masks secrets in operation messages and logs before they are printed or saved
+type Redactor struct {
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor creates a redactor which masks the values and the matches of the default and the additional patterns.
+// Invalid patterns are skipped and reported in the returned error.
+func NewRedactor(values []string, additionalPatterns []string) (*Redactor, error) {
+	redactor := &Redactor{}
+	for _, value := range values {
+		redactor.addValue(value)
+	}
+	sort.Slice(redactor.values, func(i, j int) bool {
+		return len(redactor.values[i]) > len(redactor.values[j])
+	})
+	var err error
+	for _, pattern := range append(append([]string{}, defaultRedactionPatterns...), additionalPatterns...) {
+		compiledPattern, compileErr := regexp.Compile(pattern)
+		if compileErr != nil {
+			err = fmt.Errorf("Invalid redaction pattern %q: %w", pattern, compileErr)
+			continue
+		}
+		redactor.patterns = append(redactor.patterns, compiledPattern)
+	}
+	return redactor, err
+}
+
+// NewRedactorFromEnv creates a redactor which masks the values of the secure parameters in the environment and the
+// matches of the patterns from the file in MULTIAPPS_REDACTION_PATTERNS. If some of them cannot be loaded, the error
+// is returned together with a redactor which applies the rest.
+func NewRedactorFromEnv() (*Redactor, error) {
+	var errs []string
+	parameters, err := CollectFromEnv("__MTA")
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("Could not collect secure parameters: %s", err))
+	}
+	patterns, err := readRedactionPatterns(os.Getenv(RedactionPatternsEnv))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	redactor, err := NewRedactor(getSecureValues(parameters), patterns)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) != 0 {
+		return redactor, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return redactor, nil
+}
+
+// Redact returns the text with all secrets masked
+func (r *Redactor) Redact(text string) string {
+	for _, value := range r.values {
+		text = strings.ReplaceAll(text, value, redactedValue)
+	}
+	for _, pattern := range r.patterns {
+		text = redactMatches(text, pattern)
+	}
+	return text
+}
+
+func (r *Redactor) addValue(value string) {
+	if len(value) < minRedactedValueLength {
+		return
+	}
+	r.values = append(r.values, value)
+	// The lines of multiline values, such as certificates, are often logged separately
+	if lines := strings.Split(value, "\n"); len(lines) > 1 {
+		for _, line := range lines {
+			r.addValue(strings.TrimSpace(line))
+		}
+	}
+}
+
+func redactMatches(text string, pattern *regexp.Regexp) string {
+	groupIndex := pattern.SubexpIndex(redactedValueGroup)
+	var result strings.Builder
+	end := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		matchStart, matchEnd := match[0], match[1]
+		if groupIndex > 0 {
+			matchStart, matchEnd = match[2*groupIndex], match[2*groupIndex+1]
+		}
+		if matchStart < 0 || matchStart == matchEnd {
+			continue
+		}
+		result.WriteString(text[end:matchStart])
+		result.WriteString(redactedValue)
+		end = matchEnd
+	}
+	result.WriteString(text[end:])
+	return result.String()
+}
+
+func readRedactionPatterns(location string) ([]string, error) {
+	if location == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(location)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read redaction patterns: %w", err)
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, nil
+}
+
+func getSecureValues(parameters map[string]ParameterValue) []string {
+	var values []string
+	for _, parameter := range parameters {
+		if parameter.Type == typeJSON {
+			values = append(values, getJSONStringValues(parameter.JSONContent)...)
+		} else {
+			values = append(values, parameter.StringContent)
+		}
+	}
+	return values
+}
+
+func getJSONStringValues(content interface{}) []string {
+	switch value := content.(type) {
+	case string:
+		return []string{value}
+	case map[string]interface{}:
+		var values []string
+		for _, element := range value {
+			values = append(values, getJSONStringValues(element)...)
+		}
+		return values
+	case []interface{}:
+		var values []string
+		for _, element := range value {
+			values = append(values, getJSONStringValues(element)...)
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	serviceName          = "multiapps-cli-plugin"
+	exportRequestTimeout = 10 * time.Second
+	spanKindInternal     = 1
+	spanKindClient       = 3
+	statusCodeError      = 2
+)
+
+// The OTLP JSON encoding of the spans, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+// Flush exports the ended spans to the configured file and endpoint. The spans are discarded even if the export
+// fails, so that they are not exported twice.
+func Flush() error {
+	t := currentTracer
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	spans := t.endedSpans
+	t.endedSpans = nil
+	t.mutex.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	content, err := json.Marshal(t.encode(spans))
+	if err != nil {
+		return err
+	}
+	if t.config.File != "" {
+		if err := appendLine(t.config.File, content); err != nil {
+			return fmt.Errorf("Could not write the traces to %s: %s", t.config.File, err)
+		}
+	}
+	if t.config.Endpoint != "" {
+		if err := post(t.config.Endpoint, content); err != nil {
+			return fmt.Errorf("Could not export the traces to %s: %s", t.config.Endpoint, err)
+		}
+	}
+	return nil
+}
+
+func (t *tracer) encode(spans []*Span) otlpTraces {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var encodedSpans []otlpSpan
+	for _, span := range spans {
+		encodedSpan := otlpSpan{
+			TraceID:           span.traceID,
+			SpanID:            span.spanID,
+			ParentSpanID:      span.parentID,
+			Name:              span.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.startTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.endTime.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.attributes),
+		}
+		if span.client {
+			encodedSpan.Kind = spanKindClient
+		}
+		if span.errMessage != "" {
+			encodedSpan.Status = &otlpStatus{Code: statusCodeError, Message: span.errMessage}
+		}
+		encodedSpans = append(encodedSpans, encodedSpan)
+	}
+	resource := otlpResource{Attributes: encodeAttributes(map[string]interface{}{"service.name": serviceName, "service.version": t.config.Version})}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   resource,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: serviceName, Version: t.config.Version}, Spans: encodedSpans}},
+	}}}
+}
+
+func encodeAttributes(attributes map[string]interface{}) []otlpAttribute {
+	var encodedAttributes []otlpAttribute
+	for key, value := range attributes {
+		var encodedValue otlpValue
+		switch typedValue := value.(type) {
+		case bool:
+			encodedValue.BoolValue = &typedValue
+		case int:
+			intValue := strconv.Itoa(typedValue)
+			encodedValue.IntValue = &intValue
+		case int64:
+			intValue := strconv.FormatInt(typedValue, 10)
+			encodedValue.IntValue = &intValue
+		default:
+			stringValue := fmt.Sprint(typedValue)
+			encodedValue.StringValue = &stringValue
+		}
+		encodedAttributes = append(encodedAttributes, otlpAttribute{Key: key, Value: encodedValue})
+	}
+	sort.Slice(encodedAttributes, func(i, j int) bool {
+		return encodedAttributes[i].Key < encodedAttributes[j].Key
+	})
+	return encodedAttributes
+}
+
+func appendLine(location string, content []byte) error {
+	file, err := os.OpenFile(location, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func post(endpoint string, content []byte) error {
+	client := &http.Client{Timeout: exportRequestTimeout}
+	response, err := client.Post(endpoint, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("Endpoint responded with status %s", response.Status)
+	}
+	return nil
+}
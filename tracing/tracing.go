@@ -0,0 +1,187 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// FileEnv is the environment variable with the location of the file to which the spans are appended in the
+	// OTLP JSON format
+	FileEnv = "MULTIAPPS_TRACES_FILE"
+	// EndpointEnv is the environment variable with the OTLP/HTTP endpoint to which the spans are posted in the OTLP
+	// JSON format, e.g. http://localhost:4318/v1/traces
+	EndpointEnv = "MULTIAPPS_TRACES_ENDPOINT"
+	// OtelEndpointEnv is the standard OpenTelemetry variable for the traces endpoint, used if EndpointEnv is not set
+	OtelEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	// TraceParentEnv is the environment variable through which CI systems pass the W3C trace context of the job, so
+	// that the spans of the plugin become part of the trace of the job
+	TraceParentEnv = "TRACEPARENT"
+	// TraceParentHeader is the W3C trace context header sent to the controller
+	TraceParentHeader = "traceparent"
+)
+
+var traceParentPattern = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// Config selects where the spans are exported. Tracing is disabled if no exporter is configured.
+type Config struct {
+	File        string
+	Endpoint    string
+	TraceParent string
+	Version     string
+}
+
+// tracer collects the spans of the plugin process until they are exported
+type tracer struct {
+	config        Config
+	mutex         sync.Mutex
+	active        *Span
+	endedSpans    []*Span
+	remoteTraceID string
+	remoteSpanID  string
+}
+
+// The tracer of the plugin process, nil if tracing is disabled
+var currentTracer *tracer
+
+// Span is a timed operation of the plugin. All methods of a nil span, which is returned when tracing is disabled, do
+// nothing.
+type Span struct {
+	tracer     *tracer
+	traceID    string
+	spanID     string
+	parent     *Span
+	parentID   string
+	name       string
+	client     bool
+	startTime  time.Time
+	endTime    time.Time
+	attributes map[string]interface{}
+	errMessage string
+}
+
+// ConfigFromEnv returns the tracing configuration from the environment
+func ConfigFromEnv(version string) Config {
+	endpoint := os.Getenv(EndpointEnv)
+	if endpoint == "" {
+		endpoint = os.Getenv(OtelEndpointEnv)
+	}
+	return Config{File: os.Getenv(FileEnv), Endpoint: endpoint, TraceParent: os.Getenv(TraceParentEnv), Version: version}
+}
+
+// Configure enables tracing with the exporters of the configuration, or disables it if none are configured. Spans
+// which were not exported are discarded.
+func Configure(config Config) {
+	if config.File == "" && config.Endpoint == "" {
+		currentTracer = nil
+		return
+	}
+	currentTracer = &tracer{config: config}
+	if match := traceParentPattern.FindStringSubmatch(config.TraceParent); match != nil {
+		currentTracer.remoteTraceID, currentTracer.remoteSpanID = match[1], match[2]
+	}
+}
+
+// StartSpan starts a span which is a child of the active span and becomes the active span until it ends. Spans of
+// concurrent work should be started with StartChild instead.
+func StartSpan(name string) *Span {
+	t := currentTracer
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := t.newSpan(name, t.active)
+	t.active = span
+	return span
+}
+
+// StartClientSpan starts a span of a request to another service as a child of the active span, without making it the
+// active span
+func StartClientSpan(name string) *Span {
+	t := currentTracer
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := t.newSpan(name, t.active)
+	span.client = true
+	return span
+}
+
+// StartChild starts a child span without making it the active span, e.g. for work which runs in a goroutine
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	return s.tracer.newSpan(name, s)
+}
+
+// SetAttribute adds an attribute with a string, bool or integer value to the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.attributes[key] = value
+}
+
+// Fail marks the span as failed with the message
+func (s *Span) Fail(message string) {
+	if s == nil {
+		return
+	}
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.errMessage = message
+}
+
+// End ends the span. If it is the active span, its parent becomes the active span.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.endTime = time.Now()
+	s.tracer.endedSpans = append(s.tracer.endedSpans, s)
+	if s.tracer.active == s {
+		s.tracer.active = s.parent
+	}
+}
+
+// TraceParent returns the W3C trace context header value which identifies the span
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.traceID, s.spanID)
+}
+
+func (t *tracer) newSpan(name string, parent *Span) *Span {
+	span := &Span{tracer: t, spanID: newID(8), parent: parent, name: name, startTime: time.Now(), attributes: make(map[string]interface{})}
+	switch {
+	case parent != nil:
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+	case t.remoteTraceID != "":
+		span.traceID, span.parentID = t.remoteTraceID, t.remoteSpanID
+	default:
+		span.traceID = newID(16)
+	}
+	return span
+}
+
+func newID(length int) string {
+	id := make([]byte, length)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,204 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Attributes   []struct {
+		Key   string                 `json:"key"`
+		Value map[string]interface{} `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+type exportedTraces struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []exportedSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func parseSpans(content []byte) map[string]exportedSpan {
+	var traces exportedTraces
+	Expect(json.Unmarshal(content, &traces)).To(Succeed())
+	spans := make(map[string]exportedSpan)
+	for _, span := range traces.ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[span.Name] = span
+	}
+	return spans
+}
+
+var _ = Describe("Tracing", func() {
+	var tracesDirectory string
+	var tracesFile string
+
+	BeforeEach(func() {
+		tracesDirectory, _ = os.MkdirTemp("", "traces")
+		tracesFile = filepath.Join(tracesDirectory, "traces.jsonl")
+	})
+
+	AfterEach(func() {
+		tracing.Configure(tracing.Config{})
+		os.RemoveAll(tracesDirectory)
+	})
+
+	Context("without an exporter", func() {
+		It("should not record spans", func() {
+			tracing.Configure(tracing.Config{})
+			span := tracing.StartSpan("cf deploy")
+			Expect(span).To(BeNil())
+			span.SetAttribute("operation.id", "1000")
+			span.StartChild("upload chunk").End()
+			span.End()
+			Expect(tracing.Flush()).To(Succeed())
+		})
+	})
+
+	Context("with a file exporter", func() {
+		BeforeEach(func() {
+			tracing.Configure(tracing.Config{File: tracesFile, Version: "3.0.0"})
+		})
+
+		It("should append the spans with their parents as an OTLP JSON line", func() {
+			root := tracing.StartSpan("cf deploy")
+			poll := tracing.StartSpan("poll operation")
+			poll.SetAttribute("operation.id", "1000")
+			poll.End()
+			chunk := root.StartChild("upload chunk")
+			chunk.Fail("Connection reset")
+			chunk.End()
+			root.End()
+			Expect(tracing.Flush()).To(Succeed())
+
+			content, err := os.ReadFile(tracesFile)
+			Expect(err).ToNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+			Expect(lines).To(HaveLen(1))
+			spans := parseSpans([]byte(lines[0]))
+			Expect(spans).To(HaveLen(3))
+			Expect(spans["cf deploy"].ParentSpanID).To(BeEmpty())
+			Expect(spans["poll operation"].ParentSpanID).To(Equal(spans["cf deploy"].SpanID))
+			Expect(spans["poll operation"].TraceID).To(Equal(spans["cf deploy"].TraceID))
+			Expect(spans["poll operation"].Attributes[0].Key).To(Equal("operation.id"))
+			Expect(spans["poll operation"].Attributes[0].Value).To(Equal(map[string]interface{}{"stringValue": "1000"}))
+			Expect(spans["upload chunk"].ParentSpanID).To(Equal(spans["cf deploy"].SpanID))
+			Expect(spans["upload chunk"].Status.Code).To(Equal(2))
+			Expect(spans["upload chunk"].Status.Message).To(Equal("Connection reset"))
+		})
+
+		It("should continue the trace of the traceparent", func() {
+			tracing.Configure(tracing.Config{File: tracesFile, TraceParent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"})
+			tracing.StartSpan("cf undeploy").End()
+			Expect(tracing.Flush()).To(Succeed())
+
+			content, err := os.ReadFile(tracesFile)
+			Expect(err).ToNot(HaveOccurred())
+			span := parseSpans(content)["cf undeploy"]
+			Expect(span.TraceID).To(Equal("0af7651916cd43dd8448eb211c80319c"))
+			Expect(span.ParentSpanID).To(Equal("b7ad6b7169203331"))
+		})
+
+		It("should not write anything without ended spans", func() {
+			tracing.StartSpan("cf deploy")
+			Expect(tracing.Flush()).To(Succeed())
+			Expect(tracesFile).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Context("with an endpoint exporter", func() {
+		var server *httptest.Server
+		var statusCode int
+		var bodies [][]byte
+
+		BeforeEach(func() {
+			statusCode, bodies = http.StatusOK, nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, body)
+				w.WriteHeader(statusCode)
+			}))
+			tracing.Configure(tracing.Config{Endpoint: server.URL})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should post the spans", func() {
+			tracing.StartSpan("cf deploy").End()
+			Expect(tracing.Flush()).To(Succeed())
+			Expect(bodies).To(HaveLen(1))
+			Expect(parseSpans(bodies[0])).To(HaveKey("cf deploy"))
+		})
+
+		It("should return an error if the endpoint rejects the spans", func() {
+			statusCode = http.StatusBadRequest
+			tracing.StartSpan("cf deploy").End()
+			Expect(tracing.Flush()).To(MatchError("Could not export the traces to " + server.URL + ": Endpoint responded with status 400 Bad Request"))
+		})
+	})
+
+	Describe("Transport", func() {
+		var server *httptest.Server
+		var traceParent string
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceParent = r.Header.Get(tracing.TraceParentHeader)
+				w.WriteHeader(http.StatusNotFound)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should propagate the trace context of the request span", func() {
+			tracing.Configure(tracing.Config{File: tracesFile})
+			root := tracing.StartSpan("cf mta")
+			request, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/spaces/guid/mtas", nil)
+			response, err := tracing.NewTransport(nil).RoundTrip(request)
+			Expect(err).ToNot(HaveOccurred())
+			response.Body.Close()
+			root.End()
+			Expect(request.Header.Get(tracing.TraceParentHeader)).To(BeEmpty())
+			Expect(tracing.Flush()).To(Succeed())
+
+			content, err := os.ReadFile(tracesFile)
+			Expect(err).ToNot(HaveOccurred())
+			span := parseSpans(content)["HTTP GET"]
+			Expect(span.Kind).To(Equal(3))
+			Expect(span.ParentSpanID).To(Equal(root.TraceParent()[36:52]))
+			Expect(traceParent).To(Equal("00-" + span.TraceID + "-" + span.SpanID + "-01"))
+			Expect(span.Status.Message).To(Equal("404 Not Found"))
+		})
+
+		It("should not add the header without tracing", func() {
+			request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			response, err := tracing.NewTransport(nil).RoundTrip(request)
+			Expect(err).ToNot(HaveOccurred())
+			response.Body.Close()
+			Expect(traceParent).To(BeEmpty())
+		})
+	})
+})
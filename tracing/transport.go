@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"net/http"
+)
+
+// Transport wraps an existing RoundTripper, records a client span for each request and propagates the trace context
+// of the span to the server through the traceparent header
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport creates a tracing transport which sends the requests through the base transport
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements the RoundTripper interface
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := StartClientSpan("HTTP " + req.Method)
+	if span == nil {
+		return t.Base.RoundTrip(req)
+	}
+	defer span.End()
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("server.address", req.URL.Hostname())
+	span.SetAttribute("url.path", req.URL.Path)
+
+	// Clone the request to avoid modifying the original
+	reqCopy := req.Clone(req.Context())
+	reqCopy.Header.Set(TraceParentHeader, span.TraceParent())
+	resp, err := t.Base.RoundTrip(reqCopy)
+	if err != nil {
+		span.Fail(err.Error())
+		return nil, err
+	}
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 400 {
+		span.Fail(resp.Status)
+	}
+	return resp, nil
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"sync"
 
 	"code.cloudfoundry.org/cli/v8/cf/i18n"
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
@@ -14,6 +15,9 @@ import (
 var teePrinter *terminal.TeePrinter
 var ui terminal.UI
 
+// outputMutex serializes the output of commands which report the progress of several operations in parallel
+var outputMutex sync.Mutex
+
 func init() {
 	i18n.T = func(translationID string, args ...interface{}) string {
 		return translationID
@@ -43,18 +47,26 @@ func DisableTerminalOutput(disable bool) {
 }
 
 func PrintPaginator(rows []string, err error) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.PrintPaginator(rows, err)
 }
 
 func Say(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Say(message, args...)
 }
 
 func PrintCapturingNoOutput(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.PrintCapturingNoOutput(message, args...)
 }
 
 func Warn(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Warn(message, args...)
 }
 
@@ -66,15 +78,39 @@ func Confirm(message string, args ...interface{}) bool {
 	return ui.Confirm(fmt.Sprintf(message, args...))
 }
 
+// Prompt asks questions and reads the answers from its input
+type Prompt struct {
+	ui terminal.UI
+}
+
+// NewPrompt creates a prompt which prints the questions like the other output and reads the answers from the input
+func NewPrompt(input io.Reader) *Prompt {
+	return &Prompt{ui: terminal.NewUI(input, os.Stdout, teePrinter, trace.NewWriterPrinter(io.Discard, false))}
+}
+
+func (p *Prompt) Ask(prompt string, args ...interface{}) (answer string) {
+	return p.ui.Ask(fmt.Sprintf(prompt, args...))
+}
+
+func (p *Prompt) Confirm(message string, args ...interface{}) bool {
+	return p.ui.Confirm(fmt.Sprintf(message, args...))
+}
+
 func Ok() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Ok()
 }
 
 func Failed(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Failed(message, args...)
 }
 
 func LoadingIndication() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.LoadingIndication()
 }
 
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
@@ -29,12 +30,17 @@ func NewMtaArchiveBuilder(modules, resources []string) MtaArchiveBuilder {
 
 // Build creates deployment archive from the provided deployment descriptor
 func (builder MtaArchiveBuilder) Build(deploymentDescriptorLocation string) (string, error) {
+	return builder.BuildTo(deploymentDescriptorLocation, deploymentDescriptorLocation)
+}
+
+// BuildTo creates deployment archive from the provided deployment descriptor in the archive directory
+func (builder MtaArchiveBuilder) BuildTo(deploymentDescriptorLocation, archiveDirectory string) (string, error) {
 	descriptor, deploymentDescriptorFile, err := ParseDeploymentDescriptor(deploymentDescriptorLocation)
 	if err != nil {
 		return "", err
 	}
 
-	modulesPaths, err := builder.getModulesPaths(descriptor.Modules)
+	modulesPaths, modulesContents, err := builder.getModulesPaths(deploymentDescriptorLocation, descriptor.Modules)
 	if err != nil {
 		return "", fmt.Errorf("Error building MTA Archive: %s", err.Error())
 	}
@@ -45,21 +51,10 @@ func (builder MtaArchiveBuilder) Build(deploymentDescriptorLocation string) (str
 	bindingParametersPaths := builder.getBindingParametersPaths(descriptor.Modules)
 
 	modulesSections := buildSection(normalizePathsUsingUnixSeparator(modulesPaths), MtaModule)
+	modulesSections = append(modulesSections, buildSection(getModulesDirectoryEntries(modulesContents), MtaModule)...)
 	resourcesSections := buildSection(normalizePathsUsingUnixSeparator(resourcesPaths), MtaResource)
 	bindingParametersSections := buildSection(normalizePathsUsingUnixSeparator(bindingParametersPaths), MtaRequires)
 
-	manifestBuilder := NewMtaManifestBuilder()
-	manifestBuilder.ManifestSections(modulesSections)
-	manifestBuilder.ManifestSections(resourcesSections)
-	manifestBuilder.ManifestSections(bindingParametersSections)
-	manifestBuilder.ManifestSections([]ManifestSection{NewMtaManifestSectionBuilder().Name(MtadAttribute).Build()})
-
-	manifestLocation, err := manifestBuilder.Build()
-	if err != nil {
-		return "", err
-	}
-	defer os.Remove(manifestLocation)
-
 	mtaAssembly, err := os.MkdirTemp("", "mta-assembly")
 	if err != nil {
 		return "", err
@@ -72,39 +67,59 @@ func (builder MtaArchiveBuilder) Build(deploymentDescriptorLocation string) (str
 		return "", err
 	}
 
-	manifestInfo, err := os.Stat(manifestLocation)
+	err = copyFile(deploymentDescriptorFile, filepath.Join(metaInfLocation, "mtad.yaml"))
 	if err != nil {
 		return "", err
 	}
+	// TODO: modify the deployment descriptor after copying it in order not to contain any path parameters...
 
-	err = copyFile(manifestLocation, filepath.Join(metaInfLocation, manifestInfo.Name()))
+	err = copyContent(deploymentDescriptorLocation, modulesPaths, mtaAssembly)
 	if err != nil {
 		return "", err
 	}
 
-	err = copyFile(deploymentDescriptorFile, filepath.Join(metaInfLocation, "mtad.yaml"))
+	err = copyModulesContents(modulesContents, mtaAssembly)
 	if err != nil {
 		return "", err
 	}
-	// TODO: modify the deployment descriptor after copying it in order not to contain any path parameters...
 
-	err = copyContent(deploymentDescriptorLocation, modulesPaths, mtaAssembly)
+	err = copyContent(deploymentDescriptorLocation, resourcesPaths, mtaAssembly)
 	if err != nil {
 		return "", err
 	}
 
-	err = copyContent(deploymentDescriptorLocation, resourcesPaths, mtaAssembly)
+	err = copyContent(deploymentDescriptorLocation, bindingParametersPaths, mtaAssembly)
 	if err != nil {
 		return "", err
 	}
 
-	err = copyContent(deploymentDescriptorLocation, bindingParametersPaths, mtaAssembly)
+	entriesDigests, err := computeEntriesDigests(mtaAssembly)
+	if err != nil {
+		return "", fmt.Errorf("Error building MTA Archive: could not compute digests of archive entries: %s", err.Error())
+	}
+
+	manifestSections := make([]ManifestSection, 0)
+	manifestSections = append(manifestSections, modulesSections...)
+	manifestSections = append(manifestSections, resourcesSections...)
+	manifestSections = append(manifestSections, bindingParametersSections...)
+	manifestSections = append(manifestSections, NewMtaManifestSectionBuilder().Name(MtadAttribute).Build())
+
+	manifestBuilder := NewMtaManifestBuilder()
+	manifestBuilder.ManifestSections(addDigestsToSections(manifestSections, entriesDigests))
+
+	manifestLocation, err := manifestBuilder.Build()
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(manifestLocation)
+
+	err = copyFile(manifestLocation, filepath.Join(metaInfLocation, ManifestName))
 	if err != nil {
 		return "", err
 	}
 
 	mtaArchiveName := descriptor.ID + ".mtar"
-	mtaArchiveLocation := filepath.Join(deploymentDescriptorLocation, mtaArchiveName)
+	mtaArchiveLocation := filepath.Join(archiveDirectory, mtaArchiveName)
 	err = CreateMtaArchive(mtaAssembly, mtaArchiveLocation)
 	if err != nil {
 		return "", err
@@ -118,6 +133,58 @@ func (builder MtaArchiveBuilder) Build(deploymentDescriptorLocation string) (str
 	return mtaArchiveAbsolutePath, nil
 }
 
+// computeEntriesDigests computes the SHA-256 digests of all files in the assembly directory, keyed by their archive entry names
+func computeEntriesDigests(assemblyLocation string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := filepath.Walk(assemblyLocation, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(assemblyLocation, path)
+		if err != nil {
+			return err
+		}
+		digest, err := ComputeFileDigest(path, "SHA256")
+		if err != nil {
+			return err
+		}
+		result[filepath.ToSlash(relativePath)] = digest
+		return nil
+	})
+	return result, err
+}
+
+// addDigestsToSections adds a digest attribute to the sections of the digested entries and
+// appends a new section for every digested entry which is not yet part of the manifest
+func addDigestsToSections(sections []ManifestSection, entriesDigests map[string]string) []ManifestSection {
+	result := make([]ManifestSection, 0, len(sections)+len(entriesDigests))
+	sectionsWithDigests := make(map[string]bool)
+	for _, section := range sections {
+		entryName := strings.TrimPrefix(section.Name, Name+": ")
+		if digest, ok := entriesDigests[entryName]; ok {
+			section.Attributes[Sha256DigestAttribute] = digest
+			sectionsWithDigests[entryName] = true
+		}
+		result = append(result, section)
+	}
+
+	entryNames := make([]string, 0, len(entriesDigests))
+	for entryName := range entriesDigests {
+		if !sectionsWithDigests[entryName] {
+			entryNames = append(entryNames, entryName)
+		}
+	}
+	sort.Strings(entryNames)
+	for _, entryName := range entryNames {
+		section := NewMtaManifestSectionBuilder().Name(entryName).Attribute(Sha256DigestAttribute, entriesDigests[entryName]).Build()
+		result = append(result, section)
+	}
+	return result
+}
+
 func copyContent(sourceDirectory string, elementsPaths map[string]string, targetLocation string) error {
 	for name, path := range elementsPaths {
 		if path != "" {
@@ -141,6 +208,32 @@ func copyContent(sourceDirectory string, elementsPaths map[string]string, target
 	return nil
 }
 
+func copyModulesContents(modulesContents map[string][]string, targetLocation string) error {
+	for moduleName, contentSources := range modulesContents {
+		moduleLocation := filepath.Join(targetLocation, moduleName)
+		err := os.MkdirAll(moduleLocation, os.ModePerm)
+		if err != nil {
+			return err
+		}
+		for _, sourceLocation := range contentSources {
+			sourceInfo, err := os.Stat(sourceLocation)
+			if err != nil {
+				return fmt.Errorf("Error building MTA Archive: file path %s not found", sourceLocation)
+			}
+			destinationLocation := filepath.Join(moduleLocation, filepath.Base(sourceLocation))
+			if sourceInfo.IsDir() {
+				err = copyDirectory(sourceLocation, destinationLocation)
+			} else {
+				err = copyFile(sourceLocation, destinationLocation)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func copyDirectory(src, dest string) error {
 	var err error
 	var filesInDestinationInfo []os.DirEntry
@@ -197,7 +290,15 @@ func copyFile(src, dest string) error {
 
 func buildSection(elements map[string]string, locatorName string) []ManifestSection {
 	result := make([]ManifestSection, 0)
-	for key, value := range concatenateElementsWithSameValue(elements) {
+	elementsWithSameValue := concatenateElementsWithSameValue(elements)
+	entryNames := make([]string, 0, len(elementsWithSameValue))
+	for key := range elementsWithSameValue {
+		entryNames = append(entryNames, key)
+	}
+	sort.Strings(entryNames)
+	for _, key := range entryNames {
+		value := elementsWithSameValue[key]
+		sort.Strings(value)
 		manifestSectionBuilder := NewMtaManifestSectionBuilder()
 		manifestSectionBuilder.Name(key)
 		manifestSectionBuilder.Attribute(locatorName, strings.Join(value, ","))
@@ -287,16 +388,16 @@ func getString(value interface{}) string {
 	return value.(string)
 }
 
-func (builder MtaArchiveBuilder) getModulesPaths(deploymentDescriptorResources []Module) (map[string]string, error) {
+func (builder MtaArchiveBuilder) getModulesPaths(deploymentDescriptorLocation string, deploymentDescriptorResources []Module) (map[string]string, map[string][]string, error) {
 	err := validateSpecifiedModules(builder.modules, deploymentDescriptorResources)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	modulesToAdd := filterModules(deploymentDescriptorResources, func(module Module) bool {
 		return Contains(builder.modules, module.Name)
 	})
 	specifiedModulesWithoutPaths := filterModules(modulesToAdd, func(module Module) bool {
-		return module.Path == ""
+		return len(module.ContentPaths()) == 0
 	})
 	moduleNamesWithoutPaths := make([]string, 0)
 	for _, moduleWithoutPath := range specifiedModulesWithoutPaths {
@@ -306,15 +407,73 @@ func (builder MtaArchiveBuilder) getModulesPaths(deploymentDescriptorResources [
 		ui.Warn("Modules %s do not have a path, specified for their binaries and will be ignored", strings.Join(moduleNamesWithoutPaths, ", "))
 	}
 	result := make(map[string]string)
+	modulesContents := make(map[string][]string)
 	for _, moduleToAdd := range modulesToAdd {
-		if moduleToAdd.Path != "" {
-			result[moduleToAdd.Name] = moduleToAdd.Path
+		if !hasMultipleContentSources(moduleToAdd) {
+			if moduleToAdd.Path != "" {
+				result[moduleToAdd.Name] = moduleToAdd.Path
+			}
+			continue
+		}
+		contentSources, err := resolveModuleContentSources(deploymentDescriptorLocation, moduleToAdd)
+		if err != nil {
+			return nil, nil, err
 		}
+		modulesContents[moduleToAdd.Name] = contentSources
 	}
 
+	return result, modulesContents, nil
+}
+
+// hasMultipleContentSources reports whether the module content is assembled from a list of paths or glob patterns
+// instead of a single file or directory
+func hasMultipleContentSources(module Module) bool {
+	return len(module.Paths) != 0 || isGlobPattern(module.Path)
+}
+
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// resolveModuleContentSources expands the paths and glob patterns of the module relative to the deployment descriptor
+// location. The result is sorted so that the archive layout does not depend on the order in which files are found.
+func resolveModuleContentSources(deploymentDescriptorLocation string, module Module) ([]string, error) {
+	result := make([]string, 0)
+	entryNames := make(map[string]string)
+	for _, contentPath := range module.ContentPaths() {
+		sourceLocation := filepath.Join(deploymentDescriptorLocation, contentPath)
+		matches, err := filepath.Glob(sourceLocation)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid path pattern %s of module %s: %s", contentPath, module.Name, err.Error())
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("file path %s not found", sourceLocation)
+		}
+		for _, match := range matches {
+			entryName := filepath.Base(match)
+			if previousMatch, exists := entryNames[entryName]; exists {
+				if previousMatch == match {
+					continue
+				}
+				return nil, fmt.Errorf("Paths %s and %s of module %s result in the same archive entry %s", previousMatch, match, module.Name, entryName)
+			}
+			entryNames[entryName] = match
+			result = append(result, match)
+		}
+	}
+	sort.Strings(result)
 	return result, nil
 }
 
+// getModulesDirectoryEntries returns the archive directory entries of the modules assembled from multiple content sources
+func getModulesDirectoryEntries(modulesContents map[string][]string) map[string]string {
+	result := make(map[string]string, len(modulesContents))
+	for moduleName := range modulesContents {
+		result[moduleName] = moduleName + "/"
+	}
+	return result
+}
+
 func normalizePathsUsingUnixSeparator(elementsPaths map[string]string) map[string]string {
 	normalizedPaths := make(map[string]string, len(elementsPaths))
 	for key, value := range elementsPaths {
@@ -393,6 +393,103 @@ var _ = Describe("ArchiveBuilder", func() {
 			})
 		})
 
+		Context("With deployment descriptor which contains modules with multiple paths", func() {
+			BeforeEach(func() {
+				os.MkdirAll(filepath.Join(tempDirLocation, "dist"), os.ModePerm)
+				os.MkdirAll(filepath.Join(tempDirLocation, "other"), os.ModePerm)
+				os.WriteFile(filepath.Join(tempDirLocation, "dist", "index.js"), []byte("index content"), os.ModePerm)
+				os.WriteFile(filepath.Join(tempDirLocation, "dist", "util.js"), []byte("util content"), os.ModePerm)
+				os.WriteFile(filepath.Join(tempDirLocation, "dist", "styles.css"), []byte("styles content"), os.ModePerm)
+				os.WriteFile(filepath.Join(tempDirLocation, "other", "index.js"), []byte("other index content"), os.ModePerm)
+				os.WriteFile(filepath.Join(tempDirLocation, "package.json"), []byte("{}"), os.ModePerm)
+			})
+
+			It("Should build the MTA Archive containing all module paths in the module directory", func() {
+				descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "100", ID: "test", Modules: []util.Module{
+					util.Module{Name: "TestModule", Paths: []string{"dist", "package.json"}},
+				}}
+				generatedYamlBytes, _ := yaml.Marshal(descriptor)
+				os.WriteFile(filepath.Join(tempDirLocation, "mtad.yaml"), generatedYamlBytes, os.ModePerm)
+				mtaArchiveLocation, err := util.NewMtaArchiveBuilder([]string{"TestModule"}, []string{}).Build(tempDirLocation)
+				defer os.Remove(mtaArchiveLocation)
+				Expect(err).To(BeNil())
+				Expect(isInArchive("TestModule/", mtaArchiveLocation)).To(BeTrue())
+				Expect(isInArchive("TestModule/dist/index.js", mtaArchiveLocation)).To(BeTrue())
+				Expect(isInArchive("TestModule/dist/util.js", mtaArchiveLocation)).To(BeTrue())
+				Expect(isInArchive("TestModule/package.json", mtaArchiveLocation)).To(BeTrue())
+				sections := parseManifestSections(readArchiveEntry("META-INF/MANIFEST.MF", mtaArchiveLocation))
+				Expect(sections["TestModule/"]).To(Equal(map[string]string{"MTA-Module": "TestModule"}))
+			})
+
+			It("Should build the MTA Archive containing the files matching the glob pattern of the module", func() {
+				descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "100", ID: "test", Modules: []util.Module{
+					util.Module{Name: "TestModule", Path: "dist/*.js", Paths: []string{"package.json"}},
+				}}
+				generatedYamlBytes, _ := yaml.Marshal(descriptor)
+				os.WriteFile(filepath.Join(tempDirLocation, "mtad.yaml"), generatedYamlBytes, os.ModePerm)
+				mtaArchiveLocation, err := util.NewMtaArchiveBuilder([]string{"TestModule"}, []string{}).Build(tempDirLocation)
+				defer os.Remove(mtaArchiveLocation)
+				Expect(err).To(BeNil())
+				Expect(isInArchive("TestModule/index.js", mtaArchiveLocation)).To(BeTrue())
+				Expect(isInArchive("TestModule/util.js", mtaArchiveLocation)).To(BeTrue())
+				Expect(isInArchive("TestModule/package.json", mtaArchiveLocation)).To(BeTrue())
+				Expect(isInArchive("TestModule/styles.css", mtaArchiveLocation)).To(BeFalse())
+				sections := parseManifestSections(readArchiveEntry("META-INF/MANIFEST.MF", mtaArchiveLocation))
+				Expect(sections["TestModule/"]).To(Equal(map[string]string{"MTA-Module": "TestModule"}))
+			})
+
+			It("Should fail when a glob pattern does not match any file", func() {
+				descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "100", ID: "test", Modules: []util.Module{
+					util.Module{Name: "TestModule", Path: "dist/*.txt"},
+				}}
+				generatedYamlBytes, _ := yaml.Marshal(descriptor)
+				os.WriteFile(filepath.Join(tempDirLocation, "mtad.yaml"), generatedYamlBytes, os.ModePerm)
+				_, err := util.NewMtaArchiveBuilder([]string{"TestModule"}, []string{}).Build(tempDirLocation)
+				Expect(err.Error()).To(MatchRegexp("Error building MTA Archive: file path .*?dist/\\*.txt not found"))
+			})
+
+			It("Should fail when two paths result in the same archive entry", func() {
+				descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "100", ID: "test", Modules: []util.Module{
+					util.Module{Name: "TestModule", Paths: []string{"dist/index.js", "other/index.js"}},
+				}}
+				generatedYamlBytes, _ := yaml.Marshal(descriptor)
+				os.WriteFile(filepath.Join(tempDirLocation, "mtad.yaml"), generatedYamlBytes, os.ModePerm)
+				_, err := util.NewMtaArchiveBuilder([]string{"TestModule"}, []string{}).Build(tempDirLocation)
+				Expect(err.Error()).To(MatchRegexp("Error building MTA Archive: Paths .*? of module TestModule result in the same archive entry index.js"))
+			})
+		})
+
+		Context("With deployment descriptor which contains modules with long paths", func() {
+			It("Should add SHA-256 digests for every archive entry and wrap the long manifest lines", func() {
+				moduleDirectory := filepath.Join(tempDirLocation, "test-module-content")
+				longFileName := strings.Repeat("very-long-file-name-", 5) + "content.txt"
+				os.MkdirAll(moduleDirectory, os.ModePerm)
+				os.WriteFile(filepath.Join(moduleDirectory, "index.js"), []byte("test content"), os.ModePerm)
+				os.WriteFile(filepath.Join(moduleDirectory, longFileName), []byte("other test content"), os.ModePerm)
+				descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "100", ID: "test", Modules: []util.Module{
+					util.Module{Name: "TestModule", Path: "test-module-content"},
+				}}
+				generatedYamlBytes, _ := yaml.Marshal(descriptor)
+				testDeploymentDescriptor := filepath.Join(tempDirLocation, "mtad.yaml")
+				os.WriteFile(testDeploymentDescriptor, generatedYamlBytes, os.ModePerm)
+				mtaArchiveLocation, err := util.NewMtaArchiveBuilder([]string{"TestModule"}, []string{}).Build(tempDirLocation)
+				defer os.Remove(mtaArchiveLocation)
+				Expect(err).To(BeNil())
+
+				manifestContent := readArchiveEntry("META-INF/MANIFEST.MF", mtaArchiveLocation)
+				for _, line := range strings.Split(manifestContent, "\n") {
+					Expect(len(line)).To(BeNumerically("<=", 72))
+				}
+				sections := parseManifestSections(manifestContent)
+				Expect(sections["TestModule/test-module-content/index.js"]).To(Equal(map[string]string{
+					"SHA-256-Digest": "auinVVUgn9bEQVfArtgBbnY/9DWhnPGG92hjFAFD/3I=",
+				}))
+				Expect(sections["TestModule/test-module-content/"+longFileName]).To(HaveKeyWithValue("SHA-256-Digest", "wbJfqa8G3CUqS33gi8Wo8Ic590ACKvM1jZTofPF1AXE="))
+				Expect(sections["TestModule/test-module-content"]).To(Equal(map[string]string{"MTA-Module": "TestModule"}))
+				Expect(sections["META-INF/mtad.yaml"]).To(HaveKey("SHA-256-Digest"))
+			})
+		})
+
 		AfterEach(func() {
 			os.RemoveAll(tempDirLocation)
 		})
@@ -450,3 +547,46 @@ func isManifestValid(manifestLocation string, searchCriteria map[string]string,
 func filepathUnixJoin(elements ...string) string {
 	return strings.Join(elements, "/")
 }
+
+func readArchiveEntry(fileName, archiveLocation string) string {
+	mtaArchiveReader, err := zip.OpenReader(archiveLocation)
+	if err != nil {
+		return ""
+	}
+	defer mtaArchiveReader.Close()
+	for _, file := range mtaArchiveReader.File {
+		if file.Name == fileName {
+			reader, err := file.Open()
+			if err != nil {
+				return ""
+			}
+			defer reader.Close()
+			content, _ := io.ReadAll(reader)
+			return string(content)
+		}
+	}
+	return ""
+}
+
+func parseManifestSections(manifestContent string) map[string]map[string]string {
+	unwrappedContent := strings.ReplaceAll(manifestContent, "\n ", "")
+	result := make(map[string]map[string]string)
+	var currentSection map[string]string
+	for _, line := range strings.Split(unwrappedContent, "\n") {
+		separatorIndex := strings.Index(line, ": ")
+		if separatorIndex < 0 {
+			currentSection = nil
+			continue
+		}
+		key, value := line[:separatorIndex], line[separatorIndex+2:]
+		if key == "Name" {
+			currentSection = make(map[string]string)
+			result[value] = currentSection
+			continue
+		}
+		if currentSection != nil {
+			currentSection[key] = value
+		}
+	}
+	return result
+}
@@ -41,6 +41,21 @@ func (c *CloudFoundryContext) GetSpace() (plugin_models.Space, error) {
 	return space, nil
 }
 
+// GetOrgAndSpace gets the org and the space with the specified names from the CLI connection
+func (c *CloudFoundryContext) GetOrgAndSpace(orgName, spaceName string) (plugin_models.Organization, plugin_models.Space, error) {
+	org, err := c.cliConnection.GetOrg(orgName)
+	if err != nil {
+		return plugin_models.Organization{}, plugin_models.Space{}, fmt.Errorf("Could not get org %s: %s", orgName, err)
+	}
+	for _, space := range org.Spaces {
+		if space.Name == spaceName {
+			return plugin_models.Organization{OrganizationFields: plugin_models.OrganizationFields{Guid: org.Guid, Name: org.Name}},
+				plugin_models.Space{SpaceFields: plugin_models.SpaceFields{Guid: space.Guid, Name: space.Name}}, nil
+		}
+	}
+	return plugin_models.Organization{}, plugin_models.Space{}, fmt.Errorf("Space %s not found in org %s", spaceName, orgName)
+}
+
 // GetUsername gets the username from the CLI connection
 func (c *CloudFoundryContext) GetUsername() (string, error) {
 	username, err := c.cliConnection.Username()
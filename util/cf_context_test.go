@@ -5,6 +5,7 @@ import (
 
 	"code.cloudfoundry.org/cli/v8/cf/terminal"
 
+	plugin_models "code.cloudfoundry.org/cli/v8/plugin/models"
 	plugin_fakes "code.cloudfoundry.org/cli/v8/plugin/pluginfakes"
 	cli_fakes "github.com/cloudfoundry-incubator/multiapps-cli-plugin/cli/fakes"
 	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/ui"
@@ -70,6 +71,45 @@ var _ = Describe("CloudFoundryContext", func() {
 		})
 	})
 
+	Describe("GetOrgAndSpace", func() {
+		var orgModel = plugin_models.GetOrg_Model{Guid: "other-org-guid", Name: "other-org", Spaces: []plugin_models.GetOrg_Space{
+			{Guid: "space-a-guid", Name: "space-a"},
+			{Guid: "space-b-guid", Name: "space-b"},
+		}}
+
+		Context("with an existing org and space", func() {
+			It("should return the org and the space", func() {
+				fakeCliConnection := cli_fakes.NewFakeCliConnectionBuilder().
+					GetOrg("other-org", orgModel, nil).Build()
+				cfContext := NewCloudFoundryContext(fakeCliConnection)
+				org, space, err := cfContext.GetOrgAndSpace("other-org", "space-b")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(org.Guid).To(Equal("other-org-guid"))
+				Expect(org.Name).To(Equal("other-org"))
+				Expect(space.Guid).To(Equal("space-b-guid"))
+				Expect(space.Name).To(Equal("space-b"))
+			})
+		})
+		Context("with a space which does not exist in the org", func() {
+			It("should return an error", func() {
+				fakeCliConnection := cli_fakes.NewFakeCliConnectionBuilder().
+					GetOrg("other-org", orgModel, nil).Build()
+				cfContext := NewCloudFoundryContext(fakeCliConnection)
+				_, _, err := cfContext.GetOrgAndSpace("other-org", "space-c")
+				Expect(err).To(MatchError("Space space-c not found in org other-org"))
+			})
+		})
+		Context("with an org which cannot be retrieved", func() {
+			It("should return an error", func() {
+				fakeCliConnection := cli_fakes.NewFakeCliConnectionBuilder().
+					GetOrg("missing-org", plugin_models.GetOrg_Model{}, fmt.Errorf("Organization missing-org not found")).Build()
+				cfContext := NewCloudFoundryContext(fakeCliConnection)
+				_, _, err := cfContext.GetOrgAndSpace("missing-org", "space-a")
+				Expect(err).To(MatchError("Could not get org missing-org: Organization missing-org not found"))
+			})
+		})
+	})
+
 	Describe("GetUsername", func() {
 		Context("with valid username returned by the CLI connection", func() {
 			It("should not exit or report any errors", func() {
@@ -0,0 +1,160 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	cfHomeEnv               = "CF_HOME"
+	deploymentHistoryFile   = "multiapps-history.jsonl"
+	maxDeploymentRecordSize = 1024 * 1024
+)
+
+// Records of parallel operations are appended to the same file
+var deploymentHistoryMutex sync.Mutex
+
+// DeploymentRecord is an operation which the plugin started or monitored, as stored in the local deployment history
+type DeploymentRecord struct {
+	RecordedAt    time.Time `json:"recordedAt"`
+	ProcessID     string    `json:"processId"`
+	ProcessType   string    `json:"processType"`
+	MtaID         string    `json:"mtaId,omitempty"`
+	MtaVersion    string    `json:"mtaVersion,omitempty"`
+	Namespace     string    `json:"namespace,omitempty"`
+	Org           string    `json:"org,omitempty"`
+	Space         string    `json:"space,omitempty"`
+	SpaceID       string    `json:"spaceId,omitempty"`
+	User          string    `json:"user,omitempty"`
+	Strategy      string    `json:"strategy,omitempty"`
+	Flags         []string  `json:"flags,omitempty"`
+	ArchiveDigest string    `json:"archiveDigest,omitempty"`
+	State         string    `json:"state"`
+	DurationInSec int64     `json:"durationInSeconds"`
+}
+
+// DeploymentHistoryFilter selects deployment records. Empty fields match all records.
+type DeploymentHistoryFilter struct {
+	MtaID       string
+	Namespace   string
+	Space       string
+	State       string
+	ProcessType string
+	Since       time.Time
+}
+
+// DeploymentHistory is a local store of deployment records, kept as JSON lines in the CF home directory
+type DeploymentHistory struct {
+	location string
+}
+
+// NewDeploymentHistory returns the deployment history in the CF home directory, which is $CF_HOME/.cf or ~/.cf
+func NewDeploymentHistory() (DeploymentHistory, error) {
+	home := os.Getenv(cfHomeEnv)
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return DeploymentHistory{}, fmt.Errorf("Could not determine the CF home directory: %s", err)
+		}
+	}
+	return NewDeploymentHistoryAt(filepath.Join(home, ".cf", deploymentHistoryFile)), nil
+}
+
+// NewDeploymentHistoryAt returns the deployment history stored in the specified file
+func NewDeploymentHistoryAt(location string) DeploymentHistory {
+	return DeploymentHistory{location: location}
+}
+
+// Add appends a record to the deployment history
+func (h DeploymentHistory) Add(record DeploymentRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	deploymentHistoryMutex.Lock()
+	defer deploymentHistoryMutex.Unlock()
+	if err := os.MkdirAll(filepath.Dir(h.location), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(h.location, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Query returns the records which match the filter, oldest first. An operation which was recorded several times,
+// e.g. when it was monitored again after being resumed, is returned with its latest record, completed with the details
+// which only the earlier records hold.
+func (h DeploymentHistory) Query(filter DeploymentHistoryFilter) ([]DeploymentRecord, error) {
+	file, err := os.Open(h.location)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []DeploymentRecord
+	latestPositions := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxDeploymentRecordSize)
+	for number := 1; scanner.Scan(); number++ {
+		var record DeploymentRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("Invalid deployment record on line %d of %s: %s", number, h.location, err)
+		}
+		if position, exists := latestPositions[record.ProcessID]; exists {
+			record = record.completedWith(records[position])
+		}
+		latestPositions[record.ProcessID] = len(records)
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []DeploymentRecord
+	for position, record := range records {
+		if latestPositions[record.ProcessID] == position && filter.matches(record) {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+func (r DeploymentRecord) completedWith(earlier DeploymentRecord) DeploymentRecord {
+	for _, field := range []struct{ value, earlierValue *string }{
+		{&r.MtaID, &earlier.MtaID}, {&r.MtaVersion, &earlier.MtaVersion}, {&r.Namespace, &earlier.Namespace},
+		{&r.Org, &earlier.Org}, {&r.Space, &earlier.Space}, {&r.Strategy, &earlier.Strategy},
+		{&r.ArchiveDigest, &earlier.ArchiveDigest},
+	} {
+		if *field.value == "" {
+			*field.value = *field.earlierValue
+		}
+	}
+	if len(r.Flags) == 0 {
+		r.Flags = earlier.Flags
+	}
+	return r
+}
+
+func (f DeploymentHistoryFilter) matches(record DeploymentRecord) bool {
+	return matchesIfSet(f.MtaID, record.MtaID) && matchesIfSet(f.Namespace, record.Namespace) &&
+		matchesIfSet(f.Space, record.Space) && matchesIfSet(f.State, record.State) &&
+		matchesIfSet(f.ProcessType, record.ProcessType) && !record.RecordedAt.Before(f.Since)
+}
+
+func matchesIfSet(expected, actual string) bool {
+	return expected == "" || expected == actual
+}
@@ -0,0 +1,66 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeploymentHistory", func() {
+	var historyDirectory string
+	var history util.DeploymentHistory
+	var recordedAt = time.Date(2024, 5, 14, 10, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		historyDirectory, _ = os.MkdirTemp("", "deployment-history")
+		history = util.NewDeploymentHistoryAt(filepath.Join(historyDirectory, "history", "multiapps-history.jsonl"))
+	})
+
+	Describe("Query", func() {
+		Context("without recorded operations", func() {
+			It("should return no records", func() {
+				records, err := history.Query(util.DeploymentHistoryFilter{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+		})
+
+		Context("with recorded operations", func() {
+			BeforeEach(func() {
+				Expect(history.Add(util.DeploymentRecord{RecordedAt: recordedAt, ProcessID: "1", MtaID: "shop", MtaVersion: "1.0.0", State: "ACTION_REQUIRED"})).To(Succeed())
+				Expect(history.Add(util.DeploymentRecord{RecordedAt: recordedAt.Add(time.Hour), ProcessID: "2", MtaID: "billing", State: "FINISHED"})).To(Succeed())
+				Expect(history.Add(util.DeploymentRecord{RecordedAt: recordedAt.Add(2 * time.Hour), ProcessID: "1", State: "FINISHED"})).To(Succeed())
+				Expect(history.Add(util.DeploymentRecord{RecordedAt: recordedAt.Add(3 * time.Hour), ProcessID: "3", MtaID: "shop", State: "ERROR"})).To(Succeed())
+			})
+
+			It("should return the latest record of each operation", func() {
+				records, err := history.Query(util.DeploymentHistoryFilter{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(3))
+				Expect(records[0].ProcessID).To(Equal("2"))
+				Expect(records[1].ProcessID).To(Equal("1"))
+				Expect(records[1].State).To(Equal("FINISHED"))
+				Expect(records[1].MtaVersion).To(Equal("1.0.0"))
+				Expect(records[2].ProcessID).To(Equal("3"))
+			})
+
+			It("should return the records which match the filter", func() {
+				records, err := history.Query(util.DeploymentHistoryFilter{MtaID: "shop", Since: recordedAt.Add(90 * time.Minute)})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(2))
+				records, err = history.Query(util.DeploymentHistoryFilter{MtaID: "shop", State: "ERROR"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].ProcessID).To(Equal("3"))
+			})
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(historyDirectory)
+	})
+})
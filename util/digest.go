@@ -5,6 +5,7 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -15,6 +16,41 @@ import (
 
 // ComputeFileChecksum computes the checksum of the specified file based on the specified algorithm
 func ComputeFileChecksum(filePath, algorithm string) (string, error) {
+	digest, err := computeFileDigest(filePath, algorithm)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+// ComputeFileDigest computes the base64 encoded digest of the specified file, as used in the digest attributes of MANIFEST.MF
+func ComputeFileDigest(filePath, algorithm string) (string, error) {
+	digest, err := computeFileDigest(filePath, algorithm)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(digest), nil
+}
+
+// ComputeChecksum computes the checksum of the content read from the reader based on the specified algorithm
+func ComputeChecksum(reader io.Reader, algorithm string) (string, error) {
+	digest, err := computeDigest(reader, algorithm)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+func computeFileDigest(filePath, algorithm string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return computeDigest(file, algorithm)
+}
+
+func computeDigest(reader io.Reader, algorithm string) ([]byte, error) {
 	var hasher hash.Hash
 	switch strings.ToUpper(algorithm) {
 	case "MD5":
@@ -26,18 +62,12 @@ func ComputeFileChecksum(filePath, algorithm string) (string, error) {
 	case "SHA512":
 		hasher = sha512.New()
 	default:
-		return "", fmt.Errorf("Unsupported digest algorithm %q", algorithm)
+		return nil, fmt.Errorf("Unsupported digest algorithm %q", algorithm)
 	}
 
-	file, err := os.Open(filePath)
+	_, err := io.Copy(hasher, reader)
 	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	_, err = io.Copy(hasher, file)
-	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return hex.EncodeToString(hasher.Sum(nil)), nil
+	return hasher.Sum(nil), nil
 }
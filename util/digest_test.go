@@ -51,4 +51,37 @@ var _ = Describe("Digest", func() {
 			os.Remove(testFileName)
 		})
 	})
+
+	Describe("ComputeFileDigest", func() {
+		const testFileName = "test-file.txt"
+
+		var testFilePath string
+		var testFile *os.File
+
+		BeforeEach(func() {
+			testFile, _ = os.Create(testFileName)
+			testFilePath, _ = filepath.Abs(testFileName)
+		})
+
+		Context("with an unsupported digest algorithm", func() {
+			It("should return an error", func() {
+				digest, err := util.ComputeFileDigest(testFilePath, "unsupported-algorithm-name")
+				testutil.ExpectErrorAndZeroResult(err, digest)
+			})
+		})
+
+		Context("with a supported digest algorithm and a non-empty file", func() {
+			It("should return the base64 encoded digest of the file", func() {
+				const testFileContent = "test file content"
+				os.WriteFile(testFile.Name(), []byte(testFileContent), 0644)
+				digest, err := util.ComputeFileDigest(testFilePath, "SHA1")
+				testutil.ExpectNoErrorAndResult(err, digest, "kDK7wiTtizkYPLk7mnRHcnzmf50=")
+			})
+		})
+
+		AfterEach(func() {
+			testFile.Close()
+			os.Remove(testFileName)
+		})
+	})
 })
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseDuration parses a non-negative duration. In addition to the units supported by time.ParseDuration, whole days
+// and weeks can be specified, e.g. 7d or 2w.
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range durationUnits {
+		if count, err := strconv.ParseUint(strings.TrimSuffix(value, suffix), 10, 32); strings.HasSuffix(value, suffix) && err == nil {
+			return time.Duration(count) * unit, nil
+		}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("Invalid duration %q, expected a value like 7d, 12h or 30m", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,28 @@
+package util_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseDuration", func() {
+	It("should parse days and weeks", func() {
+		Expect(util.ParseDuration("7d")).To(Equal(7 * 24 * time.Hour))
+		Expect(util.ParseDuration("2w")).To(Equal(14 * 24 * time.Hour))
+	})
+
+	It("should parse the units supported by the standard library", func() {
+		Expect(util.ParseDuration("12h")).To(Equal(12 * time.Hour))
+		Expect(util.ParseDuration("1h30m")).To(Equal(90 * time.Minute))
+	})
+
+	It("should reject invalid and negative durations", func() {
+		for _, value := range []string{"", "7", "d", "1.5d", "-1h", "seven days"} {
+			_, err := util.ParseDuration(value)
+			Expect(err).To(MatchError("Invalid duration \"" + value + "\", expected a value like 7d, 12h or 30m"))
+		}
+	})
+})
@@ -6,6 +6,13 @@ ENVIRONMENT:
    DEBUG=1                                         Enables the logging of HTTP requests in STDOUT and STDERR.
    MULTIAPPS_CONTROLLER_URL=<URL>                  Overrides the default deploy-service.<system-domain> with a custom URL.
    MULTIAPPS_USER_AGENT_SUFFIX=<STRING>            Appends custom text to User-Agent header. Only alphanumeric, spaces, hyphens, dots, underscores allowed. Max 128 chars, excess truncated.
+   MULTIAPPS_PROTECTION_POLICY=<PATH>              YAML file listing the protected MTA IDs and namespaces, which require --override-protection for destructive operations.
+   MULTIAPPS_REDACTION_PATTERNS=<PATH>             File with additional regular expressions, one per line, whose matches are masked in operation messages and logs.
+   MULTIAPPS_TIMING_REPORT=<PATH>                  JUnit XML file to which the time spent in each phase of the monitored operations is written.
+   MULTIAPPS_TRACES_ENDPOINT=<URL>                 OTLP/HTTP endpoint, e.g. http://localhost:4318/v1/traces, to which the traces of the command are exported as JSON.
+   MULTIAPPS_TRACES_FILE=<PATH>                    File to which the traces of the command are appended as OTLP JSON lines.
+   MULTIAPPS_WEBHOOK_CONFIG=<PATH>                 YAML file with the events, headers and payload templates of the notifications sent to --notify-webhook.
+   MULTIAPPS_WEBHOOK_SECRET=<STRING>               Secret with which the payloads sent to --notify-webhook are signed in the X-Multiapps-Signature header.
 `
 const UploadEnvHelpText = BaseEnvHelpText + `
    MULTIAPPS_UPLOAD_CHUNK_SIZE=<POSITIVE_INTEGER>  Configures chunk size (in MB) for MTAR upload.
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GetChangedPathsSinceRef returns the paths of the files which differ between the specified git ref and the working tree,
+// including untracked files. Only files located in the directory are returned and their paths are relative to it.
+func GetChangedPathsSinceRef(directory, ref string) ([]string, error) {
+	// A ref starting with a dash would be interpreted as an option of git
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("Invalid git ref %q, a ref cannot start with \"-\"", ref)
+	}
+	changedFiles, err := executeGitCommand(directory, "diff", "--name-only", "--relative", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("Could not get the changes since %s: %s", ref, err.Error())
+	}
+	untrackedFiles, err := executeGitCommand(directory, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("Could not get the untracked files: %s", err.Error())
+	}
+	result := make([]string, 0, len(changedFiles)+len(untrackedFiles))
+	for _, changedFile := range append(changedFiles, untrackedFiles...) {
+		result = append(result, filepath.FromSlash(changedFile))
+	}
+	return result, nil
+}
+
+func executeGitCommand(directory string, args ...string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.Command("git", append([]string{"-c", "core.quotepath=off", "-C", directory}, args...)...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s", message)
+		}
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package util_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitChanges", func() {
+	Describe("GetChangedPathsSinceRef", func() {
+		var repositoryLocation string
+		var mtaLocation string
+
+		var git = func(args ...string) {
+			command := exec.Command("git", append([]string{"-C", repositoryLocation, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			output, err := command.CombinedOutput()
+			Expect(err).To(BeNil(), string(output))
+		}
+
+		BeforeEach(func() {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git is not available")
+			}
+			repositoryLocation, _ = os.MkdirTemp("", "git-changes")
+			mtaLocation = filepath.Join(repositoryLocation, "mta")
+			os.MkdirAll(filepath.Join(mtaLocation, "backend"), os.ModePerm)
+			os.MkdirAll(filepath.Join(repositoryLocation, "other"), os.ModePerm)
+			os.WriteFile(filepath.Join(mtaLocation, "mtad.yaml"), []byte("ID: test"), os.ModePerm)
+			os.WriteFile(filepath.Join(mtaLocation, "backend", "server.js"), []byte("server"), os.ModePerm)
+			os.WriteFile(filepath.Join(repositoryLocation, "other", "file.txt"), []byte("other"), os.ModePerm)
+			git("init", "-q")
+			git("add", "-A")
+			git("commit", "-q", "-m", "initial")
+		})
+
+		Context("with modified, untracked and outside changes", func() {
+			It("should return the changed paths within the directory relative to it", func() {
+				os.WriteFile(filepath.Join(mtaLocation, "backend", "server.js"), []byte("changed server"), os.ModePerm)
+				os.WriteFile(filepath.Join(mtaLocation, "backend", "new.js"), []byte("new"), os.ModePerm)
+				os.WriteFile(filepath.Join(repositoryLocation, "other", "file.txt"), []byte("changed other"), os.ModePerm)
+				changedPaths, err := util.GetChangedPathsSinceRef(mtaLocation, "HEAD")
+				Expect(err).To(BeNil())
+				Expect(changedPaths).To(ConsistOf(filepath.Join("backend", "server.js"), filepath.Join("backend", "new.js")))
+			})
+		})
+
+		Context("with an unknown ref", func() {
+			It("should return an error", func() {
+				_, err := util.GetChangedPathsSinceRef(mtaLocation, "not-existing-ref")
+				Expect(err).To(MatchError(HavePrefix("Could not get the changes since not-existing-ref: ")))
+			})
+		})
+
+		Context("with a ref which starts with a dash", func() {
+			It("should return an error without running git", func() {
+				outputLocation := filepath.Join(repositoryLocation, "diff.txt")
+				_, err := util.GetChangedPathsSinceRef(mtaLocation, "--output="+outputLocation)
+				Expect(err).To(MatchError(`Invalid git ref "--output=` + outputLocation + `", a ref cannot start with "-"`))
+				Expect(outputLocation).ToNot(BeAnExistingFile())
+			})
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(repositoryLocation)
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"encoding/xml"
+	"os"
+	"time"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report, as read by CI servers
+type JUnitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite is a group of test cases, e.g. the phases of an operation
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a single timed step. A step which did not succeed has a failure.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure describes why a test case failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitTestSuite creates a test suite with the cases and computes its counts and total time
+func NewJUnitTestSuite(name string, startTime time.Time, cases []JUnitTestCase) JUnitTestSuite {
+	suite := JUnitTestSuite{Name: name, Tests: len(cases), Cases: cases}
+	if !startTime.IsZero() {
+		suite.Timestamp = startTime.UTC().Format("2006-01-02T15:04:05")
+	}
+	for _, testCase := range cases {
+		suite.Time += testCase.Time
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+	return suite
+}
+
+// JUnitSeconds converts the duration to the seconds with millisecond precision, as used in the time attributes
+func JUnitSeconds(duration time.Duration) float64 {
+	return float64(duration.Milliseconds()) / 1000
+}
+
+// WriteJUnitReport writes the test suites to the file at the specified location, replacing any existing content
+func WriteJUnitReport(location string, suites []JUnitTestSuite) error {
+	content, err := xml.MarshalIndent(JUnitTestSuites{Suites: suites}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(location, append([]byte(xml.Header), append(content, '\n')...), 0644)
+}
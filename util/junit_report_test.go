@@ -0,0 +1,46 @@
+package util_test
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JUnitReport", func() {
+	var reportDirectory string
+
+	BeforeEach(func() {
+		reportDirectory, _ = os.MkdirTemp("", "junit-report")
+	})
+
+	It("should write the test suites with their counts and times", func() {
+		location := filepath.Join(reportDirectory, "report.xml")
+		suite := util.NewJUnitTestSuite("deploy 1000", time.Date(2024, 5, 14, 10, 0, 0, 0, time.UTC), []util.JUnitTestCase{
+			{Name: "staging", ClassName: "shop", Time: util.JUnitSeconds(1500 * time.Millisecond)},
+			{Name: "starting", ClassName: "shop", Time: 2, Failure: &util.JUnitFailure{Message: "Application failed to start"}},
+		})
+		Expect(util.WriteJUnitReport(location, []util.JUnitTestSuite{suite})).To(Succeed())
+
+		content, err := os.ReadFile(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(HavePrefix(xml.Header))
+		var report util.JUnitTestSuites
+		Expect(xml.Unmarshal(content, &report)).To(Succeed())
+		Expect(report.Suites).To(HaveLen(1))
+		Expect(report.Suites[0].Name).To(Equal("deploy 1000"))
+		Expect(report.Suites[0].Tests).To(Equal(2))
+		Expect(report.Suites[0].Failures).To(Equal(1))
+		Expect(report.Suites[0].Time).To(Equal(3.5))
+		Expect(report.Suites[0].Timestamp).To(Equal("2024-05-14T10:00:00"))
+		Expect(report.Suites[0].Cases[1].Failure.Message).To(Equal("Application failed to start"))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(reportDirectory)
+	})
+})
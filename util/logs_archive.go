@@ -0,0 +1,105 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// LogsArchive is a zip or a gzipped tar archive to which downloaded logs are streamed
+type LogsArchive struct {
+	file       *os.File
+	zipWriter  *zip.Writer
+	gzipWriter *gzip.Writer
+	tarWriter  *tar.Writer
+}
+
+// NewLogsArchive creates an archive at the specified location. The format of the archive is determined by the
+// extension of the location, which can be .zip, .tar.gz or .tgz.
+func NewLogsArchive(location string) (*LogsArchive, error) {
+	isZip := strings.HasSuffix(location, ".zip")
+	isTarGz := strings.HasSuffix(location, ".tar.gz") || strings.HasSuffix(location, ".tgz")
+	if !isZip && !isTarGz {
+		return nil, fmt.Errorf("Unsupported format of archive %s, expected a .zip, .tar.gz or .tgz file", location)
+	}
+	if stat, _ := os.Stat(location); stat != nil {
+		return nil, fmt.Errorf("File or directory %s already exists.", location)
+	}
+	file, err := os.Create(location)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &LogsArchive{file: file}
+	if isZip {
+		archive.zipWriter = zip.NewWriter(file)
+	} else {
+		archive.gzipWriter = gzip.NewWriter(file)
+		archive.tarWriter = tar.NewWriter(archive.gzipWriter)
+	}
+	return archive, nil
+}
+
+// AddFile streams the content of the file at the source location to an entry with the specified name
+func (a *LogsArchive) AddFile(name, sourceLocation string) error {
+	source, err := os.Open(sourceLocation)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	stat, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	return a.addEntry(name, stat.Size(), stat.ModTime(), source)
+}
+
+// AddContent adds an entry with the specified name and content
+func (a *LogsArchive) AddContent(name string, content []byte) error {
+	return a.addEntry(name, int64(len(content)), time.Now(), bytes.NewReader(content))
+}
+
+func (a *LogsArchive) addEntry(name string, size int64, modTime time.Time, content io.Reader) error {
+	if a.zipWriter != nil {
+		writer, err := a.zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, content)
+		return err
+	}
+	err := a.tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(a.tarWriter, content)
+	return err
+}
+
+// Close writes the end of the archive and closes its file
+func (a *LogsArchive) Close() error {
+	var err error
+	if a.zipWriter != nil {
+		err = a.zipWriter.Close()
+	} else {
+		err = a.tarWriter.Close()
+		if gzipErr := a.gzipWriter.Close(); err == nil {
+			err = gzipErr
+		}
+	}
+	if fileErr := a.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
+
+// Location returns the location of the archive file
+func (a *LogsArchive) Location() string {
+	return a.file.Name()
+}
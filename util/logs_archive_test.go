@@ -0,0 +1,89 @@
+package util_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogsArchive", func() {
+	var archiveDirectory string
+	var sourceLocation string
+
+	var writeArchive = func(location string) {
+		archive, err := util.NewLogsArchive(location)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archive.AddFile("mta-op-1000/OPERATION.log", sourceLocation)).To(Succeed())
+		Expect(archive.AddContent("mta-op-1000/metadata.json", []byte(`{"id":"1000"}`))).To(Succeed())
+		Expect(archive.Close()).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		archiveDirectory, _ = os.MkdirTemp("", "logs-archive")
+		sourceLocation = filepath.Join(archiveDirectory, "OPERATION.log")
+		os.WriteFile(sourceLocation, []byte("Deploying"), 0644)
+	})
+
+	Context("with a zip location", func() {
+		It("should write the entries to a zip archive", func() {
+			location := filepath.Join(archiveDirectory, "logs.zip")
+			writeArchive(location)
+			reader, err := zip.OpenReader(location)
+			Expect(err).ToNot(HaveOccurred())
+			defer reader.Close()
+			contents := map[string]string{}
+			for _, file := range reader.File {
+				entry, _ := file.Open()
+				content, _ := io.ReadAll(entry)
+				entry.Close()
+				contents[file.Name] = string(content)
+			}
+			Expect(contents).To(Equal(map[string]string{"mta-op-1000/OPERATION.log": "Deploying", "mta-op-1000/metadata.json": `{"id":"1000"}`}))
+		})
+	})
+
+	Context("with a tar.gz location", func() {
+		It("should write the entries to a gzipped tar archive", func() {
+			location := filepath.Join(archiveDirectory, "logs.tar.gz")
+			writeArchive(location)
+			file, _ := os.Open(location)
+			defer file.Close()
+			gzipReader, err := gzip.NewReader(file)
+			Expect(err).ToNot(HaveOccurred())
+			tarReader := tar.NewReader(gzipReader)
+			contents := map[string]string{}
+			for header, err := tarReader.Next(); err == nil; header, err = tarReader.Next() {
+				content, _ := io.ReadAll(tarReader)
+				contents[header.Name] = string(content)
+			}
+			Expect(contents).To(Equal(map[string]string{"mta-op-1000/OPERATION.log": "Deploying", "mta-op-1000/metadata.json": `{"id":"1000"}`}))
+		})
+	})
+
+	Context("with an unsupported extension", func() {
+		It("should return an error", func() {
+			_, err := util.NewLogsArchive(filepath.Join(archiveDirectory, "logs.rar"))
+			Expect(err).To(MatchError(ContainSubstring("expected a .zip, .tar.gz or .tgz file")))
+		})
+	})
+
+	Context("with an existing file", func() {
+		It("should return an error", func() {
+			location := filepath.Join(archiveDirectory, "logs.zip")
+			os.WriteFile(location, []byte{}, 0644)
+			_, err := util.NewLogsArchive(location)
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(archiveDirectory)
+	})
+})
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// FindChangedModules returns the names of the modules whose content paths contain any of the changed paths.
+// The changed paths must be relative to the location of the deployment descriptor.
+func FindChangedModules(descriptor MtaDeploymentDescriptor, changedPaths []string) []string {
+	result := make([]string, 0)
+	for _, module := range descriptor.Modules {
+		if containsAnyChangedPath(module.ContentPaths(), changedPaths) {
+			result = append(result, module.Name)
+		}
+	}
+	return result
+}
+
+func containsAnyChangedPath(contentPaths, changedPaths []string) bool {
+	for _, contentPath := range contentPaths {
+		for _, changedPath := range changedPaths {
+			if IsPathWithin(contentPath, changedPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsPathWithin reports whether the path is the content path itself, is matched by the content path glob pattern or
+// is located in a directory denoted by the content path. Both paths must be relative to the same location.
+func IsPathWithin(contentPath, path string) bool {
+	contentPath = filepath.Clean(contentPath)
+	path = filepath.Clean(path)
+	if contentPath == "." {
+		return !strings.HasPrefix(path, "..")
+	}
+	for currentPath := path; currentPath != "." && currentPath != string(filepath.Separator); currentPath = filepath.Dir(currentPath) {
+		if currentPath == contentPath {
+			return true
+		}
+		if matches, _ := filepath.Match(contentPath, currentPath); matches {
+			return true
+		}
+	}
+	return false
+}
+
+// FindChangedResources returns the names of the resources whose configuration paths contain any of the changed paths.
+// The changed paths must be relative to the location of the deployment descriptor.
+func FindChangedResources(descriptor MtaDeploymentDescriptor, changedPaths []string) []string {
+	result := make([]string, 0)
+	for _, resource := range descriptor.Resources {
+		resourcePath := getString(resource.Parameters["path"])
+		if resourcePath != "" && containsAnyChangedPath([]string{resourcePath}, changedPaths) {
+			result = append(result, resource.Name)
+		}
+	}
+	return result
+}
+
+// FindAffectedModules returns the names of the modules which have changed content or required dependency configuration
+// paths, as well as of the modules which require any of the changed resources
+func FindAffectedModules(descriptor MtaDeploymentDescriptor, changedPaths, changedResources []string) []string {
+	changedModules := FindChangedModules(descriptor, changedPaths)
+	result := make([]string, 0)
+	for _, module := range descriptor.Modules {
+		if Contains(changedModules, module.Name) || requiresAnyChangedElement(module, changedPaths, changedResources) {
+			result = append(result, module.Name)
+		}
+	}
+	return result
+}
+
+func requiresAnyChangedElement(module Module, changedPaths, changedResources []string) bool {
+	for _, requiredDependency := range module.RequiredDependencies {
+		if Contains(changedResources, requiredDependency.Name) {
+			return true
+		}
+	}
+	for _, configPath := range getRequiredDependenciesConfigPaths(module.RequiredDependencies) {
+		if containsAnyChangedPath([]string{configPath}, changedPaths) {
+			return true
+		}
+	}
+	return false
+}
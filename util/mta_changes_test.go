@@ -0,0 +1,91 @@
+package util_test
+
+import (
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MtaChanges", func() {
+	Describe("FindChangedModules", func() {
+		descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "3.3", ID: "test", Modules: []util.Module{
+			util.Module{Name: "backend", Path: "backend/target/backend.jar"},
+			util.Module{Name: "ui", Paths: []string{"ui/dist", "ui/package.json"}},
+			util.Module{Name: "scripts", Path: "scripts/*.sh"},
+			util.Module{Name: "no-content"},
+		}}
+
+		Context("with changes in a file module", func() {
+			It("should return the module", func() {
+				Expect(util.FindChangedModules(descriptor, []string{"backend/target/backend.jar"})).To(Equal([]string{"backend"}))
+			})
+		})
+
+		Context("with changes in nested files of a directory module", func() {
+			It("should return the module", func() {
+				Expect(util.FindChangedModules(descriptor, []string{"ui/dist/js/app.js"})).To(Equal([]string{"ui"}))
+			})
+		})
+
+		Context("with changes in files matched by a glob pattern", func() {
+			It("should return the module", func() {
+				Expect(util.FindChangedModules(descriptor, []string{"scripts/start.sh", "scripts/README.md"})).To(Equal([]string{"scripts"}))
+			})
+		})
+
+		Context("with changes in several modules", func() {
+			It("should return the modules in the order of the deployment descriptor", func() {
+				Expect(util.FindChangedModules(descriptor, []string{"ui/package.json", "backend/target/backend.jar"})).To(Equal([]string{"backend", "ui"}))
+			})
+		})
+
+		Context("with changes outside of the module paths", func() {
+			It("should return no modules", func() {
+				Expect(util.FindChangedModules(descriptor, []string{"backend/src/Main.java", "ui/distribution", "test.mtar"})).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("FindAffectedModules", func() {
+		descriptor := util.MtaDeploymentDescriptor{SchemaVersion: "3.3", ID: "test", Modules: []util.Module{
+			util.Module{Name: "backend", Path: "backend", RequiredDependencies: []util.RequiredDependency{
+				util.RequiredDependency{Name: "db"},
+				util.RequiredDependency{Name: "uaa", Parameters: map[string]interface{}{"path": "config/backend-uaa.json"}},
+			}},
+			util.Module{Name: "ui", Path: "ui", RequiredDependencies: []util.RequiredDependency{
+				util.RequiredDependency{Name: "uaa"},
+			}},
+		}, Resources: []util.Resource{
+			util.Resource{Name: "db", Parameters: map[string]interface{}{"path": "config/db.json"}},
+			util.Resource{Name: "uaa", Parameters: map[string]interface{}{"path": "config/xs-security.json"}},
+			util.Resource{Name: "destination"},
+		}}
+
+		Context("with changes in the configuration of a resource", func() {
+			It("should return the resource and the modules which require it", func() {
+				changedPaths := []string{"config/db.json"}
+				changedResources := util.FindChangedResources(descriptor, changedPaths)
+				Expect(changedResources).To(Equal([]string{"db"}))
+				Expect(util.FindAffectedModules(descriptor, changedPaths, changedResources)).To(Equal([]string{"backend"}))
+			})
+		})
+
+		Context("with changes in the configuration of a required dependency", func() {
+			It("should return only the module which requires it with that configuration", func() {
+				changedPaths := []string{"config/backend-uaa.json"}
+				changedResources := util.FindChangedResources(descriptor, changedPaths)
+				Expect(changedResources).To(BeEmpty())
+				Expect(util.FindAffectedModules(descriptor, changedPaths, changedResources)).To(Equal([]string{"backend"}))
+			})
+		})
+
+		Context("with changes in a module and a resource required by another module", func() {
+			It("should return both modules", func() {
+				changedPaths := []string{"backend/pom.xml", "config/xs-security.json"}
+				changedResources := util.FindChangedResources(descriptor, changedPaths)
+				Expect(changedResources).To(Equal([]string{"uaa"}))
+				Expect(util.FindAffectedModules(descriptor, changedPaths, changedResources)).To(Equal([]string{"backend", "ui"}))
+			})
+		})
+	})
+})
@@ -12,9 +12,19 @@ type Module struct {
 	Name                 string               `yaml:"name"`
 	Type                 string               `yaml:"type"`
 	Path                 string               `yaml:"path"`
+	Paths                []string             `yaml:"paths,omitempty"`
 	RequiredDependencies []RequiredDependency `yaml:"requires,omitempty"`
 }
 
+// ContentPaths returns all paths and glob patterns from which the module content is assembled
+func (module Module) ContentPaths() []string {
+	result := make([]string, 0, len(module.Paths)+1)
+	if module.Path != "" {
+		result = append(result, module.Path)
+	}
+	return append(result, module.Paths...)
+}
+
 type Resource struct {
 	Name       string                 `yaml:"name"`
 	Type       string                 `yaml:"type"`
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"os"
 	"path/filepath"
+	"sort"
+	"unicode/utf8"
 )
 
 const ContentTypeAttribute string = "Content-Type"
@@ -14,8 +16,12 @@ const MtaResource string = "MTA-Resource"
 const MtaRequires string = "MTA-Requires"
 const MtaModule string = "MTA-Module"
 const MtadAttribute string = "META-INF/mtad.yaml"
+const Sha256DigestAttribute string = "SHA-256-Digest"
 const SectionSeparator string = "\n"
 
+// maxManifestLineLength is the maximum length of a MANIFEST.MF line in bytes, as defined by the JAR file specification
+const maxManifestLineLength int = 72
+
 type MtaManifest struct {
 	ManifestVersion  string
 	ManifestSections []ManifestSection
@@ -52,11 +58,7 @@ func (builder *MtaManifestBuilder) Build() (string, error) {
 	defer file.Close()
 
 	fileWriter := bufio.NewWriter(file)
-	_, err = fileWriter.WriteString(getManifestVersion(builder.manifest))
-	if err != nil {
-		return "", err
-	}
-	_, err = fileWriter.WriteString(SectionSeparator)
+	err = writeManifestLine(fileWriter, getManifestVersion(builder.manifest))
 	if err != nil {
 		return "", err
 	}
@@ -66,11 +68,7 @@ func (builder *MtaManifestBuilder) Build() (string, error) {
 	}
 
 	for _, section := range builder.manifest.ManifestSections {
-		_, err = fileWriter.WriteString(section.Name)
-		if err != nil {
-			return "", err
-		}
-		_, err = fileWriter.WriteString(SectionSeparator)
+		err = writeManifestLine(fileWriter, section.Name)
 		if err != nil {
 			return "", err
 		}
@@ -94,17 +92,39 @@ func getManifestVersion(manifest MtaManifest) string {
 }
 
 func writeSectionAttributes(fileWriter *bufio.Writer, attributes map[string]string) error {
-	for attrName, attrValue := range attributes {
-		_, err := fileWriter.WriteString(attrName + ": " + attrValue)
+	attrNames := make([]string, 0, len(attributes))
+	for attrName := range attributes {
+		attrNames = append(attrNames, attrName)
+	}
+	sort.Strings(attrNames)
+	for _, attrName := range attrNames {
+		err := writeManifestLine(fileWriter, attrName+": "+attributes[attrName])
 		if err != nil {
 			return err
 		}
-		_, err = fileWriter.WriteString(SectionSeparator)
+	}
+	return nil
+}
+
+// writeManifestLine writes the line followed by a line separator. Lines longer than 72 bytes are wrapped
+// into continuation lines starting with a single space, without splitting multi-byte characters.
+func writeManifestLine(fileWriter *bufio.Writer, line string) error {
+	lineLimit := maxManifestLineLength
+	for len(line) > lineLimit {
+		splitIndex := lineLimit
+		for splitIndex > 0 && !utf8.RuneStart(line[splitIndex]) {
+			splitIndex--
+		}
+		_, err := fileWriter.WriteString(line[:splitIndex] + SectionSeparator + " ")
 		if err != nil {
 			return err
 		}
+		line = line[splitIndex:]
+		// continuation lines start with a space, which counts towards the limit
+		lineLimit = maxManifestLineLength - 1
 	}
-	return nil
+	_, err := fileWriter.WriteString(line + SectionSeparator)
+	return err
 }
 
 type MtaManifestSectionBuilder struct {
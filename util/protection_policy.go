@@ -0,0 +1,64 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProtectionPolicyEnv is the environment variable which points to the local protection policy file
+const ProtectionPolicyEnv = "MULTIAPPS_PROTECTION_POLICY"
+
+// ProtectionPolicy declares the MTAs which refuse destructive operations unless the protection is explicitly overridden
+type ProtectionPolicy struct {
+	Protected []ProtectedMta `yaml:"protected"`
+}
+
+// ProtectedMta matches MTAs by ID, by namespace or by both. An empty field matches any value.
+type ProtectedMta struct {
+	MtaID     string `yaml:"mta-id,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// GetProtectionPolicy parses the protection policy file configured through the environment. If no file is configured,
+// an empty policy is returned.
+func GetProtectionPolicy() (ProtectionPolicy, error) {
+	policyLocation := os.Getenv(ProtectionPolicyEnv)
+	if policyLocation == "" {
+		return ProtectionPolicy{}, nil
+	}
+	return ParseProtectionPolicy(policyLocation)
+}
+
+// ParseProtectionPolicy parses and validates the protection policy at the provided location
+func ParseProtectionPolicy(policyLocation string) (ProtectionPolicy, error) {
+	policyYaml, err := os.ReadFile(policyLocation)
+	if err != nil {
+		return ProtectionPolicy{}, fmt.Errorf("Could not read protection policy: %s", err.Error())
+	}
+	var policy ProtectionPolicy
+	decoder := yaml.NewDecoder(bytes.NewReader(policyYaml))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&policy)
+	if err != nil {
+		return ProtectionPolicy{}, fmt.Errorf("Could not unmarshal protection policy from yaml: %s", err.Error())
+	}
+	for i, protectedMta := range policy.Protected {
+		if protectedMta.MtaID == "" && protectedMta.Namespace == "" {
+			return ProtectionPolicy{}, fmt.Errorf("Invalid protection policy: The entry at position %d must have an mta-id or a namespace", i+1)
+		}
+	}
+	return policy, nil
+}
+
+// IsProtected checks whether the MTA with the specified ID and namespace is protected by the policy
+func (policy ProtectionPolicy) IsProtected(mtaID, namespace string) bool {
+	for _, protectedMta := range policy.Protected {
+		if (protectedMta.MtaID == "" || protectedMta.MtaID == mtaID) && (protectedMta.Namespace == "" || protectedMta.Namespace == namespace) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProtectionPolicy", func() {
+	var policyDirectory string
+	var policyLocation string
+
+	var writePolicy = func(content string) {
+		os.WriteFile(policyLocation, []byte(content), os.ModePerm)
+	}
+
+	BeforeEach(func() {
+		policyDirectory, _ = os.MkdirTemp("", "protection-policy")
+		policyLocation = filepath.Join(policyDirectory, "protection-policy.yaml")
+	})
+
+	Describe("ParseProtectionPolicy", func() {
+		Context("with a valid policy", func() {
+			It("should protect the matching multi-target apps", func() {
+				writePolicy("protected:\n- mta-id: billing\n- namespace: prod\n- mta-id: orders\n  namespace: eu\n")
+				policy, err := util.ParseProtectionPolicy(policyLocation)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.IsProtected("billing", "")).To(BeTrue())
+				Expect(policy.IsProtected("billing", "dev")).To(BeTrue())
+				Expect(policy.IsProtected("shop", "prod")).To(BeTrue())
+				Expect(policy.IsProtected("orders", "eu")).To(BeTrue())
+				Expect(policy.IsProtected("orders", "us")).To(BeFalse())
+				Expect(policy.IsProtected("shop", "")).To(BeFalse())
+			})
+		})
+
+		Context("with an entry which has neither an mta id nor a namespace", func() {
+			It("should return an error", func() {
+				writePolicy("protected:\n- mta-id: billing\n- {}\n")
+				_, err := util.ParseProtectionPolicy(policyLocation)
+				Expect(err).To(MatchError("Invalid protection policy: The entry at position 2 must have an mta-id or a namespace"))
+			})
+		})
+
+		Context("with an unknown field", func() {
+			It("should return an error", func() {
+				writePolicy("protected:\n- id: billing\n")
+				_, err := util.ParseProtectionPolicy(policyLocation)
+				Expect(err).To(MatchError(ContainSubstring("Could not unmarshal protection policy from yaml")))
+			})
+		})
+	})
+
+	Describe("GetProtectionPolicy", func() {
+		Context("without a configured policy file", func() {
+			It("should return an empty policy", func() {
+				os.Unsetenv(util.ProtectionPolicyEnv)
+				policy, err := util.GetProtectionPolicy()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.IsProtected("billing", "")).To(BeFalse())
+			})
+		})
+
+		Context("with a configured policy file", func() {
+			It("should parse the policy", func() {
+				writePolicy("protected:\n- mta-id: billing\n")
+				os.Setenv(util.ProtectionPolicyEnv, policyLocation)
+				defer os.Unsetenv(util.ProtectionPolicyEnv)
+				policy, err := util.GetProtectionPolicy()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(policy.IsProtected("billing", "")).To(BeTrue())
+			})
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(policyDirectory)
+	})
+})
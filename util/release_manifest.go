@@ -0,0 +1,143 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReleaseManifest describes a set of MTAs which are deployed together, in the order defined by their dependencies
+type ReleaseManifest struct {
+	MTAs []ReleaseMta `yaml:"mtas"`
+}
+
+// ReleaseMta describes the deployment of a single MTA of a release
+type ReleaseMta struct {
+	Name                 string   `yaml:"name"`
+	Archive              string   `yaml:"archive"`
+	ExtensionDescriptors []string `yaml:"extension-descriptors,omitempty"`
+	Namespace            string   `yaml:"namespace,omitempty"`
+	Strategy             string   `yaml:"strategy,omitempty"`
+	DependsOn            []string `yaml:"depends-on,omitempty"`
+}
+
+// ParseReleaseManifest parses and validates the release manifest at the provided location. The archive and extension
+// descriptor paths of the MTAs are resolved relative to the directory of the release manifest.
+func ParseReleaseManifest(manifestLocation string) (ReleaseManifest, error) {
+	manifestYaml, err := os.ReadFile(manifestLocation)
+	if err != nil {
+		return ReleaseManifest{}, fmt.Errorf("Could not read release manifest: %s", err.Error())
+	}
+	var manifest ReleaseManifest
+	decoder := yaml.NewDecoder(bytes.NewReader(manifestYaml))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&manifest)
+	if err != nil {
+		return ReleaseManifest{}, fmt.Errorf("Could not unmarshal release manifest from yaml: %s", err.Error())
+	}
+	err = manifest.validate()
+	if err != nil {
+		return ReleaseManifest{}, fmt.Errorf("Invalid release manifest: %s", err.Error())
+	}
+
+	manifestDirectory := filepath.Dir(manifestLocation)
+	for i := range manifest.MTAs {
+		manifest.MTAs[i].Archive = resolvePath(manifestDirectory, manifest.MTAs[i].Archive)
+		for j, extensionDescriptor := range manifest.MTAs[i].ExtensionDescriptors {
+			manifest.MTAs[i].ExtensionDescriptors[j] = resolvePath(manifestDirectory, extensionDescriptor)
+		}
+	}
+	return manifest, nil
+}
+
+// GetMta returns the MTA with the specified name
+func (manifest ReleaseManifest) GetMta(name string) (ReleaseMta, bool) {
+	for _, mta := range manifest.MTAs {
+		if mta.Name == name {
+			return mta, true
+		}
+	}
+	return ReleaseMta{}, false
+}
+
+func (manifest ReleaseManifest) validate() error {
+	if len(manifest.MTAs) == 0 {
+		return fmt.Errorf("No MTAs are defined")
+	}
+	names := make(map[string]bool)
+	for i, mta := range manifest.MTAs {
+		if mta.Name == "" {
+			return fmt.Errorf("The MTA at position %d has no name", i+1)
+		}
+		if names[mta.Name] {
+			return fmt.Errorf("The MTA %s is defined more than once", mta.Name)
+		}
+		names[mta.Name] = true
+		if mta.Archive == "" {
+			return fmt.Errorf("The MTA %s has no archive", mta.Name)
+		}
+	}
+	for _, mta := range manifest.MTAs {
+		for _, dependency := range mta.DependsOn {
+			if !names[dependency] {
+				return fmt.Errorf("The MTA %s depends on the unknown MTA %s", mta.Name, dependency)
+			}
+		}
+	}
+	if cycle := manifest.findDependencyCycle(); len(cycle) != 0 {
+		return fmt.Errorf("The MTAs have cyclic dependencies: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+func (manifest ReleaseManifest) findDependencyCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		states[name] = visiting
+		path = append(path, name)
+		mta, _ := manifest.GetMta(name)
+		for _, dependency := range mta.DependsOn {
+			switch states[dependency] {
+			case visiting:
+				for i, element := range path {
+					if element == dependency {
+						return append(append([]string{}, path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[name] = visited
+		return nil
+	}
+	for _, mta := range manifest.MTAs {
+		if states[mta.Name] == unvisited {
+			if cycle := visit(mta.Name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func resolvePath(baseDirectory, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDirectory, path)
+}
@@ -0,0 +1,102 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReleaseManifest", func() {
+	Describe("ParseReleaseManifest", func() {
+		var manifestDirectory string
+		var manifestLocation string
+
+		var writeManifest = func(content string) {
+			os.WriteFile(manifestLocation, []byte(content), os.ModePerm)
+		}
+
+		BeforeEach(func() {
+			manifestDirectory, _ = os.MkdirTemp("", "release-manifest")
+			manifestLocation = filepath.Join(manifestDirectory, "release.yaml")
+		})
+
+		Context("with a valid release manifest", func() {
+			It("should resolve the paths relative to the manifest directory", func() {
+				writeManifest(`mtas:
+- name: backend
+  archive: backend.mtar
+  extension-descriptors: [dev.mtaext, /tmp/secrets.mtaext]
+  namespace: dev
+  strategy: blue-green
+- name: ui
+  archive: ui
+  depends-on: [backend]
+`)
+				manifest, err := util.ParseReleaseManifest(manifestLocation)
+				Expect(err).To(BeNil())
+				Expect(manifest.MTAs).To(Equal([]util.ReleaseMta{
+					{Name: "backend", Archive: filepath.Join(manifestDirectory, "backend.mtar"),
+						ExtensionDescriptors: []string{filepath.Join(manifestDirectory, "dev.mtaext"), "/tmp/secrets.mtaext"},
+						Namespace:            "dev", Strategy: "blue-green"},
+					{Name: "ui", Archive: filepath.Join(manifestDirectory, "ui"), DependsOn: []string{"backend"}},
+				}))
+			})
+		})
+
+		Context("with an unknown field", func() {
+			It("should return an error", func() {
+				writeManifest("mtas:\n- name: backend\n  archive: backend.mtar\n  dependson: [ui]\n")
+				_, err := util.ParseReleaseManifest(manifestLocation)
+				Expect(err).To(MatchError(HavePrefix("Could not unmarshal release manifest from yaml: ")))
+			})
+		})
+
+		Context("with duplicate MTA names", func() {
+			It("should return an error", func() {
+				writeManifest("mtas:\n- name: backend\n  archive: a.mtar\n- name: backend\n  archive: b.mtar\n")
+				_, err := util.ParseReleaseManifest(manifestLocation)
+				Expect(err).To(MatchError("Invalid release manifest: The MTA backend is defined more than once"))
+			})
+		})
+
+		Context("with a dependency on an unknown MTA", func() {
+			It("should return an error", func() {
+				writeManifest("mtas:\n- name: ui\n  archive: ui.mtar\n  depends-on: [backend]\n")
+				_, err := util.ParseReleaseManifest(manifestLocation)
+				Expect(err).To(MatchError("Invalid release manifest: The MTA ui depends on the unknown MTA backend"))
+			})
+		})
+
+		Context("with cyclic dependencies", func() {
+			It("should return an error naming the cycle", func() {
+				writeManifest(`mtas:
+- name: db
+  archive: db.mtar
+- name: backend
+  archive: backend.mtar
+  depends-on: [db, ui]
+- name: ui
+  archive: ui.mtar
+  depends-on: [backend]
+`)
+				_, err := util.ParseReleaseManifest(manifestLocation)
+				Expect(err).To(MatchError("Invalid release manifest: The MTAs have cyclic dependencies: backend -> ui -> backend"))
+			})
+		})
+
+		Context("with no MTAs", func() {
+			It("should return an error", func() {
+				writeManifest("mtas: []\n")
+				_, err := util.ParseReleaseManifest(manifestLocation)
+				Expect(err).To(MatchError("Invalid release manifest: No MTAs are defined"))
+			})
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(manifestDirectory)
+		})
+	})
+})
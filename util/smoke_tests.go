@@ -0,0 +1,97 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SmokeTestsConfig declares the HTTP endpoints which are probed after the deployment of an MTA
+type SmokeTestsConfig struct {
+	Modules []SmokeTestsModule `yaml:"modules"`
+}
+
+// SmokeTestsModule declares the HTTP endpoints of a single module
+type SmokeTestsModule struct {
+	Name      string              `yaml:"name"`
+	Endpoints []SmokeTestEndpoint `yaml:"endpoints"`
+}
+
+// SmokeTestEndpoint describes an HTTP endpoint and the status codes which it is expected to respond with. The endpoint
+// is either an absolute URL, or a path which is appended to each route of the application of the module.
+type SmokeTestEndpoint struct {
+	URL                 string `yaml:"url,omitempty"`
+	Path                string `yaml:"path,omitempty"`
+	ExpectedStatusCodes []int  `yaml:"expected-status-codes,omitempty"`
+}
+
+// ParseSmokeTestsConfig parses and validates the smoke tests configuration at the provided location
+func ParseSmokeTestsConfig(configLocation string) (SmokeTestsConfig, error) {
+	configYaml, err := os.ReadFile(configLocation)
+	if err != nil {
+		return SmokeTestsConfig{}, fmt.Errorf("Could not read smoke tests configuration: %s", err.Error())
+	}
+	var config SmokeTestsConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(configYaml))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&config)
+	if err != nil {
+		return SmokeTestsConfig{}, fmt.Errorf("Could not unmarshal smoke tests configuration from yaml: %s", err.Error())
+	}
+	err = config.validate()
+	if err != nil {
+		return SmokeTestsConfig{}, fmt.Errorf("Invalid smoke tests configuration: %s", err.Error())
+	}
+	return config, nil
+}
+
+// GetEndpoints returns the endpoints declared for the module with the specified name
+func (config SmokeTestsConfig) GetEndpoints(moduleName string) ([]SmokeTestEndpoint, bool) {
+	for _, module := range config.Modules {
+		if module.Name == moduleName {
+			return module.Endpoints, true
+		}
+	}
+	return nil, false
+}
+
+// IsExpectedStatusCode checks whether the status code is one of the expected ones. If no status codes are declared,
+// every successful status code is expected.
+func (endpoint SmokeTestEndpoint) IsExpectedStatusCode(statusCode int) bool {
+	if len(endpoint.ExpectedStatusCodes) == 0 {
+		return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+	}
+	for _, expectedStatusCode := range endpoint.ExpectedStatusCodes {
+		if expectedStatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (config SmokeTestsConfig) validate() error {
+	names := make(map[string]bool)
+	for i, module := range config.Modules {
+		if module.Name == "" {
+			return fmt.Errorf("The module at position %d has no name", i+1)
+		}
+		if names[module.Name] {
+			return fmt.Errorf("The module %s is defined more than once", module.Name)
+		}
+		names[module.Name] = true
+		for j, endpoint := range module.Endpoints {
+			if (endpoint.URL == "") == (endpoint.Path == "") {
+				return fmt.Errorf("The endpoint at position %d of module %s must have either a url or a path", j+1, module.Name)
+			}
+			for _, statusCode := range endpoint.ExpectedStatusCodes {
+				if statusCode < 100 || statusCode > 599 {
+					return fmt.Errorf("The endpoint at position %d of module %s has an invalid expected status code %d", j+1, module.Name, statusCode)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SmokeTests", func() {
+	Describe("ParseSmokeTestsConfig", func() {
+		var configDirectory string
+		var configLocation string
+
+		var writeConfig = func(content string) {
+			os.WriteFile(configLocation, []byte(content), os.ModePerm)
+		}
+
+		BeforeEach(func() {
+			configDirectory, _ = os.MkdirTemp("", "smoke-tests")
+			configLocation = filepath.Join(configDirectory, "smoke-tests.yaml")
+		})
+
+		Context("with a valid configuration", func() {
+			It("should return the endpoints of the modules", func() {
+				writeConfig(`modules:
+- name: backend
+  endpoints:
+  - path: /health
+    expected-status-codes: [200, 204]
+  - url: https://status.example.com
+`)
+				config, err := util.ParseSmokeTestsConfig(configLocation)
+				Expect(err).ToNot(HaveOccurred())
+				endpoints, declared := config.GetEndpoints("backend")
+				Expect(declared).To(BeTrue())
+				Expect(endpoints).To(Equal([]util.SmokeTestEndpoint{
+					{Path: "/health", ExpectedStatusCodes: []int{200, 204}},
+					{URL: "https://status.example.com"},
+				}))
+				_, declared = config.GetEndpoints("ui")
+				Expect(declared).To(BeFalse())
+			})
+		})
+
+		Context("with an endpoint which has both a url and a path", func() {
+			It("should return an error", func() {
+				writeConfig("modules:\n- name: backend\n  endpoints:\n  - path: /health\n    url: https://status.example.com\n")
+				_, err := util.ParseSmokeTestsConfig(configLocation)
+				Expect(err).To(MatchError("Invalid smoke tests configuration: The endpoint at position 1 of module backend must have either a url or a path"))
+			})
+		})
+
+		Context("with an invalid expected status code", func() {
+			It("should return an error", func() {
+				writeConfig("modules:\n- name: backend\n  endpoints:\n  - path: /health\n    expected-status-codes: [2000]\n")
+				_, err := util.ParseSmokeTestsConfig(configLocation)
+				Expect(err).To(MatchError("Invalid smoke tests configuration: The endpoint at position 1 of module backend has an invalid expected status code 2000"))
+			})
+		})
+
+		Context("with an unknown field", func() {
+			It("should return an error", func() {
+				writeConfig("modules:\n- name: backend\n  routes: [/health]\n")
+				_, err := util.ParseSmokeTestsConfig(configLocation)
+				Expect(err).To(MatchError(ContainSubstring("Could not unmarshal smoke tests configuration from yaml")))
+			})
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(configDirectory)
+		})
+	})
+
+	Describe("IsExpectedStatusCode", func() {
+		Context("without declared status codes", func() {
+			It("should expect the successful status codes", func() {
+				endpoint := util.SmokeTestEndpoint{Path: "/"}
+				Expect(endpoint.IsExpectedStatusCode(200)).To(BeTrue())
+				Expect(endpoint.IsExpectedStatusCode(204)).To(BeTrue())
+				Expect(endpoint.IsExpectedStatusCode(302)).To(BeFalse())
+				Expect(endpoint.IsExpectedStatusCode(-1)).To(BeFalse())
+			})
+		})
+
+		Context("with declared status codes", func() {
+			It("should expect only the declared status codes", func() {
+				endpoint := util.SmokeTestEndpoint{Path: "/", ExpectedStatusCodes: []int{401}}
+				Expect(endpoint.IsExpectedStatusCode(401)).To(BeTrue())
+				Expect(endpoint.IsExpectedStatusCode(200)).To(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// WriteTAPReport writes the test cases of the suites as a TAP version 13 stream to the file at the specified location,
+// replacing any existing content. Failure messages are written as YAML diagnostics of the failed test points.
+func WriteTAPReport(location string, suites []JUnitTestSuite) error {
+	var content strings.Builder
+	total := 0
+	for _, suite := range suites {
+		total += len(suite.Cases)
+	}
+	content.WriteString("TAP version 13\n")
+	fmt.Fprintf(&content, "1..%d\n", total)
+	number := 0
+	for _, suite := range suites {
+		fmt.Fprintf(&content, "# %s\n", suite.Name)
+		for _, testCase := range suite.Cases {
+			number++
+			result := "ok"
+			if testCase.Failure != nil {
+				result = "not ok"
+			}
+			fmt.Fprintf(&content, "%s %d - %s %s # time=%.3fs\n", result, number, testCase.ClassName, testCase.Name, testCase.Time)
+			if testCase.Failure != nil {
+				fmt.Fprintf(&content, "  ---\n  message: %q\n  ...\n", testCase.Failure.Message)
+			}
+		}
+	}
+	return os.WriteFile(location, []byte(content.String()), 0644)
+}
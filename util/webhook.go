@@ -0,0 +1,219 @@
+package util
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// WebhookConfigEnv is the environment variable which points to the YAML file with the headers, the events and the
+	// payload templates of the webhook notifications
+	WebhookConfigEnv = "MULTIAPPS_WEBHOOK_CONFIG"
+	// WebhookSecretEnv is the environment variable with the secret with which the webhook payloads are signed
+	WebhookSecretEnv = "MULTIAPPS_WEBHOOK_SECRET"
+	// WebhookSignatureHeader holds the hex encoded HMAC-SHA256 of the payload, prefixed with sha256=
+	WebhookSignatureHeader = "X-Multiapps-Signature"
+
+	defaultWebhookTemplate = "default"
+	maxWebhookAttempts     = 3
+	webhookRequestTimeout  = 10 * time.Second
+)
+
+// Events of a monitored operation which are sent to the webhook
+const (
+	WebhookEventStarted        = "started"
+	WebhookEventActionRequired = "action-required"
+	WebhookEventFinished       = "finished"
+	WebhookEventFailed         = "failed"
+)
+
+var webhookEvents = []string{WebhookEventStarted, WebhookEventActionRequired, WebhookEventFinished, WebhookEventFailed}
+
+// WebhookPayload is the notification about a state change of an operation. It is sent as JSON, unless a template is
+// configured for the event, in which case it is the data of the template.
+type WebhookPayload struct {
+	Event       string    `json:"event"`
+	Timestamp   time.Time `json:"timestamp"`
+	Org         string    `json:"org,omitempty"`
+	Space       string    `json:"space,omitempty"`
+	MtaID       string    `json:"mtaId,omitempty"`
+	MtaVersion  string    `json:"mtaVersion,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	ProcessID   string    `json:"processId"`
+	ProcessType string    `json:"processType,omitempty"`
+	State       string    `json:"state"`
+	Message     string    `json:"message,omitempty"`
+	LogsHint    string    `json:"logsHint"`
+}
+
+// WebhookConfig customizes the webhook notifications
+type WebhookConfig struct {
+	// Events to notify about. All events are notified if none are listed.
+	Events []string `yaml:"events"`
+	// Headers added to each request
+	Headers map[string]string `yaml:"headers"`
+	// Templates of the JSON payloads by event, with the payload as data. The default template applies to all events
+	// without a template of their own.
+	Templates map[string]string `yaml:"templates"`
+}
+
+// WebhookNotifier posts the state changes of operations to a webhook URL
+type WebhookNotifier struct {
+	url           string
+	secret        string
+	config        WebhookConfig
+	templates     map[string]*template.Template
+	client        *http.Client
+	retryInterval time.Duration
+}
+
+// NewWebhookNotifier creates a notifier for the URL, configured through MULTIAPPS_WEBHOOK_CONFIG and
+// MULTIAPPS_WEBHOOK_SECRET
+func NewWebhookNotifier(webhookURL string) (*WebhookNotifier, error) {
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, errors.New("Invalid webhook URL, expected an http or https URL")
+	}
+	config, err := readWebhookConfig(os.Getenv(WebhookConfigEnv))
+	if err != nil {
+		return nil, err
+	}
+	notifier := &WebhookNotifier{
+		url:           webhookURL,
+		secret:        os.Getenv(WebhookSecretEnv),
+		config:        config,
+		templates:     make(map[string]*template.Template),
+		client:        &http.Client{Timeout: webhookRequestTimeout},
+		retryInterval: 2 * time.Second,
+	}
+	for event, text := range config.Templates {
+		if event != defaultWebhookTemplate && !Contains(webhookEvents, event) {
+			return nil, fmt.Errorf("Invalid webhook configuration: Unknown event %q, expected one of %s", event, strings.Join(webhookEvents, ", "))
+		}
+		parsedTemplate, err := template.New(event).Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid webhook configuration: Could not parse the template of event %q: %s", event, err)
+		}
+		notifier.templates[event] = parsedTemplate
+	}
+	for _, event := range config.Events {
+		if !Contains(webhookEvents, event) {
+			return nil, fmt.Errorf("Invalid webhook configuration: Unknown event %q, expected one of %s", event, strings.Join(webhookEvents, ", "))
+		}
+	}
+	return notifier, nil
+}
+
+// Notify posts the payload to the webhook, unless its event is not configured. Requests which fail because of the
+// network, a server error or rate limiting are retried.
+func (n *WebhookNotifier) Notify(payload WebhookPayload) error {
+	if len(n.config.Events) != 0 && !Contains(n.config.Events, payload.Event) {
+		return nil
+	}
+	body, err := n.renderPayload(payload)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		retryable, err := n.post(body)
+		if err == nil || !retryable || attempt == maxWebhookAttempts {
+			return err
+		}
+		time.Sleep(n.retryInterval)
+	}
+}
+
+func (n *WebhookNotifier) renderPayload(payload WebhookPayload) ([]byte, error) {
+	payloadTemplate, exists := n.templates[payload.Event]
+	if !exists {
+		payloadTemplate, exists = n.templates[defaultWebhookTemplate]
+	}
+	if !exists {
+		return json.Marshal(payload)
+	}
+	var body bytes.Buffer
+	if err := payloadTemplate.Execute(&body, payload); err != nil {
+		return nil, fmt.Errorf("Could not render the webhook payload of event %q: %s", payload.Event, err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("The template of event %q did not render valid JSON", payload.Event)
+	}
+	return body.Bytes(), nil
+}
+
+// post sends the body and returns whether a failed request can be retried
+func (n *WebhookNotifier) post(body []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, stripURL(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range n.config.Headers {
+		request.Header.Set(name, value)
+	}
+	if n.secret != "" {
+		request.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(body, n.secret))
+	}
+	response, err := n.client.Do(request)
+	if err != nil {
+		return true, stripURL(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retryable, fmt.Errorf("Webhook responded with status %s", response.Status)
+}
+
+// stripURL removes the URL of the webhook, which often contains a token, from errors of requests to it
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s request to the webhook failed: %s", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of the payload with the secret, as sent in the signature header
+func SignWebhookPayload(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func readWebhookConfig(location string) (WebhookConfig, error) {
+	if location == "" {
+		return WebhookConfig{}, nil
+	}
+	content, err := os.ReadFile(location)
+	if err != nil {
+		return WebhookConfig{}, fmt.Errorf("Could not read webhook configuration: %s", err)
+	}
+	var config WebhookConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return WebhookConfig{}, fmt.Errorf("Could not unmarshal webhook configuration from yaml: %s", err)
+	}
+	return config, nil
+}
+
+// toJSON quotes the value as JSON, so that templates can embed arbitrary text in string fields
+func toJSON(value interface{}) (string, error) {
+	content, err := json.Marshal(value)
+	return string(content), err
+}
@@ -0,0 +1,138 @@
+package util_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/multiapps-cli-plugin/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebhookNotifier", func() {
+	var server *httptest.Server
+	var requests []*http.Request
+	var bodies [][]byte
+	var statusCodes []int
+	var configDirectory string
+
+	var payload = util.WebhookPayload{Event: util.WebhookEventFailed, Org: "org", Space: "space", MtaID: "shop", MtaVersion: "1.0.0",
+		ProcessID: "1000", State: "ERROR", Message: "Application \"shop-web\" failed to start", LogsHint: "cf dmol -i 1000"}
+
+	var writeConfig = func(content string) {
+		location := filepath.Join(configDirectory, "webhook.yml")
+		Expect(os.WriteFile(location, []byte(content), 0644)).To(Succeed())
+		os.Setenv(util.WebhookConfigEnv, location)
+	}
+
+	BeforeEach(func() {
+		requests, bodies, statusCodes = nil, nil, nil
+		configDirectory, _ = os.MkdirTemp("", "webhook")
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, body)
+			statusCode := http.StatusOK
+			if len(statusCodes) != 0 {
+				statusCode, statusCodes = statusCodes[0], statusCodes[1:]
+			}
+			w.WriteHeader(statusCode)
+		}))
+	})
+
+	Context("without configuration", func() {
+		It("should post the payload as JSON", func() {
+			notifier, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(notifier.Notify(payload)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(requests[0].Header.Get(util.WebhookSignatureHeader)).To(BeEmpty())
+			var sentPayload util.WebhookPayload
+			Expect(json.Unmarshal(bodies[0], &sentPayload)).To(Succeed())
+			Expect(sentPayload).To(Equal(payload))
+		})
+	})
+
+	Context("with a signing secret", func() {
+		It("should sign the payload", func() {
+			os.Setenv(util.WebhookSecretEnv, "webhook-secret")
+			notifier, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(notifier.Notify(payload)).To(Succeed())
+			Expect(requests[0].Header.Get(util.WebhookSignatureHeader)).To(Equal("sha256=" + util.SignWebhookPayload(bodies[0], "webhook-secret")))
+		})
+	})
+
+	Context("with a configuration", func() {
+		It("should render the template of the event and add the headers", func() {
+			writeConfig(`headers:
+  Authorization: Bearer token
+templates:
+  failed: '{"text": {{json (printf "%s %s failed: %s" .MtaID .MtaVersion .Message)}}}'
+`)
+			notifier, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(notifier.Notify(payload)).To(Succeed())
+			Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(string(bodies[0])).To(Equal(`{"text": "shop 1.0.0 failed: Application \"shop-web\" failed to start"}`))
+		})
+
+		It("should not notify about events which are not configured", func() {
+			writeConfig("events: [finished]\n")
+			notifier, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(notifier.Notify(payload)).To(Succeed())
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("should reject unknown events", func() {
+			writeConfig("templates:\n  deployed: '{}'\n")
+			_, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).To(MatchError(`Invalid webhook configuration: Unknown event "deployed", expected one of started, action-required, finished, failed`))
+		})
+	})
+
+	Context("with a failing webhook", func() {
+		It("should retry server errors", func() {
+			statusCodes = []int{http.StatusServiceUnavailable}
+			notifier, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(notifier.Notify(payload)).To(Succeed())
+			Expect(requests).To(HaveLen(2))
+		})
+
+		It("should not retry client errors", func() {
+			statusCodes = []int{http.StatusBadRequest}
+			notifier, err := util.NewWebhookNotifier(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(notifier.Notify(payload)).To(MatchError("Webhook responded with status 400 Bad Request"))
+			Expect(requests).To(HaveLen(1))
+		})
+
+		It("should not include the URL in the error of an unreachable webhook", func() {
+			notifier, err := util.NewWebhookNotifier(server.URL + "/hooks/secret-token")
+			Expect(err).ToNot(HaveOccurred())
+			server.Close()
+			err = notifier.Notify(payload)
+			Expect(err).To(MatchError(HavePrefix("Post request to the webhook failed: ")))
+			Expect(err.Error()).ToNot(ContainSubstring("secret-token"))
+		})
+	})
+
+	It("should reject URLs which are not http or https", func() {
+		_, err := util.NewWebhookNotifier("hooks.example.com/deploy")
+		Expect(err).To(MatchError("Invalid webhook URL, expected an http or https URL"))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Unsetenv(util.WebhookConfigEnv)
+		os.Unsetenv(util.WebhookSecretEnv)
+		os.RemoveAll(configDirectory)
+	})
+})